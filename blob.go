@@ -0,0 +1,188 @@
+package keydbr
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BlobChunkSize is the size of the chunks of a blob, which is below the default message limit of the server
+const BlobChunkSize = 1 << 20
+
+// blobBatchChunks is the number of chunks WriteBlob commits in each transaction, which bounds the writes held in
+// memory by the client and the server
+const blobBatchChunks = 4
+
+// manifestSize is the size of a blob's manifest, the 8 byte generation, the 8 byte size and the 4 byte chunk count
+const manifestSize = 20
+
+// BlobChanged is returned by ReadBlob if the blob is replaced or removed while it is being read
+var BlobChanged = errors.New("blob changed while being read")
+
+// manifest is the entry stored under a blob's key once all of its chunks are committed
+type manifest struct {
+	generation uint64
+	size       int64
+	chunks     uint32
+}
+
+func (m manifest) encode() []byte {
+	b := make([]byte, manifestSize)
+	binary.BigEndian.PutUint64(b, m.generation)
+	binary.BigEndian.PutUint64(b[8:], uint64(m.size))
+	binary.BigEndian.PutUint32(b[16:], m.chunks)
+	return b
+}
+
+// readManifest returns the manifest of the blob key, or KeyNotFound
+func readManifest(tx Transaction, key []byte) (manifest, error) {
+	b, err := tx.Get(key)
+	if err != nil {
+		return manifest{}, err
+	}
+	if len(b) != manifestSize {
+		return manifest{}, fmt.Errorf("%q is not a blob", key)
+	}
+	return manifest{generation: binary.BigEndian.Uint64(b), size: int64(binary.BigEndian.Uint64(b[8:])),
+		chunks: binary.BigEndian.Uint32(b[16:])}, nil
+}
+
+// chunkKey returns the key of chunk index of a generation of the blob key, which is the key followed by the 8 byte
+// big endian generation and the 4 byte big endian index, so the chunks of each write of a blob have their own keys
+func chunkKey(key []byte, generation uint64, index uint32) []byte {
+	chunk := make([]byte, len(key)+12)
+	copy(chunk, key)
+	binary.BigEndian.PutUint64(chunk[len(key):], generation)
+	binary.BigEndian.PutUint32(chunk[len(key)+8:], index)
+	return chunk
+}
+
+// WriteBlob stores the contents of r as the blob key in table, returning the number of bytes written. A blob is
+// stored as entries of at most BlobChunkSize bytes, which are committed a few at a time so values larger than a
+// message are stored with bounded memory, and then a manifest is committed under key, so readers see the previous
+// blob until the whole blob is written. The chunks of the previous blob are then removed. If WriteBlob fails, the
+// chunks it wrote are removed and the previous blob remains. The entries are visible to Lookup, so blobs should be
+// kept in tables of their own, and a blob should not be written concurrently, which may leave unused chunks.
+func WriteBlob(db Database, table string, key []byte, r io.Reader) (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	m := manifest{generation: binary.BigEndian.Uint64(b[:])}
+	previous, err := writeChunks(db, table, key, r, &m)
+	if err != nil {
+		removeChunks(db, table, key, m)
+		return 0, err
+	}
+	// the chunks are unused once the manifest is replaced, so failing to remove them is not an error
+	removeChunks(db, table, key, previous)
+	return m.size, nil
+}
+
+// writeChunks commits the chunks of r in batches, and the manifest m with the last batch, returning the manifest
+// it replaced
+func writeChunks(db Database, table string, key []byte, r io.Reader, m *manifest) (manifest, error) {
+	for {
+		tx, err := db.BeginTX(table)
+		if err != nil {
+			return manifest{}, err
+		}
+		done := false
+		for i := 0; i < blobBatchChunks && !done && err == nil; i++ {
+			chunk := make([]byte, BlobChunkSize)
+			var n int
+			n, err = io.ReadFull(r, chunk)
+			if done = err == io.EOF || err == io.ErrUnexpectedEOF; done {
+				err = nil
+			}
+			if err == nil && n > 0 {
+				err = tx.Put(chunkKey(key, m.generation, m.chunks), chunk[:n])
+				m.size += int64(n)
+				m.chunks++
+			}
+		}
+		var previous manifest
+		if err == nil && done {
+			if previous, err = readManifest(tx, key); errors.Is(err, KeyNotFound) {
+				err = nil
+			}
+			if err == nil {
+				err = tx.Put(key, m.encode())
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return manifest{}, err
+		}
+		if err := tx.CommitSync(); err != nil || done {
+			return previous, err
+		}
+	}
+}
+
+// ReadBlob writes the blob key to w one chunk at a time, returning the number of bytes written, or KeyNotFound
+func ReadBlob(tx Transaction, key []byte, w io.Writer) (int64, error) {
+	m, err := readManifest(tx, key)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	for index := uint32(0); index < m.chunks; index++ {
+		chunk, err := tx.Get(chunkKey(key, m.generation, index))
+		if errors.Is(err, KeyNotFound) {
+			return written, BlobChanged
+		}
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(chunk)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// RemoveBlob removes the blob key from table, or returns KeyNotFound. The manifest is removed first, so readers
+// never see part of the blob, and then its chunks.
+func RemoveBlob(db Database, table string, key []byte) error {
+	tx, err := db.BeginTX(table)
+	if err != nil {
+		return err
+	}
+	m, err := readManifest(tx, key)
+	if err == nil {
+		_, err = tx.Remove(key)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.CommitSync(); err != nil {
+		return err
+	}
+	return removeChunks(db, table, key, m)
+}
+
+// removeChunks removes the chunks of a generation of the blob key, committing them in batches
+func removeChunks(db Database, table string, key []byte, m manifest) error {
+	for index := uint32(0); index < m.chunks; {
+		tx, err := db.BeginTX(table)
+		if err != nil {
+			return err
+		}
+		for end := index + blobBatchChunks; index < m.chunks && index < end; index++ {
+			if _, err := tx.Remove(chunkKey(key, m.generation, index)); err != nil && !errors.Is(err, KeyNotFound) {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.CommitSync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
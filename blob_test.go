@@ -0,0 +1,180 @@
+package keydbr_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/keydbrtest"
+)
+
+// countingDatabase records the largest size of the values put in a transaction
+type countingDatabase struct {
+	keydbr.Database
+	largest int
+}
+
+type countingTransaction struct {
+	keydbr.Transaction
+	db   *countingDatabase
+	size int
+}
+
+func (db *countingDatabase) BeginTX(table string) (keydbr.Transaction, error) {
+	tx, err := db.Database.BeginTX(table)
+	if err != nil {
+		return nil, err
+	}
+	return &countingTransaction{Transaction: tx, db: db}, nil
+}
+
+func (tx *countingTransaction) Put(key []byte, value []byte) error {
+	if tx.size += len(value); tx.size > tx.db.largest {
+		tx.db.largest = tx.size
+	}
+	return tx.Transaction.Put(key, value)
+}
+
+// hookReader calls hook once offset bytes have been read
+type hookReader struct {
+	io.Reader
+	offset int
+	hook   func() error
+}
+
+func (r *hookReader) Read(p []byte) (int, error) {
+	if r.offset <= 0 && r.hook != nil {
+		err := r.hook()
+		r.hook = nil
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(p) > r.offset && r.offset > 0 {
+		p = p[:r.offset]
+	}
+	n, err := r.Reader.Read(p)
+	r.offset -= n
+	return n, err
+}
+
+func readBlob(t *testing.T, db keydbr.Database, key string) ([]byte, error) {
+	t.Helper()
+	tx, err := db.BeginTX("blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	var buf bytes.Buffer
+	n, err := keydbr.ReadBlob(tx, []byte(key), &buf)
+	if err == nil && n != int64(buf.Len()) {
+		t.Fatal("wrong length returned", n, buf.Len())
+	}
+	return buf.Bytes(), err
+}
+
+func entries(t *testing.T, db keydbr.Database) int {
+	t.Helper()
+	tx, err := db.BeginTX("blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		_, _, err := itr.Next()
+		if errors.Is(err, keydbr.EndOfIterator) {
+			return n
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+}
+
+func TestBlobs(t *testing.T) {
+	db := &countingDatabase{Database: keydbrtest.Open(t, "main")}
+	defer db.Close()
+
+	// a blob larger than the message limit, which is not a multiple of the chunk size
+	blob := make([]byte, 9*keydbr.BlobChunkSize+100)
+	rand.New(rand.NewSource(1)).Read(blob)
+
+	if n, err := keydbr.WriteBlob(db, "blobs", []byte("large"), bytes.NewReader(blob)); err != nil || n != int64(len(blob)) {
+		t.Fatal("unable to write blob", n, err)
+	}
+	if db.largest > 4*keydbr.BlobChunkSize {
+		t.Fatal("blob should be committed in batches", db.largest)
+	}
+	if value, err := readBlob(t, db, "large"); err != nil || !bytes.Equal(value, blob) {
+		t.Fatal("wrong blob read", len(value), err)
+	}
+	if _, err := readBlob(t, db, "missing"); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("missing blob should not be found", err)
+	}
+
+	// the chunks and the manifest are returned by lookups without exceeding the message limit
+	if n := entries(t, db); n != 11 {
+		t.Fatal("wrong number of entries", n)
+	}
+
+	// readers see the previous blob until the whole blob is written
+	other := make([]byte, 6*keydbr.BlobChunkSize)
+	rand.New(rand.NewSource(2)).Read(other)
+	var during []byte
+	var duringErr error
+	r := &hookReader{Reader: bytes.NewReader(other), offset: 5 * keydbr.BlobChunkSize, hook: func() error {
+		during, duringErr = readBlob(t, db, "large")
+		return nil
+	}}
+	if _, err := keydbr.WriteBlob(db, "blobs", []byte("large"), r); err != nil {
+		t.Fatal(err)
+	}
+	if duringErr != nil || !bytes.Equal(during, blob) {
+		t.Fatal("partially written blob should not be read", len(during), duringErr)
+	}
+	if value, err := readBlob(t, db, "large"); err != nil || !bytes.Equal(value, other) {
+		t.Fatal("wrong blob read after replacing it", len(value), err)
+	}
+	if n := entries(t, db); n != 7 {
+		t.Fatal("the chunks of the replaced blob should be removed", n)
+	}
+
+	// a failed write leaves the previous blob, and removes its chunks
+	failed := errors.New("read failed")
+	r = &hookReader{Reader: bytes.NewReader(blob), offset: 5 * keydbr.BlobChunkSize, hook: func() error { return failed }}
+	if _, err := keydbr.WriteBlob(db, "blobs", []byte("large"), r); !errors.Is(err, failed) {
+		t.Fatal("write should fail", err)
+	}
+	if value, err := readBlob(t, db, "large"); err != nil || !bytes.Equal(value, other) {
+		t.Fatal("failed write should not replace the blob", len(value), err)
+	}
+	if n := entries(t, db); n != 7 {
+		t.Fatal("the chunks of the failed write should be removed", n)
+	}
+
+	// an empty blob is found, unlike an empty value
+	if _, err := keydbr.WriteBlob(db, "blobs", []byte("large"), bytes.NewReader(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := readBlob(t, db, "large"); err != nil || len(value) != 0 {
+		t.Fatal("wrong empty blob", len(value), err)
+	}
+	if err := keydbr.RemoveBlob(db, "blobs", []byte("large")); err != nil {
+		t.Fatal(err)
+	}
+	if err := keydbr.RemoveBlob(db, "blobs", []byte("large")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("removed blob should not be found", err)
+	}
+	if n := entries(t, db); n != 0 {
+		t.Fatal("removed blob should have no entries", n)
+	}
+}
@@ -3,16 +3,33 @@ package client
 import (
 	"context"
 	"errors"
+	"github.com/robaho/keydbr"
 	pb "github.com/robaho/keydbr/internal/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"strings"
 	"time"
 )
 
+// retryInterval is how often the members of a cluster are tried while it elects a leader
+const retryInterval = 100 * time.Millisecond
+
+var _ keydbr.Database = (*RemoteDatabase)(nil)
+
 type RemoteDatabase struct {
 	dbid    int32
 	client  pb.KeydbClient
 	timeout time.Duration
 	stream  pb.Keydb_ConnectionClient
+	// the connection and the arguments of Open, used to reopen the database on the leader of a cluster
+	conn   *grpc.ClientConn
+	addr   string
+	dbname string
+	create bool
+	secs   int
+	opts   []Option
 }
 
 type RemoteTransaction struct {
@@ -26,9 +43,67 @@ type RemoteIterator struct {
 	index   int
 }
 
-func Open(addr string, dbname string, createIfNeeded bool, timeout int) (*RemoteDatabase, error) {
+// Open opens the database dbname on the server at addr. If the server is a member of a cluster which is not the
+// leader, the database is opened on the leader, see WithCluster.
+func Open(addr string, dbname string, createIfNeeded bool, timeout int, opts ...Option) (*RemoteDatabase, error) {
+	var db *RemoteDatabase
+	err := withLeader(addr, timeout, opts, func(addr string) error {
+		var err error
+		db, err = open(addr, dbname, createIfNeeded, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.dbname, db.create, db.secs, db.opts = dbname, createIfNeeded, timeout, opts
+	return db, nil
+}
+
+// withLeader calls fn with addr. If the server is a cluster member which is not the leader, fn is called again with
+// the address of the leader, or while the leader is unknown with each member configured by WithCluster in turn,
+// until timeout seconds have passed. The members are also tried if the server is unavailable.
+func withLeader(addr string, timeout int, opts []Option, fn func(addr string) error) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	members := append([]string{addr}, o.cluster...)
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for i := 0; ; {
+		err := fn(addr)
+		if err == nil || !errors.Is(err, keydbr.NotLeader) && !(len(o.cluster) > 0 && disconnected(err)) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		if leader := leaderAddr(err); leader != "" && leader != addr {
+			addr = leader
+			continue
+		}
+		i++
+		addr = members[i%len(members)]
+		time.Sleep(retryInterval)
+	}
+}
+
+// leaderAddr returns the address of the leader from a NotLeader error, or "" if it is unknown
+func leaderAddr(err error) string {
+	prefix := keydbr.NotLeader.Error() + ": "
+	if msg := err.Error(); errors.Is(err, keydbr.NotLeader) && strings.HasPrefix(msg, prefix) {
+		return msg[len(prefix):]
+	}
+	return ""
+}
+
+// disconnected returns true if err is a connection error rather than an error returned by the server
+func disconnected(err error) bool {
+	return status.Code(err) == codes.Unavailable || errors.Is(err, io.EOF)
+}
+
+func open(addr string, dbname string, createIfNeeded bool, opts []Option) (*RemoteDatabase, error) {
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	conn, err := dial(addr, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -45,29 +120,44 @@ func Open(addr string, dbname string, createIfNeeded bool, timeout int) (*Remote
 		return nil, err
 	}
 
-	db := &RemoteDatabase{client: client, stream: stream}
+	db := &RemoteDatabase{client: client, stream: stream, conn: conn, addr: addr}
 
 	request := &pb.InMessage_Open{Open: &pb.OpenRequest{Dbname: dbname, Create: createIfNeeded}}
 
 	err = stream.Send(&pb.InMessage{Request: request})
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
 	msg, err := db.stream.Recv()
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
 	response := msg.GetOpen()
 
 	if response.Error != "" {
-		return nil, errors.New(response.Error)
+		conn.Close()
+		return nil, keydbr.ParseError(response.Error)
 	}
 
 	return db, nil
 }
 
+// reopen opens the database on the leader of the cluster, abandoning the connection and its transactions
+func (db *RemoteDatabase) reopen() error {
+	db.stream.CloseSend()
+	db.conn.Close()
+	reopened, err := Open(db.addr, db.dbname, db.create, db.secs, db.opts...)
+	if err != nil {
+		return err
+	}
+	*db = *reopened
+	return nil
+}
+
 func (db *RemoteDatabase) Close() error {
 
 	request := &pb.InMessage_Close{Close: &pb.CloseRequest{}}
@@ -85,17 +175,25 @@ func (db *RemoteDatabase) Close() error {
 	response := msg.GetClose()
 
 	if response.Error != "" {
-		return errors.New(response.Error)
+		return keydbr.ParseError(response.Error)
 	}
 
 	db.stream.CloseSend()
+	db.conn.Close()
 
 	return nil
 }
 
-func Remove(addr string, dbname string, timeout int) error {
+// Remove removes the database dbname on the server at addr, or on the leader if the server is a cluster member
+func Remove(addr string, dbname string, timeout int, opts ...Option) error {
+	return withLeader(addr, timeout, opts, func(addr string) error {
+		return remove(addr, dbname, opts)
+	})
+}
+
+func remove(addr string, dbname string, opts []Option) error {
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	conn, err := dial(addr, opts)
 	if err != nil {
 		return err
 	}
@@ -118,16 +216,345 @@ func Remove(addr string, dbname string, timeout int) error {
 	}
 
 	if response.Error != "" {
-		return errors.New(response.Error)
+		return keydbr.ParseError(response.Error)
 	}
 	return nil
 }
 
-func (db *RemoteDatabase) BeginTX(table string) (*RemoteTransaction, error) {
+// List returns the names of the databases on the server which the caller can access
+func List(addr string, timeout int, opts ...Option) ([]string, error) {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.List(ctx, &pb.ListRequest{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
+	}
+	return response.Dbnames, nil
+}
+
+// ListTables returns the tables of a database, which requires read access to the database. Tables are listed
+// once the server has written their data to disk.
+func ListTables(addr string, dbname string, timeout int, opts ...Option) ([]string, error) {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.ListTables(ctx, &pb.ListTablesRequest{Dbname: dbname})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
+	}
+	return response.Tables, nil
+}
+
+// SlowOp is a request which took longer than the server's slow operation threshold
+type SlowOp struct {
+	Time      time.Time
+	Duration  time.Duration
+	Type      string // get, put, commit, lookup or next
+	Database  string
+	Table     string
+	KeyPrefix []byte // empty unless the server logs keys
+	KeySize   int
+	Entries   int // entries returned by next, or put by the committed transaction
+	// Connection is the server's id of the connection, and Identity is the authenticated caller
+	Connection uint64
+	Identity   string
+}
+
+// SlowOps returns up to limit of the most recent slow operations, newest first, on the databases which the caller
+// administers. A limit of zero returns all retained operations.
+func SlowOps(addr string, limit int, timeout int, opts ...Option) ([]SlowOp, error) {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.SlowOps(ctx, &pb.SlowOpsRequest{Limit: int32(limit)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
+	}
+
+	ops := make([]SlowOp, len(response.Ops))
+	for i, op := range response.Ops {
+		ops[i] = SlowOp{
+			Time:       time.Unix(0, op.Time),
+			Duration:   time.Duration(op.Duration),
+			Type:       op.Type,
+			Database:   op.Dbname,
+			Table:      op.Table,
+			KeyPrefix:  op.KeyPrefix,
+			KeySize:    int(op.KeySize),
+			Entries:    int(op.Entries),
+			Connection: op.Connection,
+			Identity:   op.Identity,
+		}
+	}
+	return ops, nil
+}
+
+// Session is a connection to the server
+type Session struct {
+	ID       uint64
+	Peer     string // the client address
+	Identity string
+	Database string // the open database, if any
+	// Transactions and Iterators are the number open
+	Transactions int
+	Iterators    int
+	Opened       time.Time
+	Age          time.Duration
+}
+
+// ListSessions returns the connections to the server which the caller administers, ordered by id
+func ListSessions(addr string, timeout int, opts ...Option) ([]Session, error) {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.ListSessions(ctx, &pb.ListSessionsRequest{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
+	}
+
+	sessions := make([]Session, len(response.Sessions))
+	for i, session := range response.Sessions {
+		sessions[i] = Session{
+			ID:           session.Id,
+			Peer:         session.Peer,
+			Identity:     session.Identity,
+			Database:     session.Dbname,
+			Transactions: int(session.Transactions),
+			Iterators:    int(session.Iterators),
+			Opened:       time.Unix(0, session.Opened),
+			Age:          time.Duration(session.Age),
+		}
+	}
+	return sessions, nil
+}
+
+// KillSession rolls back the transactions of a connection and closes it
+func KillSession(addr string, id uint64, timeout int, opts ...Option) error {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.KillSession(ctx, &pb.KillSessionRequest{Id: id})
+
+	if err != nil {
+		return err
+	}
+
+	return keydbr.ParseError(response.Error)
+}
+
+// Replication is the replication state of a server
+type Replication struct {
+	// Primary is the address of the server a replica replicates, and is empty for a primary
+	Primary   string
+	Databases []ReplicationPosition
+}
+
+// ReplicationPosition is the position of a database in its replication log. For a replica it is the last
+// transaction applied, and for a primary the last transaction committed.
+type ReplicationPosition struct {
+	Database string
+	// Epoch identifies the replication log, which is replaced when the primary restarts
+	Epoch    string
+	Position uint64
+	// Connected is true if a replica is receiving transactions from the primary, otherwise Error is the reason
+	Connected bool
+	Error     string
+}
+
+// ReplicationStatus returns the replication state of the server, for the databases which the caller administers
+func ReplicationStatus(addr string, timeout int, opts ...Option) (*Replication, error) {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.ReplicationStatus(ctx, &pb.ReplicationStatusRequest{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
+	}
+
+	replication := &Replication{Primary: response.Primary}
+	for _, db := range response.Databases {
+		replication.Databases = append(replication.Databases, ReplicationPosition{
+			Database:  db.Dbname,
+			Epoch:     db.Epoch,
+			Position:  db.Position,
+			Connected: db.Connected,
+			Error:     db.Error,
+		})
+	}
+	return replication, nil
+}
+
+// Promote makes a replica a primary, which stops replicating and accepts writes. The caller must administer all
+// databases.
+func Promote(addr string, timeout int, opts ...Option) error {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.Promote(ctx, &pb.PromoteRequest{})
+
+	if err != nil {
+		return err
+	}
+
+	return keydbr.ParseError(response.Error)
+}
+
+// Cluster is the membership of a cluster
+type Cluster struct {
+	// Leader is the address of the leader, and is empty while the cluster elects one
+	Leader  string
+	Members []string
+	// Applied is the index in the replicated log of the last command applied by the server
+	Applied uint64
+}
+
+// ClusterStatus returns the leader and members of the cluster of the server at addr, and its progress applying the
+// replicated log
+func ClusterStatus(addr string, timeout int, opts ...Option) (*Cluster, error) {
+	// Set up a connection to the server.
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	client := pb.NewKeydbClient(conn)
+
+	ctx := context.Background()
+
+	response, err := client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
+	}
+
+	return &Cluster{Leader: response.Leader, Members: response.Members, Applied: response.Applied}, nil
+}
+
+// BeginTX starts a transaction on table. If the database was opened on the leader of a cluster which is no longer
+// the leader, the database is opened on the new leader first, and the transactions on the old leader fail.
+func (db *RemoteDatabase) BeginTX(table string) (keydbr.Transaction, error) {
+	tx, err := db.begin(table)
+	if err != nil && db.follow(err) {
+		if err = db.reopen(); err == nil {
+			tx, err = db.begin(table)
+		}
+	}
+	return tx, err
+}
+
+// follow returns true if the database should be reopened on the leader after err
+func (db *RemoteDatabase) follow(err error) bool {
+	var o options
+	for _, opt := range db.opts {
+		opt(&o)
+	}
+	return errors.Is(err, keydbr.NotLeader) || len(o.cluster) > 0 && disconnected(err)
+}
+
+func (db *RemoteDatabase) begin(table string) (keydbr.Transaction, error) {
 
 	request := &pb.InMessage_Begin{Begin: &pb.BeginRequest{Table: table}}
 
 	err := db.stream.Send(&pb.InMessage{Request: request})
+	if err == io.EOF {
+		// the server ended the stream, and its status is returned by Recv
+		_, err = db.stream.Recv()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +567,7 @@ func (db *RemoteDatabase) BeginTX(table string) (*RemoteTransaction, error) {
 	response := msg.GetBegin()
 
 	if response.Error != "" {
-		return nil, errors.New(response.Error)
+		return nil, keydbr.ParseError(response.Error)
 	}
 
 	rtx := new(RemoteTransaction)
@@ -166,7 +593,30 @@ func (tx *RemoteTransaction) Get(key []byte) ([]byte, error) {
 	response := msg.GetGet()
 
 	if response.Error != "" {
-		return nil, errors.New(response.Error)
+		return nil, keydbr.ParseError(response.Error)
+	}
+
+	return response.Value, nil
+}
+
+// Remove deletes a key, returning its previous value
+func (tx *RemoteTransaction) Remove(key []byte) ([]byte, error) {
+	request := &pb.InMessage_Delete{Delete: &pb.DeleteRequest{Txid: tx.txid, Key: key}}
+
+	err := tx.db.stream.Send(&pb.InMessage{Request: request})
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := tx.db.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	response := msg.GetDelete()
+
+	if response.Error != "" {
+		return nil, keydbr.ParseError(response.Error)
 	}
 
 	return response.Value, nil
@@ -183,7 +633,7 @@ func (tx *RemoteTransaction) PutSync(key []byte, value []byte) error {
 }
 
 func (tx *RemoteTransaction) put(key []byte, value []byte, sync bool) error {
-	request := &pb.InMessage_Put{Put: &pb.PutRequest{Txid: tx.txid, Key: key, Value: value}}
+	request := &pb.InMessage_Put{Put: &pb.PutRequest{Txid: tx.txid, Key: key, Value: value, Sync: sync}}
 
 	err := tx.db.stream.Send(&pb.InMessage{Request: request})
 	if err != nil || !sync {
@@ -198,7 +648,7 @@ func (tx *RemoteTransaction) put(key []byte, value []byte, sync bool) error {
 	response := msg.GetPut()
 
 	if response.Error != "" {
-		return errors.New(response.Error)
+		return keydbr.ParseError(response.Error)
 	}
 
 	return nil
@@ -220,7 +670,7 @@ func (tx *RemoteTransaction) commitOption(sync bool) error {
 	response := msg.GetCommit()
 
 	if response.Error != "" {
-		return errors.New(response.Error)
+		return keydbr.ParseError(response.Error)
 	}
 
 	return nil
@@ -250,13 +700,13 @@ func (tx *RemoteTransaction) Rollback() error {
 	response := msg.GetRollback()
 
 	if response.Error != "" {
-		return errors.New(response.Error)
+		return keydbr.ParseError(response.Error)
 	}
 
 	return nil
 }
 
-func (tx *RemoteTransaction) Lookup(lower []byte, upper []byte) (*RemoteIterator, error) {
+func (tx *RemoteTransaction) Lookup(lower []byte, upper []byte) (keydbr.Iterator, error) {
 	request := &pb.InMessage_Lookup{Lookup: &pb.LookupRequest{Txid: tx.txid, Lower: lower, Upper: upper}}
 
 	err := tx.db.stream.Send(&pb.InMessage{Request: request})
@@ -272,7 +722,7 @@ func (tx *RemoteTransaction) Lookup(lower []byte, upper []byte) (*RemoteIterator
 	response := msg.GetLookup()
 
 	if response.Error != "" {
-		return nil, errors.New(response.Error)
+		return nil, keydbr.ParseError(response.Error)
 	}
 
 	ri := RemoteIterator{id: response.Id, db: tx.db}
@@ -302,7 +752,7 @@ func (itr *RemoteIterator) Next() (key []byte, value []byte, err error) {
 	response := msg.GetNext()
 
 	if response.Error != "" {
-		return nil, nil, errors.New(response.Error)
+		return nil, nil, keydbr.ParseError(response.Error)
 	}
 
 	itr.entries = response.Entries
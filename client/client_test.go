@@ -1,20 +1,21 @@
 package client_test
 
 import (
-	"github.com/robaho/keydbr/client"
-	"log"
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
 	"testing"
+
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/keydbrtest"
 )
 
-var addr = "localhost:8501"
 var dbname = "main"
 
 func TestBasic(t *testing.T) {
 
-	db, err := client.Open(addr, dbname, true, 10)
-	if err != nil {
-		log.Fatal(err)
-	}
+	db := keydbrtest.Open(t, dbname)
 
 	tx, err := db.BeginTX("test")
 	if err != nil {
@@ -42,16 +43,13 @@ func TestBasic(t *testing.T) {
 
 	err = db.Close()
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
 }
 
 func TestRollback(t *testing.T) {
 
-	db, err := client.Open(addr, dbname, true, 10)
-	if err != nil {
-		log.Fatal(err)
-	}
+	db := keydbrtest.Open(t, dbname)
 
 	tx, err := db.BeginTX("test")
 	if err != nil {
@@ -74,7 +72,7 @@ func TestRollback(t *testing.T) {
 
 	err = db.Close()
 	if err == nil {
-		log.Fatal("commit should fail with open tx")
+		t.Fatal("commit should fail with open tx")
 	}
 
 	err = tx.Rollback()
@@ -89,10 +87,7 @@ func TestRollback(t *testing.T) {
 
 func TestLookup(t *testing.T) {
 
-	db, err := client.Open(addr, dbname, true, 10)
-	if err != nil {
-		log.Fatal(err)
-	}
+	db := keydbrtest.Open(t, dbname)
 
 	tx, err := db.BeginTX("test")
 	if err != nil {
@@ -148,6 +143,135 @@ func TestLookup(t *testing.T) {
 
 	err = db.Close()
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+
+	db := keydbrtest.Open(t, dbname)
+
+	tx, err := db.BeginTX("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.PutSync([]byte("removed"), []byte("myvalue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := tx.Remove([]byte("removed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(val) != "myvalue" {
+		t.Fatal("wrong value returned", string(val))
+	}
+
+	_, err = tx.Get([]byte("removed"))
+	if err == nil {
+		t.Fatal("key should be removed")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countingConn counts the bytes read from and written to a connection
+type countingConn struct {
+	net.Conn
+	read, written *int64
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	atomic.AddInt64(c.read, int64(n))
+	return n, err
+}
+
+func (c countingConn) Write(p []byte) (int, error) {
+	atomic.AddInt64(c.written, int64(len(p)))
+	return c.Conn.Write(p)
+}
+
+func TestCompression(t *testing.T) {
+
+	srv := keydbrtest.NewServer(t)
+
+	if _, err := client.Open(keydbrtest.Addr, dbname, true, 10, srv.Options(client.WithCompression("none"))...); err == nil {
+		t.Fatal("unknown compressor should fail")
+	}
+
+	gs, err := srv.NewGRPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	var read, written int64
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return countingConn{Conn: conn, read: &read, written: &written}, nil
+	}
+
+	db, err := client.Open(lis.Addr().String(), dbname, true, 10, client.WithDialer(dialer), client.WithCompression(client.Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := bytes.Repeat([]byte("compressible "), 100000)
+
+	tx, err := db.BeginTX("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.PutSync([]byte("mykey"), value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt64(&written); n > int64(len(value))/10 {
+		t.Fatal("value should be compressed, wrote", n)
+	}
+
+	val, err := tx.Get([]byte("mykey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(val, value) {
+		t.Fatal("wrong value returned", len(val))
+	}
+
+	if n := atomic.LoadInt64(&read); n > int64(len(value))/10 {
+		t.Fatal("reply should be compressed, read", n)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
 	}
 }
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"net"
+)
+
+// Gzip is the name of the gzip compressor, see WithCompression
+const Gzip = gzip.Name
+
+// Option configures the connection made by Open or Remove
+type Option func(*options)
+
+type options struct {
+	tls     *tls.Config
+	token   string
+	dialer  func(ctx context.Context, addr string) (net.Conn, error)
+	cluster []string
+	// the name of the compressor of the messages sent, "" for none
+	compressor string
+}
+
+// WithTLS connects to the server using TLS with the provided configuration
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tls = config.Clone()
+	}
+}
+
+// WithCA connects to the server using TLS, verifying the server certificate against the CAs in pool
+func WithCA(pool *x509.CertPool) Option {
+	return func(o *options) {
+		o.tlsConfig().RootCAs = pool
+	}
+}
+
+// WithClientCertificate connects to the server using TLS, presenting cert to the server for mutual TLS
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(o *options) {
+		config := o.tlsConfig()
+		config.Certificates = append(config.Certificates, cert)
+	}
+}
+
+// WithToken authenticates to the server using an API token. Tokens are only sent over TLS connections.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithDialer connects to the server using dialer rather than TCP, e.g. to an in-memory listener in tests
+func WithDialer(dialer func(ctx context.Context, addr string) (net.Conn, error)) Option {
+	return func(o *options) {
+		o.dialer = dialer
+	}
+}
+
+// WithCluster sets the addresses of the other members of a cluster, which are tried in turn to find the leader when
+// the server is unavailable or not the leader. A database opened with it is reopened on the new leader by BeginTX
+// when the leader fails.
+func WithCluster(addrs ...string) Option {
+	return func(o *options) {
+		o.cluster = append(o.cluster, addrs...)
+	}
+}
+
+// WithCompression compresses the messages sent to the server using the named compressor, such as Gzip, and the
+// server compresses its replies with it. Compression reduces the bytes sent for large scans and bulk writes of
+// compressible values, at the cost of CPU. The compressor is fixed when the connection is made, so it applies to
+// every transaction and request on the connection; each Open, Remove or other call makes its own connection, so
+// the compression is chosen per connection.
+func WithCompression(name string) Option {
+	return func(o *options) {
+		o.compressor = name
+	}
+}
+
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+func (o *options) tlsConfig() *tls.Config {
+	if o.tls == nil {
+		o.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return o.tls
+}
+
+// Dial returns a grpc connection to the server configured by opts, for the packages of this module which use the
+// server's grpc interface directly
+func Dial(addr string, opts ...Option) (*grpc.ClientConn, error) {
+	return dial(addr, opts)
+}
+
+func dial(addr string, opts []Option) (*grpc.ClientConn, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var dialOpts []grpc.DialOption
+	if o.tls != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(o.tls)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	if o.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials(o.token)))
+	}
+	if o.dialer != nil {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(o.dialer))
+	}
+	if o.compressor != "" {
+		if encoding.GetCompressor(o.compressor) == nil {
+			return nil, fmt.Errorf("unknown compressor %q", o.compressor)
+		}
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(o.compressor)))
+	}
+
+	return grpc.Dial(addr, dialOpts...)
+}
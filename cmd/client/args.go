@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// splitArgs splits a command line into arguments separated by white space. Arguments may be enclosed in double
+// quotes, which support Go escape sequences, or single quotes, which are literal.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	for {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line == "" {
+			return args, nil
+		}
+		switch line[0] {
+		case '"':
+			end := 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			arg, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string %s", line[:end+1])
+			}
+			args = append(args, arg)
+			line = line[end+1:]
+		case '\'':
+			end := strings.IndexByte(line[1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated quoted string")
+			}
+			args = append(args, line[1:end+1])
+			line = line[end+2:]
+		default:
+			end := strings.IndexFunc(line, unicode.IsSpace)
+			if end < 0 {
+				end = len(line)
+			}
+			args = append(args, line[:end])
+			line = line[end:]
+		}
+	}
+}
+
+// format renders keys and values, and parses them from arguments
+type format string
+
+const (
+	formatUTF8   format = "utf8"
+	formatHex    format = "hex"
+	formatBase64 format = "base64"
+)
+
+func parseFormat(s string) (format, error) {
+	switch f := format(strings.ToLower(s)); f {
+	case formatUTF8, formatHex, formatBase64:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %s, use utf8, hex or base64", s)
+}
+
+func (f format) encode(b []byte) string {
+	switch f {
+	case formatHex:
+		return hex.EncodeToString(b)
+	case formatBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return string(b)
+}
+
+func (f format) decode(s string) ([]byte, error) {
+	switch f {
+	case formatHex:
+		return hex.DecodeString(s)
+	case formatBase64:
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return []byte(s), nil
+}
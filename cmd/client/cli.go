@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cli is a client session, which opens the database when first needed. Outside of an explicit transaction each
+// command runs in its own transaction on the current table.
+type cli struct {
+	addr    string
+	timeout int
+	opts    []client.Option
+	create  bool
+	dbname  string
+	table   string
+	format  format
+	db      *client.RemoteDatabase
+	tx      keydbr.Transaction
+	txtable string
+	out     io.Writer
+}
+
+type command struct {
+	usage string
+	help  string
+	run   func(c *cli, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"open":     {"open <database>", "use a different database", (*cli).open},
+		"close":    {"close", "close the database", (*cli).closeCmd},
+		"dbs":      {"dbs", "list the databases", (*cli).dbs},
+		"tables":   {"tables", "list the tables of the database", (*cli).tables},
+		"remove":   {"remove <database>", "remove a database", (*cli).remove},
+		"use":      {"use <table>", "use a different table", (*cli).use},
+		"get":      {"get <key>", "print the value of a key", (*cli).get},
+		"put":      {"put <key> <value>", "store a value", (*cli).put},
+		"delete":   {"delete <key>", "delete a key", (*cli).delete},
+		"scan":     {"scan [-prefix p] [-limit n] [-keys] [lower [upper]]", "print the keys and values in a range, inclusive", (*cli).scan},
+		"begin":    {"begin [table]", "start a transaction", (*cli).begin},
+		"commit":   {"commit", "commit the transaction", (*cli).commit},
+		"rollback": {"rollback", "roll back the transaction", (*cli).rollback},
+		"import":   {"import <file>", "store the keys and values in a file written by export", (*cli).importCmd},
+		"export":   {"export <file>", "write the keys and values of the table to a file, - for standard output", (*cli).export},
+		"format":   {"format [utf8|hex|base64]", "set or print the format of keys and values", (*cli).setFormat},
+		"sessions": {"sessions", "list the connections to the server", (*cli).sessions},
+		"kill":     {"kill <session>", "close a connection to the server", (*cli).kill},
+		"slowops":  {"slowops [n]", "list the most recent slow operations", (*cli).slowops},
+		"help":     {"help [command]", "describe the commands", (*cli).help},
+	}
+}
+
+var errQuit = errors.New("quit")
+
+// exec runs a command line
+func (c *cli) exec(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if args[0] == "quit" || args[0] == "exit" {
+		return errQuit
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %s, try help", args[0])
+	}
+	return cmd.run(c, args[1:])
+}
+
+func (c *cli) database() (*client.RemoteDatabase, error) {
+	if c.db == nil {
+		db, err := client.Open(c.addr, c.dbname, c.create, c.timeout, c.opts...)
+		if err != nil {
+			return nil, err
+		}
+		c.db = db
+	}
+	return c.db, nil
+}
+
+// run calls fn with the open transaction, or in a new transaction on the current table which is committed if fn
+// succeeds
+func (c *cli) run(fn func(tx keydbr.Transaction) error) error {
+	if c.tx != nil {
+		return fn(c.tx)
+	}
+	db, err := c.database()
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTX(c.table)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// close rolls back the open transaction and closes the database
+func (c *cli) close() error {
+	if c.tx != nil {
+		c.tx.Rollback()
+		c.tx = nil
+	}
+	if c.db == nil {
+		return nil
+	}
+	err := c.db.Close()
+	c.db = nil
+	return err
+}
+
+func (c *cli) noTransaction() error {
+	if c.tx != nil {
+		return errors.New("a transaction is open, commit or rollback first")
+	}
+	return nil
+}
+
+func (c *cli) open(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["open"].usage)
+	}
+	if err := c.noTransaction(); err != nil {
+		return err
+	}
+	if err := c.close(); err != nil {
+		return err
+	}
+	c.dbname = args[0]
+	_, err := c.database()
+	return err
+}
+
+func (c *cli) closeCmd(args []string) error {
+	if err := c.noTransaction(); err != nil {
+		return err
+	}
+	return c.close()
+}
+
+func (c *cli) dbs(args []string) error {
+	dbs, err := client.List(c.addr, c.timeout, c.opts...)
+	if err != nil {
+		return err
+	}
+	for _, db := range dbs {
+		fmt.Fprintln(c.out, db)
+	}
+	return nil
+}
+
+func (c *cli) tables(args []string) error {
+	tables, err := client.ListTables(c.addr, c.dbname, c.timeout, c.opts...)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		fmt.Fprintln(c.out, table)
+	}
+	return nil
+}
+
+func (c *cli) remove(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["remove"].usage)
+	}
+	if args[0] == c.dbname && c.db != nil {
+		return errors.New("the database is open, close it first")
+	}
+	return client.Remove(c.addr, args[0], c.timeout, c.opts...)
+}
+
+func (c *cli) use(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["use"].usage)
+	}
+	if err := c.noTransaction(); err != nil {
+		return err
+	}
+	c.table = args[0]
+	return nil
+}
+
+func (c *cli) get(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["get"].usage)
+	}
+	key, err := c.format.decode(args[0])
+	if err != nil {
+		return err
+	}
+	return c.run(func(tx keydbr.Transaction) error {
+		value, err := tx.Get(key)
+		if err == nil {
+			fmt.Fprintln(c.out, c.format.encode(value))
+		}
+		return err
+	})
+}
+
+func (c *cli) put(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: " + commands["put"].usage)
+	}
+	key, err := c.format.decode(args[0])
+	if err != nil {
+		return err
+	}
+	value, err := c.format.decode(args[1])
+	if err != nil {
+		return err
+	}
+	return c.run(func(tx keydbr.Transaction) error {
+		return tx.PutSync(key, value)
+	})
+}
+
+func (c *cli) delete(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["delete"].usage)
+	}
+	key, err := c.format.decode(args[0])
+	if err != nil {
+		return err
+	}
+	return c.run(func(tx keydbr.Transaction) error {
+		_, err := tx.Remove(key)
+		return err
+	})
+}
+
+// each calls fn with the entries from lower to upper, or having prefix, until fn returns false
+func each(tx keydbr.Transaction, lower, upper, prefix []byte, fn func(key, value []byte) bool) error {
+	if prefix != nil {
+		lower = prefix
+	}
+	itr, err := tx.Lookup(lower, upper)
+	if err != nil {
+		return err
+	}
+	for {
+		key, value, err := itr.Next()
+		if errors.Is(err, keydbr.EndOfIterator) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if prefix != nil && !bytes.HasPrefix(key, prefix) {
+			return nil
+		}
+		if !fn(key, value) {
+			return nil
+		}
+	}
+}
+
+func (c *cli) scan(args []string) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	prefixArg := flags.String("prefix", "", "")
+	limit := flags.Int("limit", 0, "")
+	keysOnly := flags.Bool("keys", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 2 {
+		return errors.New("usage: " + commands["scan"].usage)
+	}
+
+	var lower, upper, prefix []byte
+	var err error
+	if flags.NArg() > 0 {
+		if lower, err = c.format.decode(flags.Arg(0)); err != nil {
+			return err
+		}
+	}
+	if flags.NArg() > 1 {
+		if upper, err = c.format.decode(flags.Arg(1)); err != nil {
+			return err
+		}
+	}
+	if *prefixArg != "" {
+		if lower != nil {
+			return errors.New("use either a prefix or a range")
+		}
+		if prefix, err = c.format.decode(*prefixArg); err != nil {
+			return err
+		}
+	}
+
+	return c.run(func(tx keydbr.Transaction) error {
+		count := 0
+		return each(tx, lower, upper, prefix, func(key, value []byte) bool {
+			if *keysOnly {
+				fmt.Fprintln(c.out, c.format.encode(key))
+			} else {
+				fmt.Fprintf(c.out, "%s\t%s\n", c.format.encode(key), c.format.encode(value))
+			}
+			count++
+			return *limit <= 0 || count < *limit
+		})
+	})
+}
+
+func (c *cli) begin(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: " + commands["begin"].usage)
+	}
+	if err := c.noTransaction(); err != nil {
+		return err
+	}
+	table := c.table
+	if len(args) == 1 {
+		table = args[0]
+	}
+	db, err := c.database()
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTX(table)
+	if err != nil {
+		return err
+	}
+	c.tx = tx
+	c.txtable = table
+	return nil
+}
+
+func (c *cli) commit(args []string) error {
+	if c.tx == nil {
+		return errors.New("no transaction")
+	}
+	tx := c.tx
+	c.tx = nil
+	return tx.Commit()
+}
+
+func (c *cli) rollback(args []string) error {
+	if c.tx == nil {
+		return errors.New("no transaction")
+	}
+	tx := c.tx
+	c.tx = nil
+	return tx.Rollback()
+}
+
+// entry is a line of an export file, the key and value are encoded in base64
+type entry struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+func (c *cli) importCmd(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["import"].usage)
+	}
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	count := 0
+	err := c.run(func(tx keydbr.Transaction) error {
+		decoder := json.NewDecoder(bufio.NewReader(in))
+		for {
+			var e entry
+			err := decoder.Decode(&e)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err = tx.Put(e.Key, e.Value); err != nil {
+				return err
+			}
+			count++
+		}
+	})
+	if err == nil {
+		fmt.Fprintln(os.Stderr, "imported", count, "entries")
+	}
+	return err
+}
+
+func (c *cli) export(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["export"].usage)
+	}
+	out := c.out
+	if args[0] != "-" {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	encoder := json.NewEncoder(w)
+	count := 0
+	err := c.run(func(tx keydbr.Transaction) error {
+		var err error
+		if lookupErr := each(tx, nil, nil, nil, func(key, value []byte) bool {
+			err = encoder.Encode(entry{Key: key, Value: value})
+			count++
+			return err == nil
+		}); lookupErr != nil {
+			return lookupErr
+		}
+		return err
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && args[0] != "-" {
+		fmt.Fprintln(os.Stderr, "exported", count, "entries")
+	}
+	return err
+}
+
+func (c *cli) setFormat(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: " + commands["format"].usage)
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(c.out, c.format)
+		return nil
+	}
+	f, err := parseFormat(args[0])
+	if err == nil {
+		c.format = f
+	}
+	return err
+}
+
+func (c *cli) sessions(args []string) error {
+	sessions, err := client.ListSessions(c.addr, c.timeout, c.opts...)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		fmt.Fprintf(c.out, "%d\t%s\t%s\t%s\ttxs=%d\titrs=%d\tage=%s\n", s.ID, s.Peer, s.Identity, s.Database,
+			s.Transactions, s.Iterators, s.Age.Round(time.Second))
+	}
+	return nil
+}
+
+func (c *cli) kill(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["kill"].usage)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return errors.New("usage: " + commands["kill"].usage)
+	}
+	return client.KillSession(c.addr, id, c.timeout, c.opts...)
+}
+
+func (c *cli) slowops(args []string) error {
+	limit := 0
+	if len(args) > 1 {
+		return errors.New("usage: " + commands["slowops"].usage)
+	}
+	if len(args) == 1 {
+		var err error
+		if limit, err = strconv.Atoi(args[0]); err != nil {
+			return errors.New("usage: " + commands["slowops"].usage)
+		}
+	}
+	ops, err := client.SlowOps(c.addr, limit, c.timeout, c.opts...)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		fmt.Fprintf(c.out, "%s\t%s\t%s\t%s\t%s\tkeysize=%d\tentries=%d\t%s\n", op.Time.Format(time.RFC3339), op.Duration,
+			op.Type, op.Database, op.Table, op.KeySize, op.Entries, c.format.encode(op.KeyPrefix))
+	}
+	return nil
+}
+
+func (c *cli) help(args []string) error {
+	if len(args) == 1 {
+		cmd, ok := commands[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown command %s", args[0])
+		}
+		fmt.Fprintf(c.out, "%s\n    %s\n", cmd.usage, cmd.help)
+		return nil
+	}
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(c.out, "%-55s %s\n", commands[name].usage, commands[name].help)
+	}
+	fmt.Fprintf(c.out, "%-55s %s\n", "quit", "exit the client")
+	return nil
+}
+
+// prompt returns the interactive prompt, showing the database and table
+func (c *cli) prompt() string {
+	table := c.table
+	if c.tx != nil {
+		table = c.txtable + "*"
+	}
+	return strings.Join([]string{c.dbname, table}, "/") + "> "
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{"  get   key ", []string{"get", "key"}},
+		{`put "a key" 'a value'`, []string{"put", "a key", "a value"}},
+		{`put "tab\tand \"quote\"" 'back\slash'`, []string{"put", "tab\tand \"quote\"", `back\slash`}},
+		{`"" ''`, []string{"", ""}},
+	}
+	for _, test := range tests {
+		args, err := splitArgs(test.line)
+		if err != nil || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%s: wrong arguments %q %v", test.line, args, err)
+		}
+	}
+	for _, line := range []string{`get "key`, `get 'key`, `get "\q"`} {
+		if _, err := splitArgs(line); err == nil {
+			t.Errorf("%s: should fail", line)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	value := []byte("k\x00\xff")
+	for f, encoded := range map[format]string{formatUTF8: "k\x00\xff", formatHex: "6b00ff", formatBase64: "awD/"} {
+		if s := f.encode(value); s != encoded {
+			t.Errorf("%s: wrong encoding %q", f, s)
+		}
+		if b, err := f.decode(encoded); err != nil || !bytes.Equal(b, value) {
+			t.Errorf("%s: wrong decoding %q %v", f, b, err)
+		}
+	}
+	if _, err := formatHex.decode("zz"); err == nil {
+		t.Error("invalid hex should fail")
+	}
+	if f, err := parseFormat("HEX"); err != nil || f != formatHex {
+		t.Error("wrong format", f, err)
+	}
+	if _, err := parseFormat("octal"); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+// startServer starts a server on a local port, returning its address
+func startServer(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "keydbr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewServer(dir)
+	s := grpc.NewServer()
+	pb.RegisterKeydbServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(func() {
+		srv.Stop()
+		s.Stop()
+		os.RemoveAll(dir)
+	})
+	return lis.Addr().String()
+}
+
+func TestCommands(t *testing.T) {
+	var out bytes.Buffer
+	c := &cli{addr: startServer(t), timeout: 10, create: true, dbname: "main", table: "main", format: formatUTF8, out: &out}
+	defer c.close()
+
+	exec := func(line string) string {
+		t.Helper()
+		args, err := splitArgs(line)
+		if err == nil {
+			err = c.exec(args)
+		}
+		if err != nil {
+			t.Fatal(line, err)
+		}
+		s := out.String()
+		out.Reset()
+		return s
+	}
+
+	exec("put a 1")
+	exec(`put "b c" 2`)
+	exec("put d 3")
+	if s := exec("get a"); s != "1\n" {
+		t.Fatal("wrong value", s)
+	}
+	if s := exec("scan -limit 2"); s != "a\t1\nb c\t2\n" {
+		t.Fatal("wrong scan", s)
+	}
+	if s := exec("scan -keys -prefix b"); s != "b c\n" {
+		t.Fatal("wrong prefix scan", s)
+	}
+
+	// commands in a transaction are not visible until it commits
+	exec("begin")
+	exec("delete a")
+	if c.prompt() != "main/main*> " {
+		t.Fatal("wrong prompt", c.prompt())
+	}
+	if err := c.exec([]string{"use", "other"}); err == nil {
+		t.Fatal("changing table in a transaction should fail")
+	}
+	exec("rollback")
+	exec("format hex")
+	if s := exec("get 61"); s != "31\n" {
+		t.Fatal("rolled back delete should keep the key", s)
+	}
+
+	if err := c.exec([]string{"get"}); err == nil || !strings.HasPrefix(err.Error(), "usage: get") {
+		t.Fatal("missing argument should print the usage", err)
+	}
+	if err := c.exec([]string{"frobnicate"}); err == nil {
+		t.Fatal("unknown command should fail")
+	}
+	if err := c.exec([]string{"exit"}); err != errQuit {
+		t.Fatal("exit should quit", err)
+	}
+}
+
+func TestScript(t *testing.T) {
+	var out bytes.Buffer
+	c := &cli{addr: startServer(t), timeout: 10, create: true, dbname: "main", table: "main", format: formatUTF8, out: &out}
+	defer c.close()
+
+	if status := c.repl(strings.NewReader("# comment\nput a 1\n\nget a\nquit\nget b\n"), false); status != 0 || out.String() != "1\n" {
+		t.Fatal("wrong script result", status, out.String())
+	}
+	// an empty quoted command is an error rather than a comment
+	if status := c.repl(strings.NewReader(`"" a`+"\nget a\n"), false); status != 1 {
+		t.Fatal("script should stop at the error", status)
+	}
+}
+
+// failingIterator returns an entry, and then fails
+type failingIterator struct {
+	next bool
+}
+
+func (itr *failingIterator) Next() ([]byte, []byte, error) {
+	if itr.next {
+		return nil, nil, keydbr.TransactionExpired
+	}
+	itr.next = true
+	return []byte("a"), []byte("1"), nil
+}
+
+type failingTransaction struct {
+	keydbr.Transaction
+}
+
+func (tx failingTransaction) Lookup(lower, upper []byte) (keydbr.Iterator, error) {
+	return &failingIterator{}, nil
+}
+
+func TestEachError(t *testing.T) {
+	count := 0
+	err := each(failingTransaction{}, nil, nil, nil, func(key, value []byte) bool {
+		count++
+		return true
+	})
+	if !errors.Is(err, keydbr.TransactionExpired) || count != 1 {
+		t.Fatal("lookup errors should be returned", count, err)
+	}
+}
@@ -1,29 +1,125 @@
 package main
 
 import (
+	"bufio"
+	"crypto/tls"
 	"flag"
+	"fmt"
+	"github.com/robaho/keydbr"
 	"github.com/robaho/keydbr/client"
+	"io"
 	"log"
-	"time"
+	"os"
+	"strings"
 )
 
 func main() {
 	addr := flag.String("addr", "localhost:8501", "set the remote database address")
 	dbname := flag.String("db", "main", "set the remote database name")
+	table := flag.String("table", "main", "set the table used outside of an explicit transaction")
 	create := flag.Bool("c", true, "create if needed")
 	timeout := flag.Int("t", 5, "number of seconds before timeout")
+	caFile := flag.String("ca", "", "set the CA file used to verify the server, enables TLS")
+	certFile := flag.String("cert", "", "set the client certificate file for mutual TLS")
+	keyFile := flag.String("key", "", "set the client private key file for mutual TLS")
+	token := flag.String("token", "", "set the API token used to authenticate")
+	compression := flag.String("compress", "", "set the compressor of the messages, e.g. gzip, none if empty")
+	formatName := flag.String("format", "utf8", "set the format of keys and values, utf8, hex or base64")
 
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [command [args]]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Runs the command, or reads commands from standard input. Use the help command to list them.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	db, err := client.Open(*addr, *dbname, *create, *timeout)
+	var opts []client.Option
+	if *caFile != "" {
+		pool, err := keydbr.LoadCertPool(*caFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, client.WithCA(pool))
+	}
+	if *certFile != "" {
+		cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, client.WithClientCertificate(cert))
+	}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+	if *compression != "" {
+		opts = append(opts, client.WithCompression(*compression))
+	}
+
+	f, err := parseFormat(*formatName)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	time.Sleep(time.Second * 2)
+	c := &cli{addr: *addr, timeout: *timeout, opts: opts, create: *create, dbname: *dbname, table: *table, format: f, out: os.Stdout}
 
-	err = db.Close()
-	if err != nil {
-		log.Fatal(err)
+	status := 0
+	if flag.NArg() > 0 {
+		if err := c.exec(flag.Args()); err != nil && err != errQuit {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	} else {
+		interactive := false
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			interactive = true
+		}
+		status = c.repl(os.Stdin, interactive)
+	}
+
+	if err := c.close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		status = 1
+	}
+	os.Exit(status)
+}
+
+// repl reads commands from in. If the input is not interactive, the commands are run as a script which stops at the
+// first error, returning a non-zero exit status.
+func (c *cli) repl(in io.Reader, interactive bool) int {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; ; line++ {
+		if interactive {
+			fmt.Fprint(c.out, c.prompt())
+		}
+		if !scanner.Scan() {
+			break
+		}
+		args, err := splitArgs(scanner.Text())
+		if err == nil && len(args) > 0 && strings.HasPrefix(args[0], "#") {
+			continue
+		}
+		if err == nil {
+			err = c.exec(args)
+		}
+		if err == errQuit {
+			return 0
+		}
+		if err != nil {
+			if !interactive {
+				fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+				return 1
+			}
+			fmt.Fprintln(c.out, err)
+		}
+	}
+	if interactive {
+		fmt.Fprintln(c.out)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	keydb "github.com/robaho/keydbr/client"
+	"log"
+	"strings"
+	"time"
+)
+
+const compressionRecords = 100000
+
+// compressibleValue returns a 1KB value which compresses well, like JSON or text
+func compressibleValue(i int) []byte {
+	return []byte(fmt.Sprintf("{\"id\":%7d,\"text\":%q}", i, strings.Repeat("the quick brown fox ", 50)))
+}
+
+// testCompression compares the throughput of inserting and scanning compressible values without compression and
+// with each compressor
+func testCompression(cfg *config) {
+	for _, compressor := range []string{"", keydb.Gzip} {
+		var opts []keydb.Option
+		name := "none"
+		if compressor != "" {
+			opts = append(opts, keydb.WithCompression(compressor))
+			name = compressor
+		}
+
+		keydb.Remove(cfg.addr, "test/compression", cfg.timeout, opts...)
+
+		db, err := keydb.Open(cfg.addr, "test/compression", true, cfg.timeout, opts...)
+		if err != nil {
+			log.Fatal("unable to create database", err)
+		}
+
+		bytes := 0
+		start := time.Now()
+		tx, err := db.BeginTX("main")
+		if err != nil {
+			panic(err)
+		}
+		for i := 0; i < compressionRecords; i++ {
+			value := compressibleValue(i)
+			bytes += len(value)
+			tx.Put([]byte(fmt.Sprintf("mykey%7d", i)), value)
+			if i%1000 == 999 {
+				tx.CommitSync()
+				tx, err = db.BeginTX("main")
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+		tx.CommitSync()
+		duration := time.Since(start)
+
+		fmt.Printf("compression %s insert time %d records = %d ms, %.1f MB/sec\n", name, compressionRecords,
+			duration.Milliseconds(), float64(bytes)/duration.Seconds()/1e6)
+
+		start = time.Now()
+		tx, err = db.BeginTX("main")
+		if err != nil {
+			panic(err)
+		}
+		itr, err := tx.Lookup(nil, nil)
+		if err != nil {
+			panic(err)
+		}
+		count := 0
+		for {
+			_, _, err = itr.Next()
+			if err != nil {
+				break
+			}
+			count++
+		}
+		if count != compressionRecords {
+			log.Fatal("incorrect count != ", compressionRecords, ", count is ", count)
+		}
+		duration = time.Since(start)
+		tx.Rollback()
+
+		fmt.Printf("compression %s scan time %d records = %d ms, %.1f MB/sec\n", name, compressionRecords,
+			duration.Milliseconds(), float64(bytes)/duration.Seconds()/1e6)
+
+		db.Close()
+		keydb.Remove(cfg.addr, "test/compression", cfg.timeout, opts...)
+	}
+}
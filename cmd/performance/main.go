@@ -1,139 +1,233 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/robaho/keydbr"
 	keydb "github.com/robaho/keydbr/client"
 	"log"
 	"math/rand"
-	"runtime"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const nr = 1000000
-
-var addr = "localhost:8501"
+// config is the settings of a run, set by the flags
+type config struct {
+	addr      string
+	dbname    string
+	table     string
+	timeout   int
+	opts      []keydb.Option
+	threads   int
+	duration  time.Duration
+	maxOps    int64
+	batch     int
+	sync      bool
+	workload  *workload
+	remaining int64 // operations remaining when maxOps is set
+}
 
 func main() {
+	addr := flag.String("addr", "localhost:8501", "set the remote database address")
+	dbname := flag.String("db", "test/mydb", "set the database name")
+	table := flag.String("table", "main", "set the table name")
+	timeout := flag.Int("t", 5, "number of seconds before timeout")
+	compress := flag.String("compress", "", "set the compressor of the messages, e.g. gzip, none if empty")
+	records := flag.Int64("records", 100000, "set the number of records loaded, and chosen from by the operations")
+	keySize := flag.Int("keysize", 16, "set the size of the keys in bytes")
+	valueSize := flag.Int("valuesize", 100, "set the size of the values in bytes")
+	read := flag.Float64("read", 0.5, "set the proportion of reads of a record")
+	update := flag.Float64("update", 0.5, "set the proportion of updates of a record")
+	insert := flag.Float64("insert", 0, "set the proportion of inserts of a new record")
+	scan := flag.Float64("scan", 0, "set the proportion of scans starting at a record")
+	scanLength := flag.Int("scanlength", 100, "set the number of records read by a scan")
+	distribution := flag.String("distribution", zipfian, "set the distribution of the records chosen, uniform, zipfian or latest")
+	threads := flag.Int("threads", 4, "set the number of concurrent clients, each with its own connection")
+	duration := flag.Duration("duration", 10*time.Second, "set the duration of the run")
+	maxOps := flag.Int64("ops", 0, "set the number of operations run, which ends the run before its duration, 0 for no limit")
+	load := flag.Bool("load", true, "recreate the database and load the records before the run")
+	batch := flag.Int("batch", 1000, "set the number of records inserted per transaction while loading")
+	syncCommit := flag.Bool("sync", false, "wait for the disk when committing updates and inserts")
+	format := flag.String("format", "text", "set the format of the results, text or json")
+	compression := flag.Bool("compression", false, "compare the throughput of compressible values with each compressor")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Loads records into a database and runs a mix of reads, updates, inserts and scans against it,")
+		fmt.Fprintln(flag.CommandLine.Output(), "like YCSB, reporting the throughput and the latency percentiles of each operation.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 0 || *format != "text" && *format != "json" || *threads < 1 || *batch < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	runtime.GOMAXPROCS(4)
+	cfg := &config{addr: *addr, dbname: *dbname, table: *table, timeout: *timeout, threads: *threads,
+		duration: *duration, maxOps: *maxOps, batch: *batch, sync: *syncCommit, remaining: *maxOps}
+	if *compress != "" {
+		cfg.opts = append(cfg.opts, keydb.WithCompression(*compress))
+	}
 
-	err := keydb.Remove(addr, "test/mydb", 0)
+	if *compression {
+		testCompression(cfg)
+		return
+	}
 
-	db, err := keydb.Open(addr, "test/mydb", true, 0)
+	w, err := newWorkload(*records, *keySize, *valueSize, *scanLength, *distribution,
+		map[string]float64{opRead: *read, opUpdate: *update, opInsert: *insert, opScan: *scan})
 	if err != nil {
-		log.Fatal("unable to create database", err)
+		log.Fatal(err)
 	}
+	cfg.workload = w
 
-	start := time.Now()
-	tx, err := db.BeginTX("main")
-	if err != nil {
-		panic(err)
+	var phases []*phase
+	if *load {
+		keydb.Remove(cfg.addr, cfg.dbname, cfg.timeout, cfg.opts...)
+		phases = append(phases, cfg.parallel("load", cfg.load))
+	}
+	phases = append(phases, cfg.parallel("run", cfg.run))
+
+	if err := report(os.Stdout, *format, phases); err != nil {
+		log.Fatal(err)
 	}
-	for i := 0; i < nr; i++ {
-		tx.Put([]byte(fmt.Sprintf("mykey%7d", i)), []byte(fmt.Sprint("myvalue", i)))
-		if i%10000 == 0 {
-			tx.CommitSync()
-			tx, err = db.BeginTX("main")
+}
+
+// parallel calls fn on each thread with its own database, returning the phase with their latencies
+func (cfg *config) parallel(name string, fn func(db keydbr.Database, thread int, r *rand.Rand, s stats) error) *phase {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := stats{}
+	start := time.Now()
+	for i := 0; i < cfg.threads; i++ {
+		wg.Add(1)
+		go func(thread int) {
+			defer wg.Done()
+			db, err := keydb.Open(cfg.addr, cfg.dbname, true, cfg.timeout, cfg.opts...)
 			if err != nil {
-				panic(err)
+				log.Fatal("unable to open database ", err)
 			}
-		}
+			defer db.Close()
+			r := rand.New(rand.NewSource(time.Now().UnixNano() + int64(thread)))
+			s := stats{}
+			if err := fn(db, thread, r, s); err != nil {
+				log.Fatal(name, " failed: ", err)
+			}
+			mu.Lock()
+			total.merge(s)
+			mu.Unlock()
+		}(i)
 	}
-	tx.CommitSync()
-
-	end := time.Now()
-	duration := end.Sub(start).Microseconds()
-
-	fmt.Println("insert time ", nr, "records = ", duration/1000, "ms, usec per op ", float64(duration)/nr)
-	start = time.Now()
-	err = db.Close()
-	end = time.Now()
-	duration = end.Sub(start).Microseconds()
+	wg.Wait()
+	return newPhase(name, total, time.Since(start))
+}
 
-	fmt.Println("close time ", (duration)/1000, "ms")
-	if err != nil {
-		panic(err)
+// load inserts the thread's share of the records, committing each batch
+func (cfg *config) load(db keydbr.Database, thread int, r *rand.Rand, s stats) error {
+	w := cfg.workload
+	values := newValues(w.valueSize, r)
+	var tx keydbr.Transaction
+	var err error
+	for i := int64(thread); i < w.records; i += int64(cfg.threads) {
+		if tx == nil {
+			if tx, err = db.BeginTX(cfg.table); err != nil {
+				return err
+			}
+		}
+		start := time.Now()
+		err := tx.Put(key(i, w.keySize), values.next(r))
+		s.record(opInsert, time.Since(start), err)
+		if i/int64(cfg.threads)%int64(cfg.batch) == int64(cfg.batch)-1 {
+			if err := cfg.commit(tx, s); err != nil {
+				return err
+			}
+			tx = nil
+		}
 	}
-
-	testRead()
-
-	db, err = keydb.Open(addr, "test/mydb", false, 0)
-	if err != nil {
-		log.Fatal("unable to open database", err)
+	if tx != nil {
+		return cfg.commit(tx, s)
 	}
-	start = time.Now()
-	db.Close()
-
-	end = time.Now()
-	duration = end.Sub(start).Microseconds()
+	return nil
+}
 
-	fmt.Println("close time ", duration/1000, "ms")
+func (cfg *config) commit(tx keydbr.Transaction, s stats) error {
+	start := time.Now()
+	var err error
+	if cfg.sync {
+		err = tx.CommitSync()
+	} else {
+		err = tx.Commit()
+	}
+	s.record(opCommit, time.Since(start), err)
+	return err
+}
 
-	testRead()
+// run runs operations chosen by the workload until the duration has passed or the operations have been run
+func (cfg *config) run(db keydbr.Database, thread int, r *rand.Rand, s stats) error {
+	w := cfg.workload
+	values := newValues(w.valueSize, r)
+	deadline := time.Now().Add(cfg.duration)
+	for time.Now().Before(deadline) {
+		if cfg.maxOps > 0 && atomic.AddInt64(&cfg.remaining, -1) < 0 {
+			return nil
+		}
+		op := w.nextOp(r)
+		start := time.Now()
+		err := cfg.runOp(db, op, r, values)
+		s.record(op, time.Since(start), err)
+	}
+	return nil
 }
 
-func testRead() {
-	db, err := keydb.Open(addr, "test/mydb", false, 0)
+// runOp runs an operation in its own transaction. A read of a record which the run is still inserting is not an error
+// if the record is not found, but a loaded record must be found.
+func (cfg *config) runOp(db keydbr.Database, op string, r *rand.Rand, values *values) error {
+	w := cfg.workload
+	tx, err := db.BeginTX(cfg.table)
 	if err != nil {
-		log.Fatal("unable to open database", err)
+		return err
 	}
-	start := time.Now()
-	tx, err := db.BeginTX("main")
-	if err != nil {
-		panic(err)
-	}
-	itr, err := tx.Lookup(nil, nil)
-	count := 0
-	for {
-		_, _, err = itr.Next()
-		if err != nil {
-			break
+	switch op {
+	case opRead:
+		i := w.nextKey(r)
+		_, err = tx.Get(key(i, w.keySize))
+		if errors.Is(err, keydbr.KeyNotFound) && i >= w.records {
+			err = nil
 		}
-		count++
-	}
-	if count != nr {
-		log.Fatal("incorrect count != ", nr, ", count is ", count)
+	case opUpdate:
+		err = tx.Put(key(w.nextKey(r), w.keySize), values.next(r))
+	case opInsert:
+		err = tx.Put(key(w.nextInsert(), w.keySize), values.next(r))
+	case opScan:
+		err = scanRecords(tx, key(w.nextKey(r), w.keySize), w.scanLength)
 	}
-	end := time.Now()
-	duration := end.Sub(start).Microseconds()
-
-	fmt.Println("scan time ", duration/1000, "ms, usec per op ", float64(duration)/nr)
-
-	start = time.Now()
-	itr, err = tx.Lookup([]byte("mykey 300000"), []byte("mykey 799999"))
-	count = 0
-	for {
-		_, _, err = itr.Next()
-		if err != nil {
-			break
-		}
-		count++
+	if err != nil || op == opRead || op == opScan {
+		tx.Rollback()
+		return err
 	}
-	if count != 500000 {
-		log.Fatal("incorrect count != 500000, count is ", count)
+	if cfg.sync {
+		return tx.CommitSync()
 	}
-	end = time.Now()
-	duration = end.Sub(start).Microseconds()
-
-	fmt.Println("scan time 50% ", duration/1000, "ms, usec per op ", float64(duration)/500000)
-
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	start = time.Now()
+	return tx.Commit()
+}
 
-	for i := 0; i < nr/10; i++ {
-		index := r.Intn(nr / 10)
-		_, err := tx.Get([]byte(fmt.Sprintf("mykey%7d", index)))
-		if err != nil {
-			panic(err)
+// scanRecords reads up to n records from start
+func scanRecords(tx keydbr.Transaction, start []byte, n int) error {
+	itr, err := tx.Lookup(start, nil)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if _, _, err := itr.Next(); err != nil {
+			if errors.Is(err, keydbr.EndOfIterator) {
+				return nil
+			}
+			return err
 		}
 	}
-	end = time.Now()
-	duration = end.Sub(start).Microseconds()
-
-	fmt.Println("random access time ", float64(duration)/(nr/10), "us per get")
-
-	tx.Rollback()
-
-	db.Close()
+	return nil
 }
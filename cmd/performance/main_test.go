@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/keydbrtest"
+)
+
+func TestNewWorkload(t *testing.T) {
+	w, err := newWorkload(100, 10, 8, 5, uniform, map[string]float64{opRead: 3, opInsert: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.ops) != 2 || w.ops[0] != opRead || w.ops[1] != opInsert || w.weights[0] != 0.75 || w.weights[1] != 0.25 {
+		t.Fatal("wrong operations", w.ops, w.weights)
+	}
+	if w.zipf != nil {
+		t.Fatal("uniform workload should not have a zipfian generator")
+	}
+	if string(key(42, w.keySize)) != "user000042" {
+		t.Fatal("wrong key", string(key(42, w.keySize)))
+	}
+	if i := w.nextInsert(); i != 100 || w.inserted != 101 {
+		t.Fatal("inserts should follow the loaded records", i, w.inserted)
+	}
+
+	for name, test := range map[string]struct {
+		records      int64
+		keySize      int
+		distribution string
+		mix          map[string]float64
+	}{
+		"no records":        {0, 10, uniform, map[string]float64{opRead: 1}},
+		"short keys":        {1000, 7, uniform, map[string]float64{opRead: 1}},
+		"distribution":      {100, 10, "normal", map[string]float64{opRead: 1}},
+		"negative":          {100, 10, uniform, map[string]float64{opRead: 1, opScan: -1}},
+		"no operations":     {100, 10, zipfian, map[string]float64{}},
+		"unknown operation": {100, 10, latest, map[string]float64{"delete": 1}},
+	} {
+		if _, err := newWorkload(test.records, test.keySize, 8, 5, test.distribution, test.mix); err == nil {
+			t.Errorf("%s: should fail", name)
+		}
+	}
+}
+
+func TestZipfian(t *testing.T) {
+	const items, n = 1000, 100000
+	z := newZipfian(items, zipfianConstant)
+	r := rand.New(rand.NewSource(1))
+	counts := make([]int, items)
+	for i := 0; i < n; i++ {
+		v := z.next(r)
+		if v < 0 || v >= items {
+			t.Fatal("out of range", v)
+		}
+		counts[v]++
+	}
+	// the probability of item i is 1/((i+1)^theta*zetan), which the algorithm approximates beyond the first two items
+	for i := 0; i < 2; i++ {
+		expected := n / (math.Pow(float64(i+1), zipfianConstant) * z.zetan)
+		if math.Abs(float64(counts[i])-expected) > 0.1*expected {
+			t.Errorf("item %d chosen %d times, expected about %.0f", i, counts[i], expected)
+		}
+	}
+	if counts[0] <= counts[1] || counts[1] <= counts[items-1] {
+		t.Error("smaller items should be more popular", counts[0], counts[1], counts[items-1])
+	}
+
+	w, err := newWorkload(items, 10, 8, 5, latest, map[string]float64{opRead: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestCount := 0
+	for i := 0; i < 1000; i++ {
+		k := w.nextKey(r)
+		if k < 0 || k >= items {
+			t.Fatal("out of range", k)
+		}
+		if k == items-1 {
+			latestCount++
+		}
+	}
+	if latestCount < 100 {
+		t.Error("the latest record should be the most popular", latestCount)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := stats{}
+	for i := 1; i <= 1000; i++ {
+		s.record(opRead, time.Duration(i)*time.Microsecond, nil)
+	}
+	other := stats{}
+	other.record(opRead, 5*time.Second, errors.New("failed"))
+	other.record(opUpdate, time.Millisecond, nil)
+	s.merge(other)
+
+	sum := summarize(s[opRead])
+	if sum.Operations != 1001 || sum.Errors != 1 || sum.Max != 5e6 {
+		t.Fatal("wrong summary", sum)
+	}
+	if expected := (500500 + 5e6) / 1001; math.Abs(sum.Average-expected) > 0.01 {
+		t.Fatal("wrong average", sum.Average, expected)
+	}
+	// the percentiles are within the precision of the histogram
+	for _, test := range []struct{ value, expected float64 }{{sum.P50, 501}, {sum.P95, 951}, {sum.P99, 991}, {sum.P999, 1000}} {
+		if math.Abs(test.value-test.expected) > test.expected/subBuckets {
+			t.Errorf("percentile %.1f, expected %.0f", test.value, test.expected)
+		}
+	}
+	if sum := summarize(s[opUpdate]); sum.Operations != 1 || sum.P50 != 1000 || sum.Max != 1000 {
+		t.Fatal("wrong summary", sum)
+	}
+	if sum := summarize(&latencies{}); sum.Operations != 0 || sum.Max != 0 {
+		t.Fatal("empty summary", sum)
+	}
+
+	for _, d := range []time.Duration{0, 1, 127, 128, 129, 1000, time.Second, math.MaxInt64} {
+		if b := bucket(d); b < 0 || b >= histogramBuckets || math.Abs(float64(bucketValue(b)-d)) > float64(d)/subBuckets {
+			t.Errorf("%d: wrong bucket %d for %d", d, b, bucketValue(b))
+		}
+	}
+}
+
+func TestRunOpMissingRecord(t *testing.T) {
+	db, err := keydbrtest.NewFake().Open("main", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := newWorkload(1, 10, 8, 5, latest, map[string]float64{opRead: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config{table: "main", workload: w}
+	r := rand.New(rand.NewSource(1))
+
+	// a loaded record must be found
+	if err := cfg.runOp(db, opRead, r, nil); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("missing loaded record should fail", err)
+	}
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Put(key(0, w.keySize), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// but an inserted record may not be committed yet
+	w.nextInsert()
+	for i := 0; i < 20; i++ {
+		if err := cfg.runOp(db, opRead, r, nil); err != nil {
+			t.Fatal("missing inserted record should not fail", err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// subBucketBits is the number of significant bits of a latency kept by the histogram, so a latency is recorded within
+// 1/64 of its value
+const subBucketBits = 6
+
+const (
+	subBuckets = 1 << subBucketBits
+	// histogramBuckets is the number of buckets needed for any duration
+	histogramBuckets = (64-subBucketBits-1)*subBuckets + 2*subBuckets
+)
+
+// latencies is a histogram of the latencies of an operation of a type, which uses the same memory however many
+// operations are run, and the number which failed
+type latencies struct {
+	counts [histogramBuckets]int64
+	count  int
+	total  time.Duration
+	max    time.Duration
+	errors int
+}
+
+// bucket returns the histogram bucket of d. Durations below 2*subBuckets nanoseconds have a bucket each, and
+// larger ones share a bucket with those having the same subBucketBits+1 most significant bits.
+func bucket(d time.Duration) int {
+	v := uint64(d)
+	if v < 2*subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return shift*subBuckets + int(v>>uint(shift))
+}
+
+// bucketValue returns the middle of the durations in bucket b
+func bucketValue(b int) time.Duration {
+	if b < 2*subBuckets {
+		return time.Duration(b)
+	}
+	shift := uint(b/subBuckets - 1)
+	lower := uint64(b%subBuckets+subBuckets) << shift
+	return time.Duration(lower + (uint64(1)<<shift)/2)
+}
+
+func (l *latencies) add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	l.counts[bucket(d)]++
+	l.count++
+	l.total += d
+	if d > l.max {
+		l.max = d
+	}
+}
+
+// stats are the latencies of the operations run by a worker, by operation
+type stats map[string]*latencies
+
+func (s stats) record(op string, d time.Duration, err error) {
+	l := s[op]
+	if l == nil {
+		l = &latencies{}
+		s[op] = l
+	}
+	l.add(d)
+	if err != nil {
+		l.errors++
+	}
+}
+
+// merge adds the latencies of other
+func (s stats) merge(other stats) {
+	for op, l := range other {
+		if s[op] == nil {
+			s[op] = &latencies{}
+		}
+		merged := s[op]
+		for b, n := range l.counts {
+			merged.counts[b] += n
+		}
+		merged.count += l.count
+		merged.total += l.total
+		if l.max > merged.max {
+			merged.max = l.max
+		}
+		merged.errors += l.errors
+	}
+}
+
+// phase is the result of loading or running a workload
+type phase struct {
+	Name       string              `json:"name"`
+	Operations int                 `json:"operations"`
+	Errors     int                 `json:"errors"`
+	Duration   float64             `json:"durationSeconds"`
+	Throughput float64             `json:"opsPerSecond"`
+	Ops        map[string]*summary `json:"ops"`
+}
+
+// summary is the latency of an operation in microseconds
+type summary struct {
+	Operations int     `json:"operations"`
+	Errors     int     `json:"errors"`
+	Average    float64 `json:"avgUs"`
+	P50        float64 `json:"p50Us"`
+	P95        float64 `json:"p95Us"`
+	P99        float64 `json:"p99Us"`
+	P999       float64 `json:"p999Us"`
+	Max        float64 `json:"maxUs"`
+}
+
+func newPhase(name string, s stats, elapsed time.Duration) *phase {
+	p := &phase{Name: name, Duration: elapsed.Seconds(), Ops: map[string]*summary{}}
+	for op, l := range s {
+		sum := summarize(l)
+		p.Ops[op] = sum
+		if op != opCommit {
+			p.Operations += sum.Operations
+		}
+		p.Errors += sum.Errors
+	}
+	if elapsed > 0 {
+		p.Throughput = float64(p.Operations) / elapsed.Seconds()
+	}
+	return p
+}
+
+func summarize(l *latencies) *summary {
+	sum := &summary{Operations: l.count, Errors: l.errors}
+	if l.count == 0 {
+		return sum
+	}
+	us := func(v time.Duration) float64 { return float64(v) / float64(time.Microsecond) }
+	// percentile returns the latency of the operation at the rank p of the sorted operations, to within its bucket
+	percentile := func(p float64) float64 {
+		rank := int64(p * float64(l.count-1))
+		for b, n := range l.counts {
+			if rank < n {
+				if v := bucketValue(b); v < l.max {
+					return us(v)
+				}
+				return us(l.max)
+			}
+			rank -= n
+		}
+		return us(l.max)
+	}
+	sum.Average = us(l.total / time.Duration(l.count))
+	sum.P50 = percentile(0.50)
+	sum.P95 = percentile(0.95)
+	sum.P99 = percentile(0.99)
+	sum.P999 = percentile(0.999)
+	sum.Max = us(l.max)
+	return sum
+}
+
+// report writes the phases as text or JSON
+func report(w io.Writer, format string, phases []*phase) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(phases)
+	}
+	for _, p := range phases {
+		fmt.Fprintf(w, "%s: %d operations in %.1fs, %.0f ops/sec, %d errors\n", p.Name, p.Operations,
+			p.Duration, p.Throughput, p.Errors)
+		var ops []string
+		for op := range p.Ops {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			s := p.Ops[op]
+			fmt.Fprintf(w, "  %-6s %9d ops  avg %8.0fus  p50 %8.0fus  p95 %8.0fus  p99 %8.0fus  p99.9 %8.0fus  max %8.0fus  errors %d\n",
+				op, s.Operations, s.Average, s.P50, s.P95, s.P99, s.P999, s.Max, s.Errors)
+		}
+	}
+	return nil
+}
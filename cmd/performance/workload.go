@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// the operations of a workload
+const (
+	opRead   = "read"
+	opUpdate = "update"
+	opInsert = "insert"
+	opScan   = "scan"
+	// opCommit is the commit of a batch of inserts while loading, which is not counted as an operation
+	opCommit = "commit"
+)
+
+// the key distributions, as in YCSB
+const (
+	uniform = "uniform"
+	zipfian = "zipfian"
+	latest  = "latest"
+)
+
+// zipfianConstant is the skew of the zipfian distribution used by YCSB
+const zipfianConstant = 0.99
+
+// workload describes the operations run against the database
+type workload struct {
+	records      int64
+	keySize      int
+	valueSize    int
+	scanLength   int
+	distribution string
+	// the proportion of each operation, which sum to 1
+	ops     []string
+	weights []float64
+	// inserted is the number of records, which grows as records are inserted
+	inserted int64
+	zipf     *zipfianGenerator
+}
+
+func newWorkload(records int64, keySize, valueSize, scanLength int, distribution string, mix map[string]float64) (*workload, error) {
+	w := &workload{records: records, keySize: keySize, valueSize: valueSize, scanLength: scanLength,
+		distribution: distribution, inserted: records}
+	if records <= 0 {
+		return nil, fmt.Errorf("the record count must be positive")
+	}
+	if min := len(fmt.Sprint("user", records)); keySize < min {
+		return nil, fmt.Errorf("the key size must be at least %d for %d records", min, records)
+	}
+	switch distribution {
+	case uniform:
+	case zipfian, latest:
+		w.zipf = newZipfian(records, zipfianConstant)
+	default:
+		return nil, fmt.Errorf("unknown distribution %q, use uniform, zipfian or latest", distribution)
+	}
+	total := 0.0
+	for _, op := range []string{opRead, opUpdate, opInsert, opScan} {
+		if mix[op] < 0 {
+			return nil, fmt.Errorf("the %s proportion must not be negative", op)
+		}
+		if mix[op] > 0 {
+			w.ops = append(w.ops, op)
+			w.weights = append(w.weights, mix[op])
+			total += mix[op]
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("the workload has no operations")
+	}
+	for i := range w.weights {
+		w.weights[i] /= total
+	}
+	return w, nil
+}
+
+// nextOp returns the next operation to run
+func (w *workload) nextOp(r *rand.Rand) string {
+	u := r.Float64()
+	for i, weight := range w.weights {
+		if u < weight {
+			return w.ops[i]
+		}
+		u -= weight
+	}
+	return w.ops[len(w.ops)-1]
+}
+
+// nextKey returns the number of an existing record chosen by the distribution
+func (w *workload) nextKey(r *rand.Rand) int64 {
+	n := atomic.LoadInt64(&w.inserted)
+	switch w.distribution {
+	case zipfian:
+		// scramble the popular records across the key space, as in YCSB
+		return int64(hash(w.zipf.next(r)) % uint64(n))
+	case latest:
+		if i := n - 1 - w.zipf.next(r); i >= 0 {
+			return i
+		}
+		return n - 1
+	default:
+		return r.Int63n(n)
+	}
+}
+
+// nextInsert returns the number of a new record
+func (w *workload) nextInsert() int64 {
+	return atomic.AddInt64(&w.inserted, 1) - 1
+}
+
+// key returns the key of record i, padded to size bytes
+func key(i int64, size int) []byte {
+	return []byte(fmt.Sprintf("user%0*d", size-4, i))
+}
+
+func hash(i int64) uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for j := range b {
+		b[j] = byte(i >> (8 * j))
+	}
+	h.Write(b[:])
+	return h.Sum64()
+}
+
+// values returns random values of size bytes from a shared buffer
+type values struct {
+	buf  []byte
+	size int
+}
+
+func newValues(size int, r *rand.Rand) *values {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	buf := make([]byte, size+4096)
+	for i := range buf {
+		buf[i] = letters[r.Intn(len(letters))]
+	}
+	return &values{buf: buf, size: size}
+}
+
+func (v *values) next(r *rand.Rand) []byte {
+	offset := r.Intn(len(v.buf) - v.size + 1)
+	return v.buf[offset : offset+v.size]
+}
+
+// zipfianGenerator returns numbers from 0 to items-1 where the smallest are the most popular, using the algorithm
+// of Gray et al, "Quickly Generating Billion-Record Synthetic Databases", as YCSB does. It is safe for concurrent
+// use, with a rand.Rand per goroutine.
+type zipfianGenerator struct {
+	items               int64
+	theta, alpha, eta   float64
+	zetan, halfPowTheta float64
+}
+
+func newZipfian(items int64, theta float64) *zipfianGenerator {
+	zetan := zeta(items, theta)
+	zeta2 := zeta(2, theta)
+	return &zipfianGenerator{
+		items:        items,
+		theta:        theta,
+		alpha:        1 / (1 - theta),
+		zetan:        zetan,
+		eta:          (1 - math.Pow(2/float64(items), 1-theta)) / (1 - zeta2/zetan),
+		halfPowTheta: 1 + math.Pow(0.5, theta),
+	}
+}
+
+func zeta(n int64, theta float64) float64 {
+	sum := 0.0
+	for i := int64(1); i <= n; i++ {
+		sum += 1 / math.Pow(float64(i), theta)
+	}
+	return sum
+}
+
+func (z *zipfianGenerator) next(r *rand.Rand) int64 {
+	u := r.Float64()
+	uz := u * z.zetan
+	if uz < 1 {
+		return 0
+	}
+	if uz < z.halfPowTheta {
+		return 1
+	}
+	i := int64(float64(z.items) * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	if i >= z.items {
+		i = z.items - 1
+	}
+	return i
+}
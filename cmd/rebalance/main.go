@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/sharded"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	from := flag.String("from", "", "set the comma separated addresses, or start=address ranges, of the current shards")
+	to := flag.String("to", "", "set the comma separated addresses, or start=address ranges, of the new shards")
+	dbname := flag.String("db", "main", "set the database name")
+	timeout := flag.Int("t", 5, "number of seconds before timeout")
+	caFile := flag.String("ca", "", "set the CA file used to verify the servers, enables TLS")
+	certFile := flag.String("cert", "", "set the client certificate file for mutual TLS")
+	keyFile := flag.String("key", "", "set the client private key file for mutual TLS")
+	token := flag.String("token", "", "set the API token used to authenticate")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -from addrs -to addrs [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Moves the keys of a database sharded across the servers in -from to their servers in -to, e.g.")
+		fmt.Fprintln(flag.CommandLine.Output(), "after adding a server. Addresses are sharded by consistent hashing, and start=address ranges by")
+		fmt.Fprintln(flag.CommandLine.Output(), "key range, where the first range starts with the empty key, e.g. =server1:8501,m=server2:8501.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Stop writing to the database while it runs.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *from == "" || *to == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var opts []client.Option
+	if *caFile != "" {
+		pool, err := keydbr.LoadCertPool(*caFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, client.WithCA(pool))
+	}
+	if *certFile != "" {
+		cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, client.WithClientCertificate(cert))
+	}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+
+	fromRouter, err := parseRouter(*from)
+	if err != nil {
+		log.Fatal(err)
+	}
+	toRouter, err := parseRouter(*to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	moved, err := sharded.Rebalance(fromRouter, toRouter, *dbname, *timeout, opts...)
+	fmt.Println("moved", moved, "keys")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseRouter returns a ring of comma separated addresses, or the ranges of comma separated start=address pairs
+func parseRouter(s string) (sharded.Router, error) {
+	var addrs []string
+	var ranges []sharded.Range
+	for _, shard := range strings.Split(s, ",") {
+		if i := strings.IndexByte(shard, '='); i >= 0 {
+			ranges = append(ranges, sharded.Range{Start: []byte(shard[:i]), Addr: shard[i+1:]})
+		} else {
+			addrs = append(addrs, shard)
+		}
+	}
+	if len(ranges) == 0 {
+		return sharded.NewRing(addrs...), nil
+	}
+	if len(addrs) > 0 {
+		return nil, errors.New("use either addresses or ranges")
+	}
+	return sharded.NewRanges(ranges...)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/robaho/keydbr/sharded"
+)
+
+func TestParseRouter(t *testing.T) {
+	router, err := parseRouter("a:8501,b:8501")
+	if ring, ok := router.(*sharded.Ring); err != nil || !ok || len(ring.Shards()) != 2 {
+		t.Fatal("addresses should be a ring", router, err)
+	}
+
+	router, err = parseRouter("=a:8501,m=b:8501")
+	if _, ok := router.(*sharded.Ranges); err != nil || !ok {
+		t.Fatal("start=address pairs should be ranges", router, err)
+	}
+	if router.Route([]byte("k")) != "a:8501" || router.Route([]byte("m")) != "b:8501" {
+		t.Fatal("wrong routes", router.Route([]byte("k")), router.Route([]byte("m")))
+	}
+
+	if _, err := parseRouter("a:8501,m=b:8501"); err == nil {
+		t.Fatal("addresses and ranges should not be mixed")
+	}
+	if _, err := parseRouter("a=a:8501,m=b:8501"); err == nil {
+		t.Fatal("ranges should start with the empty key")
+	}
+}
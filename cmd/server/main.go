@@ -3,41 +3,204 @@ package main
 import (
 	"flag"
 	"fmt"
-	pb "github.com/robaho/keydbr/internal/proto"
 	"github.com/robaho/keydbr/server"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
 	"log"
-	"net"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
-	dbpath := flag.String("path", "databases", "set top-level database directory")
-	port := flag.String("port", ":8501", "set database tcp port")
+	defaults := server.DefaultConfig()
+
+	configFile := flag.String("config", "", "set the YAML or JSON configuration file, flags override its settings")
+	dbpath := flag.String("path", defaults.Path, "set top-level database directory")
+	port := flag.String("port", defaults.Listen, "set database tcp port")
+	certFile := flag.String("cert", "", "set the TLS certificate file, enables TLS")
+	keyFile := flag.String("key", "", "set the TLS private key file")
+	clientCAFile := flag.String("clientca", "", "set the CA file used to verify client certificates, enables mutual TLS")
+	tokenFile := flag.String("tokens", "", "set the API token file, enables token authentication")
+	aclFile := flag.String("acl", "", "set the JSON access control file")
+	namespacesFile := flag.String("namespaces", "", "set the JSON namespace file, enables namespaces")
+	maxTxs := flag.Int("maxtxs", 0, "set the maximum open transactions per connection, 0 for no limit")
+	maxItrs := flag.Int("maxitrs", 0, "set the maximum open iterators per connection, 0 for no limit")
+	maxInFlight := flag.Int64("maxinflight", 0, "set the maximum uncommitted bytes per connection, 0 for no limit")
+	rate := flag.Float64("rate", 0, "set the maximum requests per second per identity and database, 0 for no limit")
+	burst := flag.Int("burst", 0, "set the number of requests allowed to exceed the rate, defaults to the rate and is at least 1")
+	txTimeout := flag.Duration("txtimeout", 0, "set the idle time after which a transaction is rolled back, 0 for no timeout")
+	itrTimeout := flag.Duration("itrtimeout", 0, "set the idle time after which an iterator is closed, 0 for no timeout")
+	metricsAddr := flag.String("metrics", "", "set the http address serving /metrics, e.g. localhost:9090")
+	restAddr := flag.String("rest", "", "set the http address of the REST gateway, e.g. localhost:8080")
+	respAddr := flag.String("resp", "", "set the tcp address of the Redis protocol frontend, e.g. localhost:6379")
+	respDatabase := flag.String("respdb", defaults.RESPDatabase, "set the database used by the Redis protocol frontend")
+	respTable := flag.String("resptable", defaults.RESPTable, "set the table used by the Redis protocol frontend")
+	grace := flag.Duration("grace", time.Duration(defaults.GracePeriod), "set the time open transactions are given to complete on shutdown")
+	maxRecv := flag.Int("maxrecv", 0, "set the maximum grpc message size received, 0 for the grpc default")
+	maxSend := flag.Int("maxsend", 0, "set the maximum grpc message size sent, 0 for the grpc default")
+	logFile := flag.String("log", "", "set the file the log is appended to, defaults to standard error")
+	logFormat := flag.String("logformat", "text", "set the log format, text or json")
+	logLevel := flag.String("loglevel", "info", "set the log level, debug, info, warn or error")
+	logKeys := flag.Bool("logkeys", false, "log the keys of requests at debug level, otherwise they are redacted")
+	slowThreshold := flag.Duration("slow", 0, "set the duration after which a request is recorded as slow, 0 to disable")
+	slowLogSize := flag.Int("slowlog", server.DefaultSlowLogSize, "set the number of slow requests retained")
+	replicationLog := flag.Int("replog", 0, "set the number of transactions per database retained for replicas, 0 to disable replication")
+	primary := flag.String("replicaof", "", "set the address of the primary server, makes the server a read only replica")
+	primaryCAFile := flag.String("replicaca", "", "set the CA file used to verify the primary, enables TLS")
+	primaryCertFile := flag.String("replicacert", "", "set the client certificate file used to connect to the primary")
+	primaryKeyFile := flag.String("replicakey", "", "set the client private key file used to connect to the primary")
+	primaryToken := flag.String("replicatoken", "", "set the API token used to connect to the primary")
+	raftAddress := flag.String("raft", "", "set the raft address the other cluster members connect to, enables cluster mode")
+	advertise := flag.String("advertise", "", "set the address clients use to reach this cluster member")
+	peers := flag.String("peers", "", "set the cluster members as comma separated address=raftaddress pairs, including this one")
 
 	flag.Parse()
 
-	lis, err := net.Listen("tcp", *port)
+	cfg := defaults
+	if *configFile != "" {
+		var err error
+		cfg, err = server.LoadConfig(*configFile)
+		if err != nil {
+			log.Fatalf("failed to load config: %v", err)
+		}
+	}
+
+	// flags which are set override the configuration file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "path":
+			cfg.Path = *dbpath
+		case "port":
+			cfg.Listen = *port
+		case "cert":
+			cfg.TLS.CertFile = *certFile
+		case "key":
+			cfg.TLS.KeyFile = *keyFile
+		case "clientca":
+			cfg.TLS.ClientCAFile = *clientCAFile
+		case "tokens":
+			cfg.Tokens = *tokenFile
+		case "acl":
+			cfg.ACL = *aclFile
+		case "namespaces":
+			cfg.Namespaces = *namespacesFile
+		case "maxtxs":
+			cfg.Limits.MaxTransactions = *maxTxs
+		case "maxitrs":
+			cfg.Limits.MaxIterators = *maxItrs
+		case "maxinflight":
+			cfg.Limits.MaxInFlightBytes = *maxInFlight
+		case "rate":
+			cfg.Limits.RequestsPerSecond = *rate
+		case "burst":
+			cfg.Limits.Burst = *burst
+		case "txtimeout":
+			cfg.TransactionTimeout = server.Duration(*txTimeout)
+		case "itrtimeout":
+			cfg.IteratorTimeout = server.Duration(*itrTimeout)
+		case "metrics":
+			cfg.Metrics = *metricsAddr
+		case "rest":
+			cfg.REST = *restAddr
+		case "resp":
+			cfg.RESP = *respAddr
+		case "respdb":
+			cfg.RESPDatabase = *respDatabase
+		case "resptable":
+			cfg.RESPTable = *respTable
+		case "grace":
+			cfg.GracePeriod = server.Duration(*grace)
+		case "maxrecv":
+			cfg.MaxRecvMessageSize = *maxRecv
+		case "maxsend":
+			cfg.MaxSendMessageSize = *maxSend
+		case "log":
+			cfg.LogFile = *logFile
+		case "logformat":
+			cfg.LogFormat = *logFormat
+		case "loglevel":
+			cfg.LogLevel = *logLevel
+		case "logkeys":
+			cfg.LogKeys = *logKeys
+		case "slow":
+			cfg.SlowThreshold = server.Duration(*slowThreshold)
+		case "slowlog":
+			cfg.SlowLogSize = *slowLogSize
+		case "replog":
+			cfg.Replication.LogSize = *replicationLog
+		case "replicaof":
+			cfg.Replication.Primary = *primary
+		case "replicaca":
+			cfg.Replication.CAFile = *primaryCAFile
+		case "replicacert":
+			cfg.Replication.CertFile = *primaryCertFile
+		case "replicakey":
+			cfg.Replication.KeyFile = *primaryKeyFile
+		case "replicatoken":
+			cfg.Replication.Token = *primaryToken
+		case "raft":
+			cfg.Cluster.RaftAddress = *raftAddress
+		case "advertise":
+			cfg.Cluster.Address = *advertise
+		case "peers":
+			members, err := parseMembers(*peers)
+			if err != nil {
+				log.Fatalf("invalid peers: %v", err)
+			}
+			cfg.Cluster.Members = members
+		}
+	})
+
+	logger, err := cfg.NewLogger()
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		log.Fatalf("failed to open log: %v", err)
 	}
+	slog.SetDefault(logger)
 
-	s := grpc.NewServer(grpc.StreamInterceptor(streamInterceptor))
-	pb.RegisterKeydbServer(s, server.NewServer(*dbpath))
-	// Register reflection service on gRPC server.
-	reflection.Register(s)
-	fmt.Println("listening on ", lis.Addr())
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	opts, err := cfg.Options()
+	if err != nil {
+		fatal("failed to configure server", err)
 	}
-}
+	srv := server.NewServer(cfg.Path, opts...)
 
-func streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	log.Printf("stream opened - Context:%s\n", ss.Context())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-	err := handler(srv, ss)
+	done := make(chan server.ShutdownSummary)
+	go func() {
+		sig := <-sigs
+		slog.Info("shutting down", "signal", sig.String())
+		done <- srv.Stop()
+	}()
 
-	log.Printf("stream closed - Context:%s\n", ss.Context())
+	if err := srv.ListenAndServe(); err != nil {
+		fatal("failed to serve", err)
+	}
+
+	summary := <-done
+	slog.Info("shutdown complete", "connections", summary.Connections, "databases", summary.Databases,
+		"completed", summary.Drained, "rolledback", summary.RolledBack, "errors", len(summary.Errors))
+	if len(summary.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseMembers parses comma separated address=raftaddress pairs
+func parseMembers(s string) ([]server.ClusterMember, error) {
+	var members []server.ClusterMember
+	for _, pair := range strings.Split(s, ",") {
+		addr, raftAddr, ok := strings.Cut(pair, "=")
+		if !ok || addr == "" || raftAddr == "" {
+			return nil, fmt.Errorf("%q is not address=raftaddress", pair)
+		}
+		members = append(members, server.ClusterMember{Address: addr, RaftAddress: raftAddr})
+	}
+	return members, nil
+}
 
-	return err
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
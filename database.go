@@ -0,0 +1,33 @@
+package keydbr
+
+// Database is a keydb database, opened in-process by package embedded or on a server by package client, so
+// applications can use either. A database is not safe for concurrent use.
+type Database interface {
+	// BeginTX starts a transaction on table, which is created if it does not exist
+	BeginTX(table string) (Transaction, error)
+	Close() error
+}
+
+// Transaction is a transaction on a table of a Database
+type Transaction interface {
+	// Get returns the value of key, or KeyNotFound
+	Get(key []byte) ([]byte, error)
+	// Put stores a value. A remote Put does not wait for the server, so an error may instead be returned by Commit.
+	Put(key []byte, value []byte) error
+	// PutSync stores a value, returning any error
+	PutSync(key []byte, value []byte) error
+	// Remove deletes a key, returning its previous value, or KeyNotFound
+	Remove(key []byte) ([]byte, error)
+	// Lookup returns an iterator over the entries from lower to upper inclusive. A nil bound is unbounded.
+	Lookup(lower []byte, upper []byte) (Iterator, error)
+	Commit() error
+	// CommitSync commits the transaction, waiting for it to be written to disk
+	CommitSync() error
+	Rollback() error
+}
+
+// Iterator returns the entries of a Lookup in key order
+type Iterator interface {
+	// Next returns the next entry, or EndOfIterator
+	Next() (key []byte, value []byte, err error)
+}
@@ -0,0 +1,110 @@
+// Package embedded opens keydb databases in-process. The databases implement the keydbr interfaces, as do the
+// remote databases opened by package client, so applications can switch between local and remote storage.
+package embedded
+
+import (
+	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
+)
+
+var _ keydbr.Database = (*Database)(nil)
+
+// Database is a keydb database opened in-process
+type Database struct {
+	db *keydb.Database
+}
+
+type transaction struct {
+	tx *keydb.Transaction
+}
+
+type iterator struct {
+	itr keydb.LookupIterator
+}
+
+// Open opens the database in directory path, creating it if createIfNeeded is set
+func Open(path string, createIfNeeded bool) (*Database, error) {
+	db, err := keydb.Open(path, createIfNeeded)
+	if err != nil {
+		return nil, convert(err)
+	}
+	return &Database{db: db}, nil
+}
+
+// Remove removes the database in directory path, which must not be open
+func Remove(path string) error {
+	return convert(keydb.Remove(path))
+}
+
+// BeginTX starts a transaction on table. Table names are validated as they are by the server.
+func (db *Database) BeginTX(table string) (keydbr.Transaction, error) {
+	if err := keydbr.ValidateTableName(table); err != nil {
+		return nil, err
+	}
+	tx, err := db.db.BeginTX(table)
+	if err != nil {
+		return nil, convert(err)
+	}
+	return &transaction{tx: tx}, nil
+}
+
+func (db *Database) Close() error {
+	return convert(db.db.Close())
+}
+
+func (tx *transaction) Get(key []byte) ([]byte, error) {
+	value, err := tx.tx.Get(key)
+	return value, convert(err)
+}
+
+func (tx *transaction) Put(key []byte, value []byte) error {
+	return convert(tx.tx.Put(key, value))
+}
+
+// PutSync is the same as Put, which returns any error immediately in-process
+func (tx *transaction) PutSync(key []byte, value []byte) error {
+	return tx.Put(key, value)
+}
+
+func (tx *transaction) Remove(key []byte) ([]byte, error) {
+	value, err := tx.tx.Remove(key)
+	return value, convert(err)
+}
+
+func (tx *transaction) Lookup(lower []byte, upper []byte) (keydbr.Iterator, error) {
+	itr, err := tx.tx.Lookup(lower, upper)
+	if err != nil {
+		return nil, convert(err)
+	}
+	return &iterator{itr: itr}, nil
+}
+
+func (tx *transaction) Commit() error {
+	return convert(tx.tx.Commit())
+}
+
+func (tx *transaction) CommitSync() error {
+	return convert(tx.tx.CommitSync())
+}
+
+func (tx *transaction) Rollback() error {
+	return convert(tx.tx.Rollback())
+}
+
+func (itr *iterator) Next() ([]byte, []byte, error) {
+	key, value, err := itr.itr.Next()
+	return key, value, convert(err)
+}
+
+// convert returns the keydbr errors in place of the keydb errors, so they match those of a remote database
+func convert(err error) error {
+	switch err {
+	case keydb.KeyNotFound:
+		return keydbr.KeyNotFound
+	case keydb.EndOfIterator:
+		return keydbr.EndOfIterator
+	case keydb.NoDatabaseFound:
+		return keydbr.NoDatabaseFound
+	}
+	return err
+}
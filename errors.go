@@ -0,0 +1,75 @@
+// Package keydbr contains the types shared by the keydbr client and server.
+package keydbr
+
+import (
+	"errors"
+	"strings"
+)
+
+var InvalidDatabaseName = errors.New("invalid database name")
+var InvalidTableName = errors.New("invalid table name")
+var PermissionDenied = errors.New("permission denied")
+var QuotaExceeded = errors.New("quota exceeded")
+var ResourceExhausted = errors.New("resource exhausted")
+var TransactionExpired = errors.New("transaction expired")
+var IteratorExpired = errors.New("iterator expired")
+var ShuttingDown = errors.New("server shutting down")
+var ReadOnly = errors.New("read only replica")
+
+// NotLeader is returned by a cluster member which is not the leader, followed by ": " and the address of the leader
+// if it is known
+var NotLeader = errors.New("not leader")
+
+// KeyNotFound, EndOfIterator and NoDatabaseFound are returned by local and remote databases in place of the keydb
+// errors with the same text
+var KeyNotFound = errors.New("key not found")
+var EndOfIterator = errors.New("end of iterator")
+var NoDatabaseFound = errors.New("no database found")
+
+// wireErrors are the errors that are recognized when received from the server
+var wireErrors = []error{
+	InvalidDatabaseName,
+	InvalidTableName,
+	PermissionDenied,
+	QuotaExceeded,
+	ResourceExhausted,
+	TransactionExpired,
+	IteratorExpired,
+	ShuttingDown,
+	ReadOnly,
+	NotLeader,
+	KeyNotFound,
+	EndOfIterator,
+	NoDatabaseFound,
+}
+
+type wireError struct {
+	msg   string
+	cause error
+}
+
+func (e *wireError) Error() string {
+	return e.msg
+}
+
+func (e *wireError) Unwrap() error {
+	return e.cause
+}
+
+// ParseError converts an error string sent by the server back into an error. If the string
+// is one of the errors declared in this package (optionally followed by ": detail"), the
+// returned error will match it using errors.Is. An empty string returns nil.
+func ParseError(s string) error {
+	if s == "" {
+		return nil
+	}
+	for _, e := range wireErrors {
+		if s == e.Error() {
+			return e
+		}
+		if strings.HasPrefix(s, e.Error()+": ") {
+			return &wireError{msg: s, cause: e}
+		}
+	}
+	return errors.New(s)
+}
@@ -34,6 +34,7 @@ type InMessage struct {
 	//	*InMessage_Rollback
 	//	*InMessage_Lookup
 	//	*InMessage_Next
+	//	*InMessage_Delete
 	Request              isInMessage_Request `protobuf_oneof:"request"`
 	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
 	XXX_unrecognized     []byte              `json:"-"`
@@ -44,7 +45,7 @@ func (m *InMessage) Reset()         { *m = InMessage{} }
 func (m *InMessage) String() string { return proto.CompactTextString(m) }
 func (*InMessage) ProtoMessage()    {}
 func (*InMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{0}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{0}
 }
 func (m *InMessage) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_InMessage.Unmarshal(m, b)
@@ -104,6 +105,10 @@ type InMessage_Next struct {
 	Next *LookupNextRequest `protobuf:"bytes,10,opt,name=next,proto3,oneof"`
 }
 
+type InMessage_Delete struct {
+	Delete *DeleteRequest `protobuf:"bytes,11,opt,name=delete,proto3,oneof"`
+}
+
 func (*InMessage_Open) isInMessage_Request() {}
 
 func (*InMessage_Close) isInMessage_Request() {}
@@ -122,6 +127,8 @@ func (*InMessage_Lookup) isInMessage_Request() {}
 
 func (*InMessage_Next) isInMessage_Request() {}
 
+func (*InMessage_Delete) isInMessage_Request() {}
+
 func (m *InMessage) GetRequest() isInMessage_Request {
 	if m != nil {
 		return m.Request
@@ -192,6 +199,13 @@ func (m *InMessage) GetNext() *LookupNextRequest {
 	return nil
 }
 
+func (m *InMessage) GetDelete() *DeleteRequest {
+	if x, ok := m.GetRequest().(*InMessage_Delete); ok {
+		return x.Delete
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*InMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _InMessage_OneofMarshaler, _InMessage_OneofUnmarshaler, _InMessage_OneofSizer, []interface{}{
@@ -204,6 +218,7 @@ func (*InMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) err
 		(*InMessage_Rollback)(nil),
 		(*InMessage_Lookup)(nil),
 		(*InMessage_Next)(nil),
+		(*InMessage_Delete)(nil),
 	}
 }
 
@@ -256,6 +271,11 @@ func _InMessage_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Next); err != nil {
 			return err
 		}
+	case *InMessage_Delete:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Delete); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("InMessage.Request has unexpected type %T", x)
@@ -338,6 +358,14 @@ func _InMessage_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buff
 		err := b.DecodeMessage(msg)
 		m.Request = &InMessage_Next{msg}
 		return true, err
+	case 11: // request.delete
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(DeleteRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &InMessage_Delete{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -392,6 +420,11 @@ func _InMessage_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *InMessage_Delete:
+		s := proto.Size(x.Delete)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -410,6 +443,7 @@ type OutMessage struct {
 	//	*OutMessage_Rollback
 	//	*OutMessage_Lookup
 	//	*OutMessage_Next
+	//	*OutMessage_Delete
 	Reply                isOutMessage_Reply `protobuf_oneof:"reply"`
 	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
 	XXX_unrecognized     []byte             `json:"-"`
@@ -420,7 +454,7 @@ func (m *OutMessage) Reset()         { *m = OutMessage{} }
 func (m *OutMessage) String() string { return proto.CompactTextString(m) }
 func (*OutMessage) ProtoMessage()    {}
 func (*OutMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{1}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{1}
 }
 func (m *OutMessage) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_OutMessage.Unmarshal(m, b)
@@ -480,6 +514,10 @@ type OutMessage_Next struct {
 	Next *LookupNextReply `protobuf:"bytes,10,opt,name=next,proto3,oneof"`
 }
 
+type OutMessage_Delete struct {
+	Delete *DeleteReply `protobuf:"bytes,11,opt,name=delete,proto3,oneof"`
+}
+
 func (*OutMessage_Open) isOutMessage_Reply() {}
 
 func (*OutMessage_Close) isOutMessage_Reply() {}
@@ -498,6 +536,8 @@ func (*OutMessage_Lookup) isOutMessage_Reply() {}
 
 func (*OutMessage_Next) isOutMessage_Reply() {}
 
+func (*OutMessage_Delete) isOutMessage_Reply() {}
+
 func (m *OutMessage) GetReply() isOutMessage_Reply {
 	if m != nil {
 		return m.Reply
@@ -568,6 +608,13 @@ func (m *OutMessage) GetNext() *LookupNextReply {
 	return nil
 }
 
+func (m *OutMessage) GetDelete() *DeleteReply {
+	if x, ok := m.GetReply().(*OutMessage_Delete); ok {
+		return x.Delete
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*OutMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _OutMessage_OneofMarshaler, _OutMessage_OneofUnmarshaler, _OutMessage_OneofSizer, []interface{}{
@@ -580,6 +627,7 @@ func (*OutMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) er
 		(*OutMessage_Rollback)(nil),
 		(*OutMessage_Lookup)(nil),
 		(*OutMessage_Next)(nil),
+		(*OutMessage_Delete)(nil),
 	}
 }
 
@@ -632,6 +680,11 @@ func _OutMessage_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Next); err != nil {
 			return err
 		}
+	case *OutMessage_Delete:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Delete); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("OutMessage.Reply has unexpected type %T", x)
@@ -714,6 +767,14 @@ func _OutMessage_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buf
 		err := b.DecodeMessage(msg)
 		m.Reply = &OutMessage_Next{msg}
 		return true, err
+	case 11: // reply.delete
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(DeleteReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &OutMessage_Delete{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -768,6 +829,11 @@ func _OutMessage_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *OutMessage_Delete:
+		s := proto.Size(x.Delete)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -787,7 +853,7 @@ func (m *OpenRequest) Reset()         { *m = OpenRequest{} }
 func (m *OpenRequest) String() string { return proto.CompactTextString(m) }
 func (*OpenRequest) ProtoMessage()    {}
 func (*OpenRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{2}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{2}
 }
 func (m *OpenRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_OpenRequest.Unmarshal(m, b)
@@ -832,7 +898,7 @@ func (m *OpenReply) Reset()         { *m = OpenReply{} }
 func (m *OpenReply) String() string { return proto.CompactTextString(m) }
 func (*OpenReply) ProtoMessage()    {}
 func (*OpenReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{3}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{3}
 }
 func (m *OpenReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_OpenReply.Unmarshal(m, b)
@@ -870,7 +936,7 @@ func (m *RemoveRequest) Reset()         { *m = RemoveRequest{} }
 func (m *RemoveRequest) String() string { return proto.CompactTextString(m) }
 func (*RemoveRequest) ProtoMessage()    {}
 func (*RemoveRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{4}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{4}
 }
 func (m *RemoveRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RemoveRequest.Unmarshal(m, b)
@@ -908,7 +974,7 @@ func (m *RemoveReply) Reset()         { *m = RemoveReply{} }
 func (m *RemoveReply) String() string { return proto.CompactTextString(m) }
 func (*RemoveReply) ProtoMessage()    {}
 func (*RemoveReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{5}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{5}
 }
 func (m *RemoveReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RemoveReply.Unmarshal(m, b)
@@ -935,317 +1001,1425 @@ func (m *RemoveReply) GetError() string {
 	return ""
 }
 
-type CloseRequest struct {
+type ListRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *CloseRequest) Reset()         { *m = CloseRequest{} }
-func (m *CloseRequest) String() string { return proto.CompactTextString(m) }
-func (*CloseRequest) ProtoMessage()    {}
-func (*CloseRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{6}
+func (m *ListRequest) Reset()         { *m = ListRequest{} }
+func (m *ListRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRequest) ProtoMessage()    {}
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{6}
 }
-func (m *CloseRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_CloseRequest.Unmarshal(m, b)
+func (m *ListRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRequest.Unmarshal(m, b)
 }
-func (m *CloseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_CloseRequest.Marshal(b, m, deterministic)
+func (m *ListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRequest.Marshal(b, m, deterministic)
 }
-func (dst *CloseRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CloseRequest.Merge(dst, src)
+func (dst *ListRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRequest.Merge(dst, src)
 }
-func (m *CloseRequest) XXX_Size() int {
-	return xxx_messageInfo_CloseRequest.Size(m)
+func (m *ListRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRequest.Size(m)
 }
-func (m *CloseRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_CloseRequest.DiscardUnknown(m)
+func (m *ListRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CloseRequest proto.InternalMessageInfo
+var xxx_messageInfo_ListRequest proto.InternalMessageInfo
 
-type CloseReply struct {
-	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+type ListReply struct {
+	Dbnames              []string `protobuf:"bytes,1,rep,name=dbnames,proto3" json:"dbnames,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *CloseReply) Reset()         { *m = CloseReply{} }
-func (m *CloseReply) String() string { return proto.CompactTextString(m) }
-func (*CloseReply) ProtoMessage()    {}
-func (*CloseReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{7}
+func (m *ListReply) Reset()         { *m = ListReply{} }
+func (m *ListReply) String() string { return proto.CompactTextString(m) }
+func (*ListReply) ProtoMessage()    {}
+func (*ListReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{7}
 }
-func (m *CloseReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_CloseReply.Unmarshal(m, b)
+func (m *ListReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListReply.Unmarshal(m, b)
 }
-func (m *CloseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_CloseReply.Marshal(b, m, deterministic)
+func (m *ListReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListReply.Marshal(b, m, deterministic)
 }
-func (dst *CloseReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CloseReply.Merge(dst, src)
+func (dst *ListReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListReply.Merge(dst, src)
 }
-func (m *CloseReply) XXX_Size() int {
-	return xxx_messageInfo_CloseReply.Size(m)
+func (m *ListReply) XXX_Size() int {
+	return xxx_messageInfo_ListReply.Size(m)
 }
-func (m *CloseReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_CloseReply.DiscardUnknown(m)
+func (m *ListReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CloseReply proto.InternalMessageInfo
+var xxx_messageInfo_ListReply proto.InternalMessageInfo
 
-func (m *CloseReply) GetError() string {
+func (m *ListReply) GetDbnames() []string {
+	if m != nil {
+		return m.Dbnames
+	}
+	return nil
+}
+
+func (m *ListReply) GetError() string {
 	if m != nil {
 		return m.Error
 	}
 	return ""
 }
 
-type GetRequest struct {
-	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
-	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+type ListTablesRequest struct {
+	Dbname               string   `protobuf:"bytes,1,opt,name=dbname,proto3" json:"dbname,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetRequest) Reset()         { *m = GetRequest{} }
-func (m *GetRequest) String() string { return proto.CompactTextString(m) }
-func (*GetRequest) ProtoMessage()    {}
-func (*GetRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{8}
+func (m *ListTablesRequest) Reset()         { *m = ListTablesRequest{} }
+func (m *ListTablesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTablesRequest) ProtoMessage()    {}
+func (*ListTablesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{8}
 }
-func (m *GetRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+func (m *ListTablesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesRequest.Unmarshal(m, b)
 }
-func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+func (m *ListTablesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesRequest.Marshal(b, m, deterministic)
 }
-func (dst *GetRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetRequest.Merge(dst, src)
+func (dst *ListTablesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesRequest.Merge(dst, src)
 }
-func (m *GetRequest) XXX_Size() int {
-	return xxx_messageInfo_GetRequest.Size(m)
+func (m *ListTablesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTablesRequest.Size(m)
 }
-func (m *GetRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+func (m *ListTablesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetRequest proto.InternalMessageInfo
-
-func (m *GetRequest) GetTxid() uint64 {
-	if m != nil {
-		return m.Txid
-	}
-	return 0
-}
+var xxx_messageInfo_ListTablesRequest proto.InternalMessageInfo
 
-func (m *GetRequest) GetKey() []byte {
+func (m *ListTablesRequest) GetDbname() string {
 	if m != nil {
-		return m.Key
+		return m.Dbname
 	}
-	return nil
+	return ""
 }
 
-type GetReply struct {
-	Value                []byte   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+type ListTablesReply struct {
+	Tables               []string `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
 	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetReply) Reset()         { *m = GetReply{} }
-func (m *GetReply) String() string { return proto.CompactTextString(m) }
-func (*GetReply) ProtoMessage()    {}
-func (*GetReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{9}
+func (m *ListTablesReply) Reset()         { *m = ListTablesReply{} }
+func (m *ListTablesReply) String() string { return proto.CompactTextString(m) }
+func (*ListTablesReply) ProtoMessage()    {}
+func (*ListTablesReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{9}
 }
-func (m *GetReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetReply.Unmarshal(m, b)
+func (m *ListTablesReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesReply.Unmarshal(m, b)
 }
-func (m *GetReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetReply.Marshal(b, m, deterministic)
+func (m *ListTablesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesReply.Marshal(b, m, deterministic)
 }
-func (dst *GetReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetReply.Merge(dst, src)
+func (dst *ListTablesReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesReply.Merge(dst, src)
 }
-func (m *GetReply) XXX_Size() int {
-	return xxx_messageInfo_GetReply.Size(m)
+func (m *ListTablesReply) XXX_Size() int {
+	return xxx_messageInfo_ListTablesReply.Size(m)
 }
-func (m *GetReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetReply.DiscardUnknown(m)
+func (m *ListTablesReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetReply proto.InternalMessageInfo
+var xxx_messageInfo_ListTablesReply proto.InternalMessageInfo
 
-func (m *GetReply) GetValue() []byte {
+func (m *ListTablesReply) GetTables() []string {
 	if m != nil {
-		return m.Value
+		return m.Tables
 	}
 	return nil
 }
 
-func (m *GetReply) GetError() string {
+func (m *ListTablesReply) GetError() string {
 	if m != nil {
 		return m.Error
 	}
 	return ""
 }
 
-type PutRequest struct {
-	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
-	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
-	Sync                 bool     `protobuf:"varint,4,opt,name=sync,proto3" json:"sync,omitempty"`
+type SlowOpsRequest struct {
+	Limit                int32    `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *PutRequest) Reset()         { *m = PutRequest{} }
-func (m *PutRequest) String() string { return proto.CompactTextString(m) }
-func (*PutRequest) ProtoMessage()    {}
-func (*PutRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{10}
+func (m *SlowOpsRequest) Reset()         { *m = SlowOpsRequest{} }
+func (m *SlowOpsRequest) String() string { return proto.CompactTextString(m) }
+func (*SlowOpsRequest) ProtoMessage()    {}
+func (*SlowOpsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{10}
 }
-func (m *PutRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_PutRequest.Unmarshal(m, b)
+func (m *SlowOpsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SlowOpsRequest.Unmarshal(m, b)
 }
-func (m *PutRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_PutRequest.Marshal(b, m, deterministic)
+func (m *SlowOpsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SlowOpsRequest.Marshal(b, m, deterministic)
 }
-func (dst *PutRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_PutRequest.Merge(dst, src)
+func (dst *SlowOpsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SlowOpsRequest.Merge(dst, src)
 }
-func (m *PutRequest) XXX_Size() int {
-	return xxx_messageInfo_PutRequest.Size(m)
+func (m *SlowOpsRequest) XXX_Size() int {
+	return xxx_messageInfo_SlowOpsRequest.Size(m)
 }
-func (m *PutRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_PutRequest.DiscardUnknown(m)
+func (m *SlowOpsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SlowOpsRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_PutRequest proto.InternalMessageInfo
+var xxx_messageInfo_SlowOpsRequest proto.InternalMessageInfo
 
-func (m *PutRequest) GetTxid() uint64 {
+func (m *SlowOpsRequest) GetLimit() int32 {
 	if m != nil {
-		return m.Txid
+		return m.Limit
 	}
 	return 0
 }
 
-func (m *PutRequest) GetKey() []byte {
+type SlowOp struct {
+	Time                 int64    `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	Duration             int64    `protobuf:"varint,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	Type                 string   `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Dbname               string   `protobuf:"bytes,4,opt,name=dbname,proto3" json:"dbname,omitempty"`
+	Table                string   `protobuf:"bytes,5,opt,name=table,proto3" json:"table,omitempty"`
+	KeyPrefix            []byte   `protobuf:"bytes,6,opt,name=key_prefix,json=keyPrefix,proto3" json:"key_prefix,omitempty"`
+	KeySize              int32    `protobuf:"varint,7,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
+	Entries              int32    `protobuf:"varint,8,opt,name=entries,proto3" json:"entries,omitempty"`
+	Connection           uint64   `protobuf:"varint,9,opt,name=connection,proto3" json:"connection,omitempty"`
+	Identity             string   `protobuf:"bytes,10,opt,name=identity,proto3" json:"identity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SlowOp) Reset()         { *m = SlowOp{} }
+func (m *SlowOp) String() string { return proto.CompactTextString(m) }
+func (*SlowOp) ProtoMessage()    {}
+func (*SlowOp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{11}
+}
+func (m *SlowOp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SlowOp.Unmarshal(m, b)
+}
+func (m *SlowOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SlowOp.Marshal(b, m, deterministic)
+}
+func (dst *SlowOp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SlowOp.Merge(dst, src)
+}
+func (m *SlowOp) XXX_Size() int {
+	return xxx_messageInfo_SlowOp.Size(m)
+}
+func (m *SlowOp) XXX_DiscardUnknown() {
+	xxx_messageInfo_SlowOp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SlowOp proto.InternalMessageInfo
+
+func (m *SlowOp) GetTime() int64 {
 	if m != nil {
-		return m.Key
+		return m.Time
 	}
-	return nil
+	return 0
 }
 
-func (m *PutRequest) GetValue() []byte {
+func (m *SlowOp) GetDuration() int64 {
 	if m != nil {
-		return m.Value
+		return m.Duration
 	}
-	return nil
+	return 0
 }
 
-func (m *PutRequest) GetSync() bool {
+func (m *SlowOp) GetType() string {
 	if m != nil {
-		return m.Sync
+		return m.Type
 	}
-	return false
+	return ""
 }
 
-type PutReply struct {
-	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *SlowOp) GetDbname() string {
+	if m != nil {
+		return m.Dbname
+	}
+	return ""
 }
 
-func (m *PutReply) Reset()         { *m = PutReply{} }
-func (m *PutReply) String() string { return proto.CompactTextString(m) }
-func (*PutReply) ProtoMessage()    {}
-func (*PutReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{11}
-}
-func (m *PutReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_PutReply.Unmarshal(m, b)
-}
-func (m *PutReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_PutReply.Marshal(b, m, deterministic)
+func (m *SlowOp) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
 }
-func (dst *PutReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_PutReply.Merge(dst, src)
+
+func (m *SlowOp) GetKeyPrefix() []byte {
+	if m != nil {
+		return m.KeyPrefix
+	}
+	return nil
 }
-func (m *PutReply) XXX_Size() int {
-	return xxx_messageInfo_PutReply.Size(m)
+
+func (m *SlowOp) GetKeySize() int32 {
+	if m != nil {
+		return m.KeySize
+	}
+	return 0
 }
-func (m *PutReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_PutReply.DiscardUnknown(m)
+
+func (m *SlowOp) GetEntries() int32 {
+	if m != nil {
+		return m.Entries
+	}
+	return 0
 }
 
-var xxx_messageInfo_PutReply proto.InternalMessageInfo
+func (m *SlowOp) GetConnection() uint64 {
+	if m != nil {
+		return m.Connection
+	}
+	return 0
+}
 
-func (m *PutReply) GetError() string {
+func (m *SlowOp) GetIdentity() string {
 	if m != nil {
-		return m.Error
+		return m.Identity
 	}
 	return ""
 }
 
-type BeginRequest struct {
-	Table                string   `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+type SlowOpsReply struct {
+	Ops                  []*SlowOp `protobuf:"bytes,1,rep,name=ops,proto3" json:"ops,omitempty"`
+	Error                string    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *BeginRequest) Reset()         { *m = BeginRequest{} }
-func (m *BeginRequest) String() string { return proto.CompactTextString(m) }
-func (*BeginRequest) ProtoMessage()    {}
-func (*BeginRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{12}
+func (m *SlowOpsReply) Reset()         { *m = SlowOpsReply{} }
+func (m *SlowOpsReply) String() string { return proto.CompactTextString(m) }
+func (*SlowOpsReply) ProtoMessage()    {}
+func (*SlowOpsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{12}
 }
-func (m *BeginRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BeginRequest.Unmarshal(m, b)
+func (m *SlowOpsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SlowOpsReply.Unmarshal(m, b)
 }
-func (m *BeginRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BeginRequest.Marshal(b, m, deterministic)
+func (m *SlowOpsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SlowOpsReply.Marshal(b, m, deterministic)
 }
-func (dst *BeginRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BeginRequest.Merge(dst, src)
+func (dst *SlowOpsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SlowOpsReply.Merge(dst, src)
 }
-func (m *BeginRequest) XXX_Size() int {
-	return xxx_messageInfo_BeginRequest.Size(m)
+func (m *SlowOpsReply) XXX_Size() int {
+	return xxx_messageInfo_SlowOpsReply.Size(m)
 }
-func (m *BeginRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_BeginRequest.DiscardUnknown(m)
+func (m *SlowOpsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SlowOpsReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_BeginRequest proto.InternalMessageInfo
+var xxx_messageInfo_SlowOpsReply proto.InternalMessageInfo
 
-func (m *BeginRequest) GetTable() string {
+func (m *SlowOpsReply) GetOps() []*SlowOp {
 	if m != nil {
-		return m.Table
+		return m.Ops
+	}
+	return nil
+}
+
+func (m *SlowOpsReply) GetError() string {
+	if m != nil {
+		return m.Error
 	}
 	return ""
 }
 
-type BeginReply struct {
-	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
-	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+type ListSessionsRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *BeginReply) Reset()         { *m = BeginReply{} }
-func (m *BeginReply) String() string { return proto.CompactTextString(m) }
-func (*BeginReply) ProtoMessage()    {}
-func (*BeginReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{13}
+func (m *ListSessionsRequest) Reset()         { *m = ListSessionsRequest{} }
+func (m *ListSessionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListSessionsRequest) ProtoMessage()    {}
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{13}
+}
+func (m *ListSessionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSessionsRequest.Unmarshal(m, b)
+}
+func (m *ListSessionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSessionsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListSessionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSessionsRequest.Merge(dst, src)
+}
+func (m *ListSessionsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListSessionsRequest.Size(m)
+}
+func (m *ListSessionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSessionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSessionsRequest proto.InternalMessageInfo
+
+type Session struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Peer                 string   `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"`
+	Identity             string   `protobuf:"bytes,3,opt,name=identity,proto3" json:"identity,omitempty"`
+	Dbname               string   `protobuf:"bytes,4,opt,name=dbname,proto3" json:"dbname,omitempty"`
+	Transactions         int32    `protobuf:"varint,5,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	Iterators            int32    `protobuf:"varint,6,opt,name=iterators,proto3" json:"iterators,omitempty"`
+	Opened               int64    `protobuf:"varint,7,opt,name=opened,proto3" json:"opened,omitempty"`
+	Age                  int64    `protobuf:"varint,8,opt,name=age,proto3" json:"age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Session) Reset()         { *m = Session{} }
+func (m *Session) String() string { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()    {}
+func (*Session) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{14}
+}
+func (m *Session) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Session.Unmarshal(m, b)
+}
+func (m *Session) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Session.Marshal(b, m, deterministic)
+}
+func (dst *Session) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Session.Merge(dst, src)
+}
+func (m *Session) XXX_Size() int {
+	return xxx_messageInfo_Session.Size(m)
+}
+func (m *Session) XXX_DiscardUnknown() {
+	xxx_messageInfo_Session.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Session proto.InternalMessageInfo
+
+func (m *Session) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Session) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *Session) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *Session) GetDbname() string {
+	if m != nil {
+		return m.Dbname
+	}
+	return ""
+}
+
+func (m *Session) GetTransactions() int32 {
+	if m != nil {
+		return m.Transactions
+	}
+	return 0
+}
+
+func (m *Session) GetIterators() int32 {
+	if m != nil {
+		return m.Iterators
+	}
+	return 0
+}
+
+func (m *Session) GetOpened() int64 {
+	if m != nil {
+		return m.Opened
+	}
+	return 0
+}
+
+func (m *Session) GetAge() int64 {
+	if m != nil {
+		return m.Age
+	}
+	return 0
+}
+
+type ListSessionsReply struct {
+	Sessions             []*Session `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	Error                string     `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ListSessionsReply) Reset()         { *m = ListSessionsReply{} }
+func (m *ListSessionsReply) String() string { return proto.CompactTextString(m) }
+func (*ListSessionsReply) ProtoMessage()    {}
+func (*ListSessionsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{15}
+}
+func (m *ListSessionsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSessionsReply.Unmarshal(m, b)
+}
+func (m *ListSessionsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSessionsReply.Marshal(b, m, deterministic)
+}
+func (dst *ListSessionsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSessionsReply.Merge(dst, src)
+}
+func (m *ListSessionsReply) XXX_Size() int {
+	return xxx_messageInfo_ListSessionsReply.Size(m)
+}
+func (m *ListSessionsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSessionsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSessionsReply proto.InternalMessageInfo
+
+func (m *ListSessionsReply) GetSessions() []*Session {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+func (m *ListSessionsReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type KillSessionRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KillSessionRequest) Reset()         { *m = KillSessionRequest{} }
+func (m *KillSessionRequest) String() string { return proto.CompactTextString(m) }
+func (*KillSessionRequest) ProtoMessage()    {}
+func (*KillSessionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{16}
+}
+func (m *KillSessionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KillSessionRequest.Unmarshal(m, b)
+}
+func (m *KillSessionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KillSessionRequest.Marshal(b, m, deterministic)
+}
+func (dst *KillSessionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KillSessionRequest.Merge(dst, src)
+}
+func (m *KillSessionRequest) XXX_Size() int {
+	return xxx_messageInfo_KillSessionRequest.Size(m)
+}
+func (m *KillSessionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KillSessionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KillSessionRequest proto.InternalMessageInfo
+
+func (m *KillSessionRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type KillSessionReply struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KillSessionReply) Reset()         { *m = KillSessionReply{} }
+func (m *KillSessionReply) String() string { return proto.CompactTextString(m) }
+func (*KillSessionReply) ProtoMessage()    {}
+func (*KillSessionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{17}
+}
+func (m *KillSessionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KillSessionReply.Unmarshal(m, b)
+}
+func (m *KillSessionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KillSessionReply.Marshal(b, m, deterministic)
+}
+func (dst *KillSessionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KillSessionReply.Merge(dst, src)
+}
+func (m *KillSessionReply) XXX_Size() int {
+	return xxx_messageInfo_KillSessionReply.Size(m)
+}
+func (m *KillSessionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_KillSessionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KillSessionReply proto.InternalMessageInfo
+
+func (m *KillSessionReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ReplicateRequest struct {
+	Dbname               string   `protobuf:"bytes,1,opt,name=dbname,proto3" json:"dbname,omitempty"`
+	Epoch                string   `protobuf:"bytes,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Position             uint64   `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicateRequest) Reset()         { *m = ReplicateRequest{} }
+func (m *ReplicateRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicateRequest) ProtoMessage()    {}
+func (*ReplicateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{18}
+}
+func (m *ReplicateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicateRequest.Unmarshal(m, b)
+}
+func (m *ReplicateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicateRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReplicateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicateRequest.Merge(dst, src)
+}
+func (m *ReplicateRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplicateRequest.Size(m)
+}
+func (m *ReplicateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicateRequest proto.InternalMessageInfo
+
+func (m *ReplicateRequest) GetDbname() string {
+	if m != nil {
+		return m.Dbname
+	}
+	return ""
+}
+
+func (m *ReplicateRequest) GetEpoch() string {
+	if m != nil {
+		return m.Epoch
+	}
+	return ""
+}
+
+func (m *ReplicateRequest) GetPosition() uint64 {
+	if m != nil {
+		return m.Position
+	}
+	return 0
+}
+
+type Mutation struct {
+	Key                  []byte   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Delete               bool     `protobuf:"varint,3,opt,name=delete,proto3" json:"delete,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Mutation) Reset()         { *m = Mutation{} }
+func (m *Mutation) String() string { return proto.CompactTextString(m) }
+func (*Mutation) ProtoMessage()    {}
+func (*Mutation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{19}
+}
+func (m *Mutation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Mutation.Unmarshal(m, b)
+}
+func (m *Mutation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Mutation.Marshal(b, m, deterministic)
+}
+func (dst *Mutation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Mutation.Merge(dst, src)
+}
+func (m *Mutation) XXX_Size() int {
+	return xxx_messageInfo_Mutation.Size(m)
+}
+func (m *Mutation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Mutation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Mutation proto.InternalMessageInfo
+
+func (m *Mutation) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *Mutation) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Mutation) GetDelete() bool {
+	if m != nil {
+		return m.Delete
+	}
+	return false
+}
+
+type ReplicationEvent struct {
+	Epoch                string      `protobuf:"bytes,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Position             uint64      `protobuf:"varint,2,opt,name=position,proto3" json:"position,omitempty"`
+	Table                string      `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	Mutations            []*Mutation `protobuf:"bytes,4,rep,name=mutations,proto3" json:"mutations,omitempty"`
+	Clear                bool        `protobuf:"varint,5,opt,name=clear,proto3" json:"clear,omitempty"`
+	Snapshot             bool        `protobuf:"varint,6,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Error                string      `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ReplicationEvent) Reset()         { *m = ReplicationEvent{} }
+func (m *ReplicationEvent) String() string { return proto.CompactTextString(m) }
+func (*ReplicationEvent) ProtoMessage()    {}
+func (*ReplicationEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{20}
+}
+func (m *ReplicationEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationEvent.Unmarshal(m, b)
+}
+func (m *ReplicationEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationEvent.Marshal(b, m, deterministic)
+}
+func (dst *ReplicationEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationEvent.Merge(dst, src)
+}
+func (m *ReplicationEvent) XXX_Size() int {
+	return xxx_messageInfo_ReplicationEvent.Size(m)
+}
+func (m *ReplicationEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationEvent proto.InternalMessageInfo
+
+func (m *ReplicationEvent) GetEpoch() string {
+	if m != nil {
+		return m.Epoch
+	}
+	return ""
+}
+
+func (m *ReplicationEvent) GetPosition() uint64 {
+	if m != nil {
+		return m.Position
+	}
+	return 0
+}
+
+func (m *ReplicationEvent) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *ReplicationEvent) GetMutations() []*Mutation {
+	if m != nil {
+		return m.Mutations
+	}
+	return nil
+}
+
+func (m *ReplicationEvent) GetClear() bool {
+	if m != nil {
+		return m.Clear
+	}
+	return false
+}
+
+func (m *ReplicationEvent) GetSnapshot() bool {
+	if m != nil {
+		return m.Snapshot
+	}
+	return false
+}
+
+func (m *ReplicationEvent) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ReplicationStatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicationStatusRequest) Reset()         { *m = ReplicationStatusRequest{} }
+func (m *ReplicationStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicationStatusRequest) ProtoMessage()    {}
+func (*ReplicationStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{21}
+}
+func (m *ReplicationStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationStatusRequest.Unmarshal(m, b)
+}
+func (m *ReplicationStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationStatusRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReplicationStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationStatusRequest.Merge(dst, src)
+}
+func (m *ReplicationStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplicationStatusRequest.Size(m)
+}
+func (m *ReplicationStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationStatusRequest proto.InternalMessageInfo
+
+type ReplicationPosition struct {
+	Dbname               string   `protobuf:"bytes,1,opt,name=dbname,proto3" json:"dbname,omitempty"`
+	Epoch                string   `protobuf:"bytes,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Position             uint64   `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+	Connected            bool     `protobuf:"varint,4,opt,name=connected,proto3" json:"connected,omitempty"`
+	Error                string   `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicationPosition) Reset()         { *m = ReplicationPosition{} }
+func (m *ReplicationPosition) String() string { return proto.CompactTextString(m) }
+func (*ReplicationPosition) ProtoMessage()    {}
+func (*ReplicationPosition) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{22}
+}
+func (m *ReplicationPosition) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationPosition.Unmarshal(m, b)
+}
+func (m *ReplicationPosition) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationPosition.Marshal(b, m, deterministic)
+}
+func (dst *ReplicationPosition) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationPosition.Merge(dst, src)
+}
+func (m *ReplicationPosition) XXX_Size() int {
+	return xxx_messageInfo_ReplicationPosition.Size(m)
+}
+func (m *ReplicationPosition) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationPosition.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationPosition proto.InternalMessageInfo
+
+func (m *ReplicationPosition) GetDbname() string {
+	if m != nil {
+		return m.Dbname
+	}
+	return ""
+}
+
+func (m *ReplicationPosition) GetEpoch() string {
+	if m != nil {
+		return m.Epoch
+	}
+	return ""
+}
+
+func (m *ReplicationPosition) GetPosition() uint64 {
+	if m != nil {
+		return m.Position
+	}
+	return 0
+}
+
+func (m *ReplicationPosition) GetConnected() bool {
+	if m != nil {
+		return m.Connected
+	}
+	return false
+}
+
+func (m *ReplicationPosition) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ReplicationStatusReply struct {
+	Primary              string                 `protobuf:"bytes,1,opt,name=primary,proto3" json:"primary,omitempty"`
+	Databases            []*ReplicationPosition `protobuf:"bytes,2,rep,name=databases,proto3" json:"databases,omitempty"`
+	Error                string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *ReplicationStatusReply) Reset()         { *m = ReplicationStatusReply{} }
+func (m *ReplicationStatusReply) String() string { return proto.CompactTextString(m) }
+func (*ReplicationStatusReply) ProtoMessage()    {}
+func (*ReplicationStatusReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{23}
+}
+func (m *ReplicationStatusReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationStatusReply.Unmarshal(m, b)
+}
+func (m *ReplicationStatusReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationStatusReply.Marshal(b, m, deterministic)
+}
+func (dst *ReplicationStatusReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationStatusReply.Merge(dst, src)
+}
+func (m *ReplicationStatusReply) XXX_Size() int {
+	return xxx_messageInfo_ReplicationStatusReply.Size(m)
+}
+func (m *ReplicationStatusReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationStatusReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationStatusReply proto.InternalMessageInfo
+
+func (m *ReplicationStatusReply) GetPrimary() string {
+	if m != nil {
+		return m.Primary
+	}
+	return ""
+}
+
+func (m *ReplicationStatusReply) GetDatabases() []*ReplicationPosition {
+	if m != nil {
+		return m.Databases
+	}
+	return nil
+}
+
+func (m *ReplicationStatusReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type PromoteRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PromoteRequest) Reset()         { *m = PromoteRequest{} }
+func (m *PromoteRequest) String() string { return proto.CompactTextString(m) }
+func (*PromoteRequest) ProtoMessage()    {}
+func (*PromoteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{24}
+}
+func (m *PromoteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PromoteRequest.Unmarshal(m, b)
+}
+func (m *PromoteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PromoteRequest.Marshal(b, m, deterministic)
+}
+func (dst *PromoteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PromoteRequest.Merge(dst, src)
+}
+func (m *PromoteRequest) XXX_Size() int {
+	return xxx_messageInfo_PromoteRequest.Size(m)
+}
+func (m *PromoteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PromoteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PromoteRequest proto.InternalMessageInfo
+
+type PromoteReply struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PromoteReply) Reset()         { *m = PromoteReply{} }
+func (m *PromoteReply) String() string { return proto.CompactTextString(m) }
+func (*PromoteReply) ProtoMessage()    {}
+func (*PromoteReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{25}
+}
+func (m *PromoteReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PromoteReply.Unmarshal(m, b)
+}
+func (m *PromoteReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PromoteReply.Marshal(b, m, deterministic)
+}
+func (dst *PromoteReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PromoteReply.Merge(dst, src)
+}
+func (m *PromoteReply) XXX_Size() int {
+	return xxx_messageInfo_PromoteReply.Size(m)
+}
+func (m *PromoteReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PromoteReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PromoteReply proto.InternalMessageInfo
+
+func (m *PromoteReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type CloseRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CloseRequest) Reset()         { *m = CloseRequest{} }
+func (m *CloseRequest) String() string { return proto.CompactTextString(m) }
+func (*CloseRequest) ProtoMessage()    {}
+func (*CloseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{26}
+}
+func (m *CloseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CloseRequest.Unmarshal(m, b)
+}
+func (m *CloseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CloseRequest.Marshal(b, m, deterministic)
+}
+func (dst *CloseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CloseRequest.Merge(dst, src)
+}
+func (m *CloseRequest) XXX_Size() int {
+	return xxx_messageInfo_CloseRequest.Size(m)
+}
+func (m *CloseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CloseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CloseRequest proto.InternalMessageInfo
+
+type CloseReply struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CloseReply) Reset()         { *m = CloseReply{} }
+func (m *CloseReply) String() string { return proto.CompactTextString(m) }
+func (*CloseReply) ProtoMessage()    {}
+func (*CloseReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{27}
+}
+func (m *CloseReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CloseReply.Unmarshal(m, b)
+}
+func (m *CloseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CloseReply.Marshal(b, m, deterministic)
+}
+func (dst *CloseReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CloseReply.Merge(dst, src)
+}
+func (m *CloseReply) XXX_Size() int {
+	return xxx_messageInfo_CloseReply.Size(m)
+}
+func (m *CloseReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CloseReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CloseReply proto.InternalMessageInfo
+
+func (m *CloseReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type GetRequest struct {
+	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetRequest) Reset()         { *m = GetRequest{} }
+func (m *GetRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()    {}
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{28}
+}
+func (m *GetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+}
+func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRequest.Merge(dst, src)
+}
+func (m *GetRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRequest.Size(m)
+}
+func (m *GetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRequest proto.InternalMessageInfo
+
+func (m *GetRequest) GetTxid() uint64 {
+	if m != nil {
+		return m.Txid
+	}
+	return 0
+}
+
+func (m *GetRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+type GetReply struct {
+	Value                []byte   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetReply) Reset()         { *m = GetReply{} }
+func (m *GetReply) String() string { return proto.CompactTextString(m) }
+func (*GetReply) ProtoMessage()    {}
+func (*GetReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{29}
+}
+func (m *GetReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetReply.Unmarshal(m, b)
+}
+func (m *GetReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetReply.Marshal(b, m, deterministic)
+}
+func (dst *GetReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetReply.Merge(dst, src)
+}
+func (m *GetReply) XXX_Size() int {
+	return xxx_messageInfo_GetReply.Size(m)
+}
+func (m *GetReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetReply proto.InternalMessageInfo
+
+func (m *GetReply) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *GetReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type PutRequest struct {
+	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Sync                 bool     `protobuf:"varint,4,opt,name=sync,proto3" json:"sync,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PutRequest) Reset()         { *m = PutRequest{} }
+func (m *PutRequest) String() string { return proto.CompactTextString(m) }
+func (*PutRequest) ProtoMessage()    {}
+func (*PutRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{30}
+}
+func (m *PutRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PutRequest.Unmarshal(m, b)
+}
+func (m *PutRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PutRequest.Marshal(b, m, deterministic)
+}
+func (dst *PutRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutRequest.Merge(dst, src)
+}
+func (m *PutRequest) XXX_Size() int {
+	return xxx_messageInfo_PutRequest.Size(m)
+}
+func (m *PutRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PutRequest proto.InternalMessageInfo
+
+func (m *PutRequest) GetTxid() uint64 {
+	if m != nil {
+		return m.Txid
+	}
+	return 0
+}
+
+func (m *PutRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *PutRequest) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *PutRequest) GetSync() bool {
+	if m != nil {
+		return m.Sync
+	}
+	return false
+}
+
+type PutReply struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PutReply) Reset()         { *m = PutReply{} }
+func (m *PutReply) String() string { return proto.CompactTextString(m) }
+func (*PutReply) ProtoMessage()    {}
+func (*PutReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{31}
+}
+func (m *PutReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PutReply.Unmarshal(m, b)
+}
+func (m *PutReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PutReply.Marshal(b, m, deterministic)
+}
+func (dst *PutReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutReply.Merge(dst, src)
+}
+func (m *PutReply) XXX_Size() int {
+	return xxx_messageInfo_PutReply.Size(m)
+}
+func (m *PutReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PutReply proto.InternalMessageInfo
+
+func (m *PutReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type DeleteRequest struct {
+	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()    {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{32}
+}
+func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+}
+func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+}
+func (dst *DeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteRequest.Merge(dst, src)
+}
+func (m *DeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteRequest.Size(m)
+}
+func (m *DeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+
+func (m *DeleteRequest) GetTxid() uint64 {
+	if m != nil {
+		return m.Txid
+	}
+	return 0
+}
+
+func (m *DeleteRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+type DeleteReply struct {
+	Value                []byte   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteReply) Reset()         { *m = DeleteReply{} }
+func (m *DeleteReply) String() string { return proto.CompactTextString(m) }
+func (*DeleteReply) ProtoMessage()    {}
+func (*DeleteReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{33}
+}
+func (m *DeleteReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteReply.Unmarshal(m, b)
+}
+func (m *DeleteReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteReply.Marshal(b, m, deterministic)
+}
+func (dst *DeleteReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteReply.Merge(dst, src)
+}
+func (m *DeleteReply) XXX_Size() int {
+	return xxx_messageInfo_DeleteReply.Size(m)
+}
+func (m *DeleteReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteReply proto.InternalMessageInfo
+
+func (m *DeleteReply) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *DeleteReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type BeginRequest struct {
+	Table                string   `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BeginRequest) Reset()         { *m = BeginRequest{} }
+func (m *BeginRequest) String() string { return proto.CompactTextString(m) }
+func (*BeginRequest) ProtoMessage()    {}
+func (*BeginRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{34}
+}
+func (m *BeginRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BeginRequest.Unmarshal(m, b)
+}
+func (m *BeginRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BeginRequest.Marshal(b, m, deterministic)
+}
+func (dst *BeginRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BeginRequest.Merge(dst, src)
+}
+func (m *BeginRequest) XXX_Size() int {
+	return xxx_messageInfo_BeginRequest.Size(m)
+}
+func (m *BeginRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BeginRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BeginRequest proto.InternalMessageInfo
+
+func (m *BeginRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+type BeginReply struct {
+	Txid                 uint64   `protobuf:"varint,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BeginReply) Reset()         { *m = BeginReply{} }
+func (m *BeginReply) String() string { return proto.CompactTextString(m) }
+func (*BeginReply) ProtoMessage()    {}
+func (*BeginReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{35}
 }
 func (m *BeginReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BeginReply.Unmarshal(m, b)
@@ -1291,7 +2465,7 @@ func (m *CommitRequest) Reset()         { *m = CommitRequest{} }
 func (m *CommitRequest) String() string { return proto.CompactTextString(m) }
 func (*CommitRequest) ProtoMessage()    {}
 func (*CommitRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{14}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{36}
 }
 func (m *CommitRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CommitRequest.Unmarshal(m, b)
@@ -1336,7 +2510,7 @@ func (m *CommitReply) Reset()         { *m = CommitReply{} }
 func (m *CommitReply) String() string { return proto.CompactTextString(m) }
 func (*CommitReply) ProtoMessage()    {}
 func (*CommitReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{15}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{37}
 }
 func (m *CommitReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CommitReply.Unmarshal(m, b)
@@ -1374,7 +2548,7 @@ func (m *RollbackRequest) Reset()         { *m = RollbackRequest{} }
 func (m *RollbackRequest) String() string { return proto.CompactTextString(m) }
 func (*RollbackRequest) ProtoMessage()    {}
 func (*RollbackRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{16}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{38}
 }
 func (m *RollbackRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RollbackRequest.Unmarshal(m, b)
@@ -1412,7 +2586,7 @@ func (m *RollbackReply) Reset()         { *m = RollbackReply{} }
 func (m *RollbackReply) String() string { return proto.CompactTextString(m) }
 func (*RollbackReply) ProtoMessage()    {}
 func (*RollbackReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{17}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{39}
 }
 func (m *RollbackReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RollbackReply.Unmarshal(m, b)
@@ -1452,7 +2626,7 @@ func (m *LookupRequest) Reset()         { *m = LookupRequest{} }
 func (m *LookupRequest) String() string { return proto.CompactTextString(m) }
 func (*LookupRequest) ProtoMessage()    {}
 func (*LookupRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{18}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{40}
 }
 func (m *LookupRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LookupRequest.Unmarshal(m, b)
@@ -1505,7 +2679,7 @@ func (m *LookupReply) Reset()         { *m = LookupReply{} }
 func (m *LookupReply) String() string { return proto.CompactTextString(m) }
 func (*LookupReply) ProtoMessage()    {}
 func (*LookupReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{19}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{41}
 }
 func (m *LookupReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LookupReply.Unmarshal(m, b)
@@ -1550,7 +2724,7 @@ func (m *LookupNextRequest) Reset()         { *m = LookupNextRequest{} }
 func (m *LookupNextRequest) String() string { return proto.CompactTextString(m) }
 func (*LookupNextRequest) ProtoMessage()    {}
 func (*LookupNextRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{20}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{42}
 }
 func (m *LookupNextRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LookupNextRequest.Unmarshal(m, b)
@@ -1589,7 +2763,7 @@ func (m *KeyValue) Reset()         { *m = KeyValue{} }
 func (m *KeyValue) String() string { return proto.CompactTextString(m) }
 func (*KeyValue) ProtoMessage()    {}
 func (*KeyValue) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{21}
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{43}
 }
 func (m *KeyValue) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_KeyValue.Unmarshal(m, b)
@@ -1616,59 +2790,222 @@ func (m *KeyValue) GetKey() []byte {
 	return nil
 }
 
-func (m *KeyValue) GetValue() []byte {
-	if m != nil {
-		return m.Value
-	}
-	return nil
+func (m *KeyValue) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type LookupNextReply struct {
+	Entries              []*KeyValue `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Error                string      `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *LookupNextReply) Reset()         { *m = LookupNextReply{} }
+func (m *LookupNextReply) String() string { return proto.CompactTextString(m) }
+func (*LookupNextReply) ProtoMessage()    {}
+func (*LookupNextReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{44}
+}
+func (m *LookupNextReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LookupNextReply.Unmarshal(m, b)
+}
+func (m *LookupNextReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LookupNextReply.Marshal(b, m, deterministic)
+}
+func (dst *LookupNextReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupNextReply.Merge(dst, src)
+}
+func (m *LookupNextReply) XXX_Size() int {
+	return xxx_messageInfo_LookupNextReply.Size(m)
+}
+func (m *LookupNextReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupNextReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LookupNextReply proto.InternalMessageInfo
+
+func (m *LookupNextReply) GetEntries() []*KeyValue {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+func (m *LookupNextReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// a command in the replicated log of a cluster, which writes a transaction or creates or removes a database
+type ClusterCommand struct {
+	Dbname               string      `protobuf:"bytes,1,opt,name=dbname,proto3" json:"dbname,omitempty"`
+	Table                string      `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	Mutations            []*Mutation `protobuf:"bytes,3,rep,name=mutations,proto3" json:"mutations,omitempty"`
+	Create               bool        `protobuf:"varint,4,opt,name=create,proto3" json:"create,omitempty"`
+	Remove               bool        `protobuf:"varint,5,opt,name=remove,proto3" json:"remove,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ClusterCommand) Reset()         { *m = ClusterCommand{} }
+func (m *ClusterCommand) String() string { return proto.CompactTextString(m) }
+func (*ClusterCommand) ProtoMessage()    {}
+func (*ClusterCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{45}
+}
+func (m *ClusterCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterCommand.Unmarshal(m, b)
+}
+func (m *ClusterCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterCommand.Marshal(b, m, deterministic)
+}
+func (dst *ClusterCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterCommand.Merge(dst, src)
+}
+func (m *ClusterCommand) XXX_Size() int {
+	return xxx_messageInfo_ClusterCommand.Size(m)
+}
+func (m *ClusterCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterCommand proto.InternalMessageInfo
+
+func (m *ClusterCommand) GetDbname() string {
+	if m != nil {
+		return m.Dbname
+	}
+	return ""
+}
+
+func (m *ClusterCommand) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *ClusterCommand) GetMutations() []*Mutation {
+	if m != nil {
+		return m.Mutations
+	}
+	return nil
+}
+
+func (m *ClusterCommand) GetCreate() bool {
+	if m != nil {
+		return m.Create
+	}
+	return false
+}
+
+func (m *ClusterCommand) GetRemove() bool {
+	if m != nil {
+		return m.Remove
+	}
+	return false
+}
+
+type ClusterStatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClusterStatusRequest) Reset()         { *m = ClusterStatusRequest{} }
+func (m *ClusterStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*ClusterStatusRequest) ProtoMessage()    {}
+func (*ClusterStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{46}
+}
+func (m *ClusterStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterStatusRequest.Unmarshal(m, b)
+}
+func (m *ClusterStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterStatusRequest.Marshal(b, m, deterministic)
+}
+func (dst *ClusterStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterStatusRequest.Merge(dst, src)
+}
+func (m *ClusterStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_ClusterStatusRequest.Size(m)
+}
+func (m *ClusterStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterStatusRequest.DiscardUnknown(m)
 }
 
-type LookupNextReply struct {
-	Entries              []*KeyValue `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
-	Error                string      `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+var xxx_messageInfo_ClusterStatusRequest proto.InternalMessageInfo
+
+type ClusterStatusReply struct {
+	Leader               string   `protobuf:"bytes,1,opt,name=leader,proto3" json:"leader,omitempty"`
+	Members              []string `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	Error                string   `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Applied              uint64   `protobuf:"varint,4,opt,name=applied,proto3" json:"applied,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *LookupNextReply) Reset()         { *m = LookupNextReply{} }
-func (m *LookupNextReply) String() string { return proto.CompactTextString(m) }
-func (*LookupNextReply) ProtoMessage()    {}
-func (*LookupNextReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_keydbr_d8381e54dab9943c, []int{22}
+func (m *ClusterStatusReply) Reset()         { *m = ClusterStatusReply{} }
+func (m *ClusterStatusReply) String() string { return proto.CompactTextString(m) }
+func (*ClusterStatusReply) ProtoMessage()    {}
+func (*ClusterStatusReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_keydbr_54a2c1a5f0b6402a, []int{47}
 }
-func (m *LookupNextReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_LookupNextReply.Unmarshal(m, b)
+func (m *ClusterStatusReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterStatusReply.Unmarshal(m, b)
 }
-func (m *LookupNextReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_LookupNextReply.Marshal(b, m, deterministic)
+func (m *ClusterStatusReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterStatusReply.Marshal(b, m, deterministic)
 }
-func (dst *LookupNextReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_LookupNextReply.Merge(dst, src)
+func (dst *ClusterStatusReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterStatusReply.Merge(dst, src)
 }
-func (m *LookupNextReply) XXX_Size() int {
-	return xxx_messageInfo_LookupNextReply.Size(m)
+func (m *ClusterStatusReply) XXX_Size() int {
+	return xxx_messageInfo_ClusterStatusReply.Size(m)
 }
-func (m *LookupNextReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_LookupNextReply.DiscardUnknown(m)
+func (m *ClusterStatusReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterStatusReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_LookupNextReply proto.InternalMessageInfo
+var xxx_messageInfo_ClusterStatusReply proto.InternalMessageInfo
 
-func (m *LookupNextReply) GetEntries() []*KeyValue {
+func (m *ClusterStatusReply) GetLeader() string {
 	if m != nil {
-		return m.Entries
+		return m.Leader
+	}
+	return ""
+}
+
+func (m *ClusterStatusReply) GetMembers() []string {
+	if m != nil {
+		return m.Members
 	}
 	return nil
 }
 
-func (m *LookupNextReply) GetError() string {
+func (m *ClusterStatusReply) GetError() string {
 	if m != nil {
 		return m.Error
 	}
 	return ""
 }
 
+func (m *ClusterStatusReply) GetApplied() uint64 {
+	if m != nil {
+		return m.Applied
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*InMessage)(nil), "remote.InMessage")
 	proto.RegisterType((*OutMessage)(nil), "remote.OutMessage")
@@ -1676,12 +3013,34 @@ func init() {
 	proto.RegisterType((*OpenReply)(nil), "remote.OpenReply")
 	proto.RegisterType((*RemoveRequest)(nil), "remote.RemoveRequest")
 	proto.RegisterType((*RemoveReply)(nil), "remote.RemoveReply")
+	proto.RegisterType((*ListRequest)(nil), "remote.ListRequest")
+	proto.RegisterType((*ListReply)(nil), "remote.ListReply")
+	proto.RegisterType((*ListTablesRequest)(nil), "remote.ListTablesRequest")
+	proto.RegisterType((*ListTablesReply)(nil), "remote.ListTablesReply")
+	proto.RegisterType((*SlowOpsRequest)(nil), "remote.SlowOpsRequest")
+	proto.RegisterType((*SlowOp)(nil), "remote.SlowOp")
+	proto.RegisterType((*SlowOpsReply)(nil), "remote.SlowOpsReply")
+	proto.RegisterType((*ListSessionsRequest)(nil), "remote.ListSessionsRequest")
+	proto.RegisterType((*Session)(nil), "remote.Session")
+	proto.RegisterType((*ListSessionsReply)(nil), "remote.ListSessionsReply")
+	proto.RegisterType((*KillSessionRequest)(nil), "remote.KillSessionRequest")
+	proto.RegisterType((*KillSessionReply)(nil), "remote.KillSessionReply")
+	proto.RegisterType((*ReplicateRequest)(nil), "remote.ReplicateRequest")
+	proto.RegisterType((*Mutation)(nil), "remote.Mutation")
+	proto.RegisterType((*ReplicationEvent)(nil), "remote.ReplicationEvent")
+	proto.RegisterType((*ReplicationStatusRequest)(nil), "remote.ReplicationStatusRequest")
+	proto.RegisterType((*ReplicationPosition)(nil), "remote.ReplicationPosition")
+	proto.RegisterType((*ReplicationStatusReply)(nil), "remote.ReplicationStatusReply")
+	proto.RegisterType((*PromoteRequest)(nil), "remote.PromoteRequest")
+	proto.RegisterType((*PromoteReply)(nil), "remote.PromoteReply")
 	proto.RegisterType((*CloseRequest)(nil), "remote.CloseRequest")
 	proto.RegisterType((*CloseReply)(nil), "remote.CloseReply")
 	proto.RegisterType((*GetRequest)(nil), "remote.GetRequest")
 	proto.RegisterType((*GetReply)(nil), "remote.GetReply")
 	proto.RegisterType((*PutRequest)(nil), "remote.PutRequest")
 	proto.RegisterType((*PutReply)(nil), "remote.PutReply")
+	proto.RegisterType((*DeleteRequest)(nil), "remote.DeleteRequest")
+	proto.RegisterType((*DeleteReply)(nil), "remote.DeleteReply")
 	proto.RegisterType((*BeginRequest)(nil), "remote.BeginRequest")
 	proto.RegisterType((*BeginReply)(nil), "remote.BeginReply")
 	proto.RegisterType((*CommitRequest)(nil), "remote.CommitRequest")
@@ -1693,6 +3052,9 @@ func init() {
 	proto.RegisterType((*LookupNextRequest)(nil), "remote.LookupNextRequest")
 	proto.RegisterType((*KeyValue)(nil), "remote.KeyValue")
 	proto.RegisterType((*LookupNextReply)(nil), "remote.LookupNextReply")
+	proto.RegisterType((*ClusterCommand)(nil), "remote.ClusterCommand")
+	proto.RegisterType((*ClusterStatusRequest)(nil), "remote.ClusterStatusRequest")
+	proto.RegisterType((*ClusterStatusReply)(nil), "remote.ClusterStatusReply")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1709,6 +3071,15 @@ const _ = grpc.SupportPackageIsVersion4
 type KeydbClient interface {
 	Connection(ctx context.Context, opts ...grpc.CallOption) (Keydb_ConnectionClient, error)
 	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveReply, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListReply, error)
+	ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesReply, error)
+	SlowOps(ctx context.Context, in *SlowOpsRequest, opts ...grpc.CallOption) (*SlowOpsReply, error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsReply, error)
+	KillSession(ctx context.Context, in *KillSessionRequest, opts ...grpc.CallOption) (*KillSessionReply, error)
+	Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (Keydb_ReplicateClient, error)
+	ReplicationStatus(ctx context.Context, in *ReplicationStatusRequest, opts ...grpc.CallOption) (*ReplicationStatusReply, error)
+	Promote(ctx context.Context, in *PromoteRequest, opts ...grpc.CallOption) (*PromoteReply, error)
+	ClusterStatus(ctx context.Context, in *ClusterStatusRequest, opts ...grpc.CallOption) (*ClusterStatusReply, error)
 }
 
 type keydbClient struct {
@@ -1759,10 +3130,123 @@ func (c *keydbClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grp
 	return out, nil
 }
 
+func (c *keydbClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListReply, error) {
+	out := new(ListReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesReply, error) {
+	out := new(ListTablesReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/ListTables", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) SlowOps(ctx context.Context, in *SlowOpsRequest, opts ...grpc.CallOption) (*SlowOpsReply, error) {
+	out := new(SlowOpsReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/SlowOps", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsReply, error) {
+	out := new(ListSessionsReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) KillSession(ctx context.Context, in *KillSessionRequest, opts ...grpc.CallOption) (*KillSessionReply, error) {
+	out := new(KillSessionReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/KillSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (Keydb_ReplicateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Keydb_serviceDesc.Streams[1], "/remote.Keydb/Replicate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keydbReplicateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Keydb_ReplicateClient interface {
+	Recv() (*ReplicationEvent, error)
+	grpc.ClientStream
+}
+
+type keydbReplicateClient struct {
+	grpc.ClientStream
+}
+
+func (x *keydbReplicateClient) Recv() (*ReplicationEvent, error) {
+	m := new(ReplicationEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keydbClient) ReplicationStatus(ctx context.Context, in *ReplicationStatusRequest, opts ...grpc.CallOption) (*ReplicationStatusReply, error) {
+	out := new(ReplicationStatusReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/ReplicationStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) Promote(ctx context.Context, in *PromoteRequest, opts ...grpc.CallOption) (*PromoteReply, error) {
+	out := new(PromoteReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/Promote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keydbClient) ClusterStatus(ctx context.Context, in *ClusterStatusRequest, opts ...grpc.CallOption) (*ClusterStatusReply, error) {
+	out := new(ClusterStatusReply)
+	err := c.cc.Invoke(ctx, "/remote.Keydb/ClusterStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeydbServer is the server API for Keydb service.
 type KeydbServer interface {
 	Connection(Keydb_ConnectionServer) error
 	Remove(context.Context, *RemoveRequest) (*RemoveReply, error)
+	List(context.Context, *ListRequest) (*ListReply, error)
+	ListTables(context.Context, *ListTablesRequest) (*ListTablesReply, error)
+	SlowOps(context.Context, *SlowOpsRequest) (*SlowOpsReply, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsReply, error)
+	KillSession(context.Context, *KillSessionRequest) (*KillSessionReply, error)
+	Replicate(*ReplicateRequest, Keydb_ReplicateServer) error
+	ReplicationStatus(context.Context, *ReplicationStatusRequest) (*ReplicationStatusReply, error)
+	Promote(context.Context, *PromoteRequest) (*PromoteReply, error)
+	ClusterStatus(context.Context, *ClusterStatusRequest) (*ClusterStatusReply, error)
 }
 
 func RegisterKeydbServer(s *grpc.Server, srv KeydbServer) {
@@ -1813,6 +3297,171 @@ func _Keydb_Remove_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Keydb_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTablesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).ListTables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/ListTables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).ListTables(ctx, req.(*ListTablesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_SlowOps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SlowOpsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).SlowOps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/SlowOps",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).SlowOps(ctx, req.(*SlowOpsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_KillSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KillSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).KillSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/KillSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).KillSession(ctx, req.(*KillSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplicateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeydbServer).Replicate(m, &keydbReplicateServer{stream})
+}
+
+type Keydb_ReplicateServer interface {
+	Send(*ReplicationEvent) error
+	grpc.ServerStream
+}
+
+type keydbReplicateServer struct {
+	grpc.ServerStream
+}
+
+func (x *keydbReplicateServer) Send(m *ReplicationEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Keydb_ReplicationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).ReplicationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/ReplicationStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).ReplicationStatus(ctx, req.(*ReplicationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_Promote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).Promote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/Promote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).Promote(ctx, req.(*PromoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keydb_ClusterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeydbServer).ClusterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/remote.Keydb/ClusterStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeydbServer).ClusterStatus(ctx, req.(*ClusterStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Keydb_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "remote.Keydb",
 	HandlerType: (*KeydbServer)(nil),
@@ -1821,6 +3470,38 @@ var _Keydb_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Remove",
 			Handler:    _Keydb_Remove_Handler,
 		},
+		{
+			MethodName: "List",
+			Handler:    _Keydb_List_Handler,
+		},
+		{
+			MethodName: "ListTables",
+			Handler:    _Keydb_ListTables_Handler,
+		},
+		{
+			MethodName: "SlowOps",
+			Handler:    _Keydb_SlowOps_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _Keydb_ListSessions_Handler,
+		},
+		{
+			MethodName: "KillSession",
+			Handler:    _Keydb_KillSession_Handler,
+		},
+		{
+			MethodName: "ReplicationStatus",
+			Handler:    _Keydb_ReplicationStatus_Handler,
+		},
+		{
+			MethodName: "Promote",
+			Handler:    _Keydb_Promote_Handler,
+		},
+		{
+			MethodName: "ClusterStatus",
+			Handler:    _Keydb_ClusterStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1829,60 +3510,120 @@ var _Keydb_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _Keydb_Replicate_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "keydbr.proto",
 }
 
-func init() { proto.RegisterFile("keydbr.proto", fileDescriptor_keydbr_d8381e54dab9943c) }
-
-var fileDescriptor_keydbr_d8381e54dab9943c = []byte{
-	// 762 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x96, 0x5f, 0x4f, 0x13, 0x41,
-	0x10, 0xc0, 0xb9, 0xfe, 0xef, 0xb4, 0x85, 0xb2, 0x05, 0x39, 0x79, 0xaa, 0x0b, 0x08, 0x12, 0x29,
-	0xa6, 0x44, 0x8c, 0x0f, 0xbe, 0x14, 0x13, 0x35, 0xa8, 0x90, 0x33, 0xf1, 0xc9, 0x97, 0xb6, 0x4c,
-	0xb0, 0xe9, 0xf5, 0xf6, 0xdc, 0xee, 0x21, 0xf5, 0xf3, 0xf8, 0x1d, 0xfc, 0x7a, 0x66, 0x77, 0xef,
-	0xcf, 0xee, 0xd1, 0x46, 0xdf, 0x6e, 0x27, 0xbf, 0xdd, 0x99, 0xeb, 0x6f, 0x67, 0x7a, 0xd0, 0x9c,
-	0xe2, 0xe2, 0x66, 0xc4, 0x7b, 0x21, 0x67, 0x82, 0x91, 0x0a, 0xc7, 0x19, 0x13, 0x48, 0xff, 0x14,
-	0xa1, 0xfe, 0x21, 0xf8, 0x84, 0xf3, 0xf9, 0xf0, 0x16, 0xc9, 0x33, 0x28, 0xb1, 0x10, 0x03, 0xd7,
-	0xe9, 0x3a, 0x47, 0x8d, 0x7e, 0xa7, 0xa7, 0xa1, 0xde, 0x55, 0x88, 0x81, 0x87, 0x3f, 0x22, 0x9c,
-	0x8b, 0xf7, 0x6b, 0x9e, 0x42, 0xc8, 0x73, 0x28, 0x8f, 0x7d, 0x36, 0x47, 0xb7, 0xa8, 0xd8, 0xad,
-	0x84, 0xbd, 0x90, 0xc1, 0x0c, 0xd6, 0x10, 0x79, 0x0a, 0xc5, 0x5b, 0x14, 0x6e, 0x49, 0xb1, 0x24,
-	0x61, 0xdf, 0xa1, 0xc8, 0x48, 0x09, 0x48, 0x2e, 0x8c, 0x84, 0x5b, 0xb6, 0xb9, 0xeb, 0xc8, 0xe4,
-	0xc2, 0x48, 0xc8, 0xec, 0x23, 0xbc, 0x9d, 0x04, 0x6e, 0xc5, 0xce, 0x3e, 0x90, 0x41, 0x23, 0xbb,
-	0x82, 0xc8, 0x29, 0x54, 0xc6, 0x6c, 0x36, 0x9b, 0x08, 0xb7, 0xaa, 0xf0, 0xed, 0xb4, 0x58, 0x15,
-	0xcd, 0xf8, 0x18, 0x23, 0x2f, 0xa1, 0xc6, 0x99, 0xef, 0x8f, 0x86, 0xe3, 0xa9, 0x5b, 0x53, 0x5b,
-	0x76, 0x92, 0x2d, 0x5e, 0x1c, 0xcf, 0x36, 0xa5, 0xa8, 0xcc, 0xe3, 0x33, 0x36, 0x8d, 0x42, 0xb7,
-	0x6e, 0xe7, 0xf9, 0xa8, 0xa2, 0x46, 0x1e, 0x8d, 0x91, 0x53, 0x28, 0x05, 0x78, 0x2f, 0x5c, 0x50,
-	0xf8, 0x63, 0x1b, 0xff, 0x8c, 0xf7, 0x46, 0x69, 0x0a, 0x1c, 0xd4, 0xa1, 0xca, 0x75, 0x88, 0xfe,
-	0x2e, 0x02, 0x5c, 0x45, 0x22, 0x51, 0x77, 0x68, 0xa9, 0xdb, 0xb4, 0xd5, 0x85, 0xfe, 0x22, 0x15,
-	0x77, 0x6c, 0x8b, 0x23, 0x39, 0x71, 0x1a, 0x8d, 0xb5, 0xed, 0x9b, 0xda, 0xda, 0x96, 0x36, 0xcd,
-	0x29, 0x69, 0xfb, 0xa6, 0xb4, 0xb6, 0x25, 0x2d, 0xa6, 0xa4, 0xb2, 0x63, 0x5b, 0x19, 0xc9, 0x29,
-	0x8b, 0xf3, 0x6a, 0x61, 0x27, 0x39, 0x61, 0x9d, 0xbc, 0x30, 0x4d, 0x27, 0xba, 0xce, 0x1e, 0xe8,
-	0xda, 0x7e, 0xa8, 0x4b, 0x6f, 0xc9, 0x64, 0x9d, 0xe4, 0x64, 0x75, 0xf2, 0xb2, 0xe2, 0x1c, 0xb1,
-	0xaa, 0x13, 0x4b, 0xd5, 0xce, 0x32, 0x55, 0xf1, 0xaf, 0xac, 0x44, 0x55, 0xa1, 0xcc, 0x65, 0x80,
-	0xbe, 0x81, 0x86, 0xd1, 0x3e, 0xe4, 0x11, 0x54, 0x6e, 0x46, 0xc1, 0x70, 0x86, 0x4a, 0x54, 0xdd,
-	0x8b, 0x57, 0x32, 0x3e, 0xe6, 0x38, 0x14, 0xe8, 0x16, 0xba, 0xce, 0x51, 0xcd, 0x8b, 0x57, 0xf4,
-	0x09, 0xd4, 0x53, 0x85, 0x64, 0x0b, 0xca, 0xc8, 0x39, 0xe3, 0x8a, 0xa9, 0x7b, 0x7a, 0x41, 0x0f,
-	0xa1, 0xe5, 0xe1, 0x8c, 0xdd, 0xe1, 0x3f, 0x72, 0xd0, 0x3d, 0x68, 0x24, 0xa0, 0x75, 0x9a, 0x63,
-	0x9e, 0xb6, 0x0e, 0x4d, 0xb3, 0x85, 0x29, 0x05, 0xc8, 0x6e, 0xc6, 0x8a, 0x3d, 0x7d, 0x80, 0xac,
-	0x95, 0x09, 0x81, 0x92, 0xb8, 0x9f, 0xdc, 0x28, 0xa4, 0xe4, 0xa9, 0x67, 0xd2, 0x86, 0xe2, 0x14,
-	0x17, 0xaa, 0xee, 0xa6, 0x27, 0x1f, 0xe9, 0x39, 0xd4, 0x92, 0x7b, 0x24, 0x4f, 0xbd, 0x1b, 0xfa,
-	0x91, 0xae, 0xb7, 0xe9, 0xe9, 0xc5, 0x8a, 0xb7, 0xfd, 0x06, 0x90, 0x8d, 0x83, 0xff, 0xcb, 0x95,
-	0x9d, 0x5f, 0x34, 0xcf, 0x27, 0x50, 0x9a, 0x2f, 0x82, 0xb1, 0xba, 0xdd, 0x35, 0x4f, 0x3d, 0xd3,
-	0x2e, 0xd4, 0x92, 0x7b, 0xbb, 0xe2, 0x5d, 0xf7, 0xa1, 0x69, 0x0e, 0x19, 0x49, 0x89, 0xe1, 0xc8,
-	0xc7, 0xa4, 0x4a, 0xb5, 0xa0, 0xe7, 0x00, 0xd9, 0xbd, 0x5e, 0x5a, 0xe5, 0xf2, 0xb7, 0x7b, 0x05,
-	0x2d, 0x6b, 0x26, 0x2d, 0xdd, 0x9a, 0x14, 0x5e, 0x30, 0x0a, 0xdf, 0x83, 0x86, 0xd1, 0x1b, 0x2b,
-	0x6a, 0x3f, 0x80, 0x8d, 0xdc, 0xf8, 0x5a, 0x76, 0x3e, 0x3d, 0x80, 0x96, 0xd5, 0x36, 0x2b, 0x4e,
-	0xbb, 0x82, 0x96, 0x35, 0xd7, 0x56, 0xbd, 0xa6, 0xcf, 0x7e, 0x22, 0x8f, 0x75, 0xe8, 0x85, 0x8c,
-	0x46, 0x61, 0x88, 0x3c, 0x11, 0xa2, 0x16, 0xf4, 0x0c, 0x1a, 0x46, 0xef, 0x91, 0x75, 0x28, 0xa4,
-	0x87, 0x15, 0x56, 0xfe, 0x62, 0x7b, 0xb0, 0xf9, 0x60, 0x5c, 0xe6, 0xb7, 0xd2, 0x3e, 0xd4, 0x2e,
-	0x71, 0xf1, 0x55, 0x69, 0x8f, 0xaf, 0x87, 0xb3, 0xe4, 0x7a, 0x14, 0x8c, 0xeb, 0x41, 0xbf, 0xc0,
-	0x46, 0xae, 0xb9, 0xc9, 0x31, 0x54, 0x31, 0x10, 0x7c, 0x82, 0x73, 0xd7, 0xe9, 0x16, 0xcd, 0x61,
-	0x97, 0x9c, 0xee, 0x25, 0xc0, 0xf2, 0x6a, 0xfb, 0xbf, 0xa0, 0x7c, 0x29, 0xff, 0x86, 0xc9, 0x6b,
-	0x80, 0x0b, 0x16, 0x04, 0x38, 0x16, 0x13, 0x16, 0x90, 0x74, 0x5c, 0xa7, 0x7f, 0xc5, 0xbb, 0xe9,
-	0x7c, 0xcc, 0x66, 0x3c, 0x5d, 0x3b, 0x72, 0x5e, 0x38, 0xe4, 0x1c, 0x2a, 0xba, 0x8d, 0x49, 0x36,
-	0xe5, 0xcc, 0xfe, 0xdf, 0xed, 0xe4, 0xc3, 0x72, 0x0e, 0xad, 0x0d, 0x0e, 0x61, 0x73, 0xcc, 0x66,
-	0x3d, 0xce, 0x46, 0xc3, 0xef, 0xac, 0xa7, 0xbf, 0x06, 0x06, 0xed, 0x4b, 0x5c, 0xbc, 0x1d, 0x78,
-	0x8a, 0xbf, 0x96, 0x5f, 0x06, 0xd7, 0xce, 0xa8, 0xa2, 0x3e, 0x11, 0xce, 0xfe, 0x06, 0x00, 0x00,
-	0xff, 0xff, 0x1b, 0xa3, 0xb9, 0x4b, 0x32, 0x08, 0x00, 0x00,
+func init() { proto.RegisterFile("keydbr.proto", fileDescriptor_keydbr_54a2c1a5f0b6402a) }
+
+var fileDescriptor_keydbr_54a2c1a5f0b6402a = []byte{
+	// 1634 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x4f, 0x6f, 0xdb, 0xc6,
+	0x12, 0x37, 0x45, 0xfd, 0xe3, 0x48, 0xb6, 0xe5, 0xb5, 0xe3, 0xd0, 0x4a, 0x5e, 0xe0, 0xc7, 0x38,
+	0x89, 0x5f, 0xf2, 0xec, 0x04, 0x0e, 0x92, 0x07, 0xe3, 0xa1, 0x28, 0x60, 0x27, 0x6d, 0x5a, 0x27,
+	0xb5, 0xb1, 0x2e, 0xd2, 0x4b, 0x80, 0x82, 0x92, 0xb6, 0x0e, 0x61, 0x8a, 0x64, 0xc9, 0x95, 0x63,
+	0xe5, 0xda, 0x5b, 0xaf, 0xbd, 0xf7, 0x1b, 0xf5, 0xd2, 0x7e, 0x83, 0x9e, 0xfa, 0x31, 0x8a, 0xfd,
+	0x43, 0xee, 0x2e, 0x25, 0xc6, 0x09, 0xd0, 0x1b, 0x67, 0xf8, 0xdb, 0x99, 0xd9, 0xf9, 0xcd, 0x2c,
+	0x67, 0x09, 0xdd, 0x73, 0x32, 0x1d, 0x0d, 0xd2, 0xdd, 0x24, 0x8d, 0x69, 0x8c, 0x9a, 0x29, 0x19,
+	0xc7, 0x94, 0x78, 0x7f, 0xd9, 0xe0, 0x7c, 0x15, 0xbd, 0x22, 0x59, 0xe6, 0x9f, 0x11, 0xf4, 0x1f,
+	0xa8, 0xc7, 0x09, 0x89, 0x5c, 0x6b, 0xd3, 0xda, 0xee, 0xec, 0xad, 0xee, 0x0a, 0xd0, 0xee, 0x71,
+	0x42, 0x22, 0x4c, 0x7e, 0x9c, 0x90, 0x8c, 0xbe, 0x58, 0xc0, 0x1c, 0x82, 0xfe, 0x0b, 0x8d, 0x61,
+	0x18, 0x67, 0xc4, 0xb5, 0x39, 0x76, 0x2d, 0xc7, 0x1e, 0x32, 0xa5, 0x02, 0x0b, 0x10, 0xba, 0x0b,
+	0xf6, 0x19, 0xa1, 0x6e, 0x9d, 0x63, 0x51, 0x8e, 0xfd, 0x92, 0x50, 0x85, 0x64, 0x00, 0x86, 0x4b,
+	0x26, 0xd4, 0x6d, 0x98, 0xb8, 0x93, 0x89, 0x8e, 0x4b, 0x26, 0x94, 0x79, 0x1f, 0x90, 0xb3, 0x20,
+	0x72, 0x9b, 0xa6, 0xf7, 0x03, 0xa6, 0xd4, 0xbc, 0x73, 0x10, 0x7a, 0x08, 0xcd, 0x61, 0x3c, 0x1e,
+	0x07, 0xd4, 0x6d, 0x71, 0xf8, 0xb5, 0x22, 0x58, 0xae, 0x55, 0x78, 0x09, 0x43, 0x4f, 0xa0, 0x9d,
+	0xc6, 0x61, 0x38, 0xf0, 0x87, 0xe7, 0x6e, 0x9b, 0x2f, 0xb9, 0x9e, 0x2f, 0xc1, 0x52, 0xaf, 0x16,
+	0x15, 0x50, 0xe6, 0x27, 0x8c, 0xe3, 0xf3, 0x49, 0xe2, 0x3a, 0xa6, 0x9f, 0x97, 0x5c, 0xab, 0xf9,
+	0x11, 0x30, 0xf4, 0x10, 0xea, 0x11, 0xb9, 0xa4, 0x2e, 0x70, 0xf8, 0x86, 0x09, 0xff, 0x86, 0x5c,
+	0x6a, 0xa1, 0x71, 0x20, 0xf3, 0x30, 0x22, 0x21, 0xa1, 0xc4, 0xed, 0x98, 0x1e, 0x9e, 0x71, 0xad,
+	0xe6, 0x41, 0xc0, 0x0e, 0x1c, 0x68, 0xa5, 0x42, 0xe9, 0xfd, 0x61, 0x03, 0x1c, 0x4f, 0x68, 0xce,
+	0xf5, 0x3d, 0x83, 0xeb, 0x15, 0x93, 0xeb, 0x24, 0x9c, 0x16, 0x4c, 0xdf, 0x37, 0x99, 0x46, 0x25,
+	0xa6, 0x05, 0x54, 0xf2, 0xbc, 0xa5, 0xf3, 0xdc, 0x33, 0x78, 0x16, 0x38, 0xce, 0xf2, 0x96, 0xce,
+	0x72, 0xcf, 0x60, 0x59, 0xa2, 0x18, 0xc7, 0xf7, 0x4d, 0x8e, 0x51, 0x89, 0x63, 0xe9, 0x57, 0x30,
+	0xbc, 0x53, 0x62, 0x78, 0xb5, 0xcc, 0xb0, 0x40, 0xe7, 0xfc, 0x3e, 0x9e, 0xe1, 0xf7, 0xda, 0x2c,
+	0xbf, 0x62, 0x89, 0x62, 0x77, 0xa7, 0xc4, 0xee, 0x6a, 0x99, 0x5d, 0xe9, 0x43, 0x72, 0xbb, 0x63,
+	0x70, 0x7b, 0x7d, 0x1e, 0xb7, 0x32, 0xcb, 0x9c, 0xd9, 0x9d, 0x12, 0xb3, 0xab, 0x65, 0x66, 0xa5,
+	0x75, 0xc9, 0x6b, 0x0b, 0x1a, 0x29, 0x53, 0x79, 0x9f, 0x41, 0x47, 0x6b, 0x4f, 0xb4, 0x0e, 0xcd,
+	0xd1, 0x20, 0xf2, 0xc7, 0x84, 0xf3, 0xea, 0x60, 0x29, 0x31, 0xfd, 0x30, 0x25, 0x3e, 0x25, 0x6e,
+	0x6d, 0xd3, 0xda, 0x6e, 0x63, 0x29, 0x79, 0xff, 0x06, 0xa7, 0x60, 0x1c, 0xad, 0x41, 0x83, 0xa4,
+	0x69, 0x9c, 0x72, 0x8c, 0x83, 0x85, 0xe0, 0xdd, 0x83, 0x45, 0x4c, 0xc6, 0xf1, 0x05, 0xb9, 0xc2,
+	0x87, 0x77, 0x1b, 0x3a, 0x39, 0xd0, 0xb0, 0x66, 0xe9, 0xd6, 0x16, 0xa1, 0xf3, 0x32, 0xc8, 0xf2,
+	0xc2, 0xf6, 0xfe, 0x0f, 0x8e, 0x10, 0xd9, 0x0a, 0x17, 0x5a, 0xc2, 0x54, 0xe6, 0x5a, 0x9b, 0xf6,
+	0xb6, 0x83, 0x73, 0xb1, 0x22, 0xb2, 0x07, 0xb0, 0xc2, 0x16, 0x7f, 0xeb, 0x0f, 0x42, 0x92, 0x5d,
+	0x15, 0xdd, 0xe7, 0xb0, 0xac, 0x83, 0x99, 0xbf, 0x75, 0x68, 0x52, 0x2e, 0x4a, 0x77, 0x52, 0xaa,
+	0xf0, 0x76, 0x17, 0x96, 0x4e, 0xc3, 0xf8, 0xdd, 0x71, 0x52, 0xb8, 0x5a, 0x83, 0x46, 0x18, 0xb0,
+	0xa2, 0x63, 0x9e, 0x1a, 0x58, 0x08, 0xde, 0xcf, 0x35, 0x68, 0x0a, 0x20, 0x42, 0x50, 0xa7, 0x81,
+	0x8c, 0xc4, 0xc6, 0xfc, 0x19, 0xf5, 0xa1, 0x3d, 0x9a, 0xa4, 0x3e, 0x0d, 0xe2, 0x88, 0xdb, 0xb7,
+	0x71, 0x21, 0x73, 0xfc, 0x34, 0x11, 0x9d, 0xe6, 0x60, 0xfe, 0xac, 0xed, 0xa7, 0x6e, 0x30, 0xba,
+	0x06, 0x0d, 0x1e, 0x2e, 0x6f, 0x23, 0x07, 0x0b, 0x01, 0xfd, 0x0b, 0xe0, 0x9c, 0x4c, 0xbf, 0x4f,
+	0x52, 0xf2, 0x43, 0x70, 0xc9, 0x3b, 0xa7, 0x8b, 0x9d, 0x73, 0x32, 0x3d, 0xe1, 0x0a, 0xb4, 0x01,
+	0x6d, 0xf6, 0x3a, 0x0b, 0xde, 0x13, 0xde, 0x29, 0x0d, 0xdc, 0x3a, 0x27, 0xd3, 0xd3, 0xe0, 0x3d,
+	0x61, 0xc9, 0x27, 0x11, 0x4d, 0x03, 0x92, 0xf1, 0x96, 0x68, 0xe0, 0x5c, 0x44, 0xb7, 0x00, 0x86,
+	0x71, 0x14, 0x91, 0x21, 0x8f, 0x99, 0x15, 0x7f, 0x1d, 0x6b, 0x1a, 0xb6, 0xa3, 0x60, 0x44, 0x22,
+	0x1a, 0xd0, 0x29, 0xaf, 0x76, 0x07, 0x17, 0xb2, 0xf7, 0x05, 0x74, 0x8b, 0xa4, 0xb1, 0x94, 0x6f,
+	0x82, 0x1d, 0x27, 0x22, 0xdf, 0x9d, 0xbd, 0xa5, 0xbc, 0xc6, 0x05, 0x04, 0xb3, 0x57, 0x15, 0xc9,
+	0xbf, 0x06, 0xab, 0x8c, 0xbd, 0x53, 0x92, 0x65, 0x41, 0x1c, 0xe5, 0x0c, 0x78, 0xbf, 0x59, 0xd0,
+	0x92, 0x3a, 0xb4, 0x04, 0xb5, 0x60, 0xc4, 0x53, 0x5d, 0xc7, 0xb5, 0x60, 0xc4, 0x92, 0x99, 0x10,
+	0x92, 0xdb, 0xe1, 0xcf, 0x46, 0xa8, 0xb6, 0x19, 0x6a, 0x65, 0xa2, 0x3d, 0xe8, 0xd2, 0xd4, 0x8f,
+	0x32, 0x9f, 0xef, 0x36, 0xe3, 0xf9, 0x6e, 0x60, 0x43, 0x87, 0x6e, 0x82, 0x13, 0x50, 0x92, 0xfa,
+	0x34, 0x4e, 0x33, 0x9e, 0xf5, 0x06, 0x56, 0x0a, 0x66, 0x99, 0x9d, 0xa4, 0x64, 0xc4, 0x73, 0x6e,
+	0x63, 0x29, 0xa1, 0x1e, 0xd8, 0xfe, 0x19, 0xe1, 0xe9, 0xb6, 0x31, 0x7b, 0xf4, 0x5e, 0x8b, 0x8a,
+	0x56, 0xdb, 0x64, 0x39, 0x7b, 0x00, 0xed, 0x4c, 0x2a, 0x64, 0xe2, 0x96, 0x8b, 0xc4, 0x09, 0x3d,
+	0x2e, 0x00, 0x15, 0xe9, 0xdb, 0x02, 0x74, 0x14, 0x84, 0x61, 0x0e, 0x97, 0xf5, 0x5b, 0xca, 0x98,
+	0xb7, 0x0d, 0x3d, 0x03, 0x55, 0xdd, 0xc5, 0x6f, 0xa0, 0xc7, 0x5e, 0x07, 0x43, 0x9f, 0x5e, 0x75,
+	0x2c, 0x70, 0x0b, 0x49, 0x3c, 0x7c, 0x5b, 0x44, 0xc4, 0x04, 0xc6, 0x44, 0x12, 0x67, 0x01, 0x2f,
+	0x29, 0x9b, 0x47, 0x50, 0xc8, 0xde, 0xd7, 0xd0, 0x7e, 0x35, 0xa1, 0xa2, 0x25, 0x7a, 0x60, 0x9f,
+	0x93, 0x29, 0x37, 0xd9, 0xc5, 0xec, 0x91, 0xd9, 0xbb, 0xf0, 0xc3, 0x89, 0x38, 0xc9, 0xba, 0x58,
+	0x08, 0xdc, 0xbb, 0x38, 0x3f, 0x6d, 0x71, 0xc0, 0x09, 0xc9, 0xfb, 0xdd, 0x52, 0xa1, 0x06, 0x71,
+	0xf4, 0xfc, 0x82, 0x44, 0x54, 0x85, 0x64, 0x55, 0x85, 0x54, 0x33, 0x43, 0x52, 0xdd, 0x66, 0xeb,
+	0xdd, 0xb6, 0x0b, 0xce, 0x58, 0x06, 0x9a, 0xb9, 0xf5, 0x4d, 0x5b, 0xff, 0x9c, 0xe5, 0x3b, 0xc0,
+	0x0a, 0xc2, 0xac, 0x0c, 0x43, 0xe2, 0xa7, 0xbc, 0x86, 0xda, 0x58, 0x08, 0xcc, 0x6f, 0x16, 0xf9,
+	0x49, 0xf6, 0x36, 0xa6, 0xbc, 0x76, 0xda, 0xb8, 0x90, 0x55, 0xfa, 0x5b, 0x7a, 0xfa, 0xfb, 0xe0,
+	0x6a, 0x7b, 0x3a, 0xa5, 0x3e, 0x9d, 0x14, 0x2d, 0xf1, 0x8b, 0x05, 0xab, 0xda, 0xcb, 0x93, 0x7c,
+	0x07, 0xff, 0x18, 0x3d, 0xac, 0xd8, 0x65, 0xf7, 0x93, 0x11, 0xef, 0x95, 0x36, 0x56, 0x0a, 0x15,
+	0x71, 0x43, 0x8f, 0xf8, 0x27, 0x0b, 0xd6, 0xe7, 0x84, 0x2c, 0x4f, 0xfd, 0x24, 0x0d, 0xc6, 0x7e,
+	0x3a, 0x95, 0x91, 0xe5, 0x22, 0xda, 0x07, 0x67, 0xe4, 0x53, 0x7f, 0xe0, 0x67, 0x24, 0x73, 0x6b,
+	0x3c, 0xbd, 0x37, 0x8a, 0xef, 0xf4, 0xec, 0x16, 0xb1, 0x42, 0xab, 0x28, 0x6c, 0x3d, 0x8a, 0x1e,
+	0x2c, 0x9d, 0xa4, 0x31, 0x5b, 0x9f, 0x67, 0x6b, 0x0b, 0xba, 0x85, 0xa6, 0xba, 0xdc, 0x97, 0xa0,
+	0xab, 0xcf, 0xb5, 0x9e, 0x07, 0xa0, 0xa6, 0x9f, 0x8a, 0x35, 0x7b, 0x00, 0x6a, 0xbe, 0xe5, 0x27,
+	0xfb, 0x65, 0xd1, 0x6c, 0xfc, 0x39, 0x2f, 0xed, 0x5a, 0x51, 0xda, 0xde, 0x53, 0x68, 0xe7, 0xb3,
+	0x92, 0x2a, 0x73, 0x4b, 0x2f, 0xf3, 0xf9, 0xed, 0xfd, 0x06, 0xe0, 0x64, 0xf2, 0x69, 0xbe, 0x94,
+	0x7d, 0x5b, 0xb7, 0x8f, 0xa0, 0x9e, 0x4d, 0xa3, 0xa1, 0xa4, 0x95, 0x3f, 0x7b, 0x9b, 0xd0, 0xce,
+	0x67, 0xb3, 0x8a, 0xbd, 0x3e, 0x81, 0x45, 0x63, 0x00, 0xfd, 0xc8, 0xed, 0xee, 0x43, 0x47, 0x9b,
+	0x6e, 0x3e, 0x69, 0xc7, 0x5b, 0xd0, 0xd5, 0x67, 0x7d, 0xd5, 0x9f, 0x35, 0xad, 0x3f, 0xbd, 0xa7,
+	0x00, 0x6a, 0x5a, 0x9c, 0x1b, 0xd4, 0x7c, 0xeb, 0xff, 0x83, 0x45, 0xe3, 0x6a, 0x30, 0x77, 0x69,
+	0x9e, 0xaa, 0x9a, 0x96, 0xaa, 0xdb, 0xd0, 0xd1, 0x26, 0xce, 0x8a, 0x6c, 0xdd, 0x81, 0xe5, 0xd2,
+	0x2d, 0x62, 0x9e, 0x7d, 0xef, 0x0e, 0x2c, 0x1a, 0xc3, 0x68, 0x85, 0xb5, 0x63, 0x58, 0x34, 0xae,
+	0x17, 0x55, 0xdb, 0x0c, 0xe3, 0x77, 0xf2, 0x63, 0xd8, 0xc5, 0x42, 0x60, 0xda, 0x49, 0x92, 0x90,
+	0x34, 0x2f, 0x01, 0x2e, 0x78, 0x8f, 0xa1, 0xa3, 0x4d, 0xb4, 0x33, 0x9f, 0xd5, 0xf9, 0x19, 0xbb,
+	0x0d, 0x2b, 0x33, 0xb7, 0x96, 0x99, 0xef, 0xcb, 0x1e, 0xb4, 0x8f, 0xc8, 0xf4, 0x35, 0xa7, 0xf5,
+	0x23, 0xcf, 0x75, 0xef, 0x14, 0x96, 0x4b, 0x23, 0x33, 0xba, 0xaf, 0x26, 0x15, 0xcb, 0x3c, 0x73,
+	0x73, 0xeb, 0x6a, 0x76, 0x99, 0x1f, 0xed, 0xaf, 0x16, 0x2c, 0x1d, 0x86, 0x93, 0x8c, 0x92, 0x94,
+	0xd1, 0xe5, 0x47, 0xa3, 0x0f, 0x1d, 0x8f, 0xb3, 0x85, 0x65, 0x1e, 0xfc, 0xf6, 0xd5, 0x07, 0xbf,
+	0x1a, 0xbf, 0xeb, 0xfa, 0xf8, 0xcd, 0xf4, 0x29, 0x1f, 0x99, 0xe5, 0x17, 0x41, 0x4a, 0xde, 0x3a,
+	0xac, 0xc9, 0xf8, 0xcc, 0xc3, 0xfd, 0x02, 0x50, 0x49, 0x2f, 0xe7, 0xd8, 0x90, 0xf8, 0x23, 0x92,
+	0x57, 0x86, 0x94, 0xd8, 0xc9, 0x3a, 0x26, 0xe3, 0x01, 0x49, 0xc5, 0xe9, 0xe9, 0xe0, 0x5c, 0x9c,
+	0x7f, 0x3c, 0x32, 0xbc, 0x9f, 0x24, 0x61, 0x20, 0x8f, 0xf5, 0x3a, 0xce, 0xc5, 0xbd, 0x3f, 0x1b,
+	0xd0, 0x38, 0x62, 0xff, 0x0f, 0xd0, 0x3e, 0xc0, 0xa1, 0x1a, 0xfd, 0x8a, 0x6b, 0x63, 0xf1, 0x0f,
+	0xa1, 0x5f, 0xdc, 0xd3, 0xd4, 0x5d, 0xd3, 0x5b, 0xd8, 0xb6, 0x1e, 0x59, 0xe8, 0x29, 0x34, 0xc5,
+	0xfd, 0x00, 0xa9, 0xdb, 0x96, 0x7e, 0xb1, 0xe8, 0xaf, 0x96, 0xd5, 0xec, 0x82, 0xb3, 0x80, 0x1e,
+	0x41, 0x9d, 0x0d, 0x45, 0x48, 0x5d, 0xb8, 0xd4, 0x05, 0xa2, 0xbf, 0x62, 0x2a, 0xc5, 0x8a, 0x03,
+	0x00, 0x35, 0xeb, 0xa3, 0x0d, 0x1d, 0x62, 0x5c, 0x16, 0xfa, 0xd7, 0xe7, 0xbd, 0x12, 0x36, 0xf6,
+	0xa1, 0x25, 0x27, 0x57, 0xb4, 0x6e, 0xce, 0xa9, 0xc5, 0xea, 0xb5, 0x19, 0xbd, 0x58, 0xfa, 0x02,
+	0xba, 0xfa, 0x14, 0x87, 0x6e, 0xe8, 0x5e, 0x4a, 0x23, 0x6c, 0x7f, 0x63, 0xfe, 0x4b, 0x61, 0xe9,
+	0x39, 0x74, 0xb4, 0x89, 0x0c, 0xf5, 0x8b, 0x42, 0x9f, 0x19, 0xe6, 0xfa, 0xee, 0xdc, 0x77, 0xc2,
+	0xcc, 0x21, 0x38, 0xc5, 0xb8, 0x86, 0xdc, 0xf2, 0x27, 0x94, 0xcc, 0x98, 0x28, 0x0f, 0x4c, 0xde,
+	0xc2, 0x23, 0x0b, 0x7d, 0x07, 0x2b, 0x33, 0x5f, 0x70, 0xb4, 0x39, 0x67, 0x89, 0x51, 0xb2, 0xfd,
+	0x5b, 0x1f, 0x40, 0x14, 0x99, 0x96, 0xdf, 0x60, 0x95, 0x69, 0xf3, 0x33, 0xdd, 0x5f, 0x9b, 0xd1,
+	0x8b, 0xa5, 0x47, 0xb0, 0x68, 0xf4, 0x03, 0xba, 0xa9, 0xfe, 0x4e, 0xcc, 0xb6, 0x4f, 0xbf, 0x5f,
+	0xf1, 0x96, 0x1b, 0x3b, 0xb8, 0x07, 0x2b, 0xc3, 0x78, 0xbc, 0x9b, 0xc6, 0x03, 0xff, 0x6d, 0xbc,
+	0x2b, 0x7e, 0x97, 0x1d, 0xf4, 0x8e, 0xc8, 0xf4, 0xd9, 0x01, 0xe6, 0xcb, 0x4e, 0xd2, 0x98, 0xc6,
+	0x27, 0xd6, 0xa0, 0xc9, 0xff, 0xa1, 0x3d, 0xfe, 0x7b, 0x00, 0x9a, 0x64, 0x6d, 0xe9, 0x53, 0x13,
+	0x00, 0x00,
 }
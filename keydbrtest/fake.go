@@ -0,0 +1,440 @@
+package keydbrtest
+
+import (
+	"bytes"
+	"errors"
+	"github.com/robaho/keydbr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"sync"
+)
+
+// ConnectionDropped is returned by the requests on a database after its connection is dropped by Disconnect, in the
+// same form as the error returned by the client when the server goes away
+var ConnectionDropped = status.Error(codes.Unavailable, "connection dropped")
+
+// the errors returned by the server, which the client returns as plain errors
+var (
+	databaseClosed    = errors.New("database closed")
+	databaseInUse     = errors.New("database in use")
+	databaseOpenTxs   = errors.New("database has open transactions")
+	emptyKey          = errors.New("key is empty")
+	keyTooLong        = errors.New("key too long, max 1024")
+	invalidTxID       = errors.New("invalid tx id")
+	invalidIteratorID = errors.New("invalid iterator id")
+)
+
+const maxKeyLength = 1024
+
+// Fake is an in-memory stand-in for a server, for unit tests of applications using package client which need
+// neither a server nor a disk. Its databases behave as the client's: transactions see their own writes and the
+// data committed by other transactions (read committed), commits are applied atomically with the last commit
+// winning, lookups return the entries in key order as of the lookup, an empty value is not found, and errors are the
+// ones the client returns.
+//
+// FailNextCommit and Disconnect inject failures to test error handling. A Fake is safe for concurrent use.
+type Fake struct {
+	sync.Mutex
+	// databases holds the committed data by database, table and key
+	databases map[string]map[string]map[string][]byte
+	conns     map[*FakeDatabase]bool
+	failures  []error
+	nextid    uint64
+}
+
+// FakeDatabase is a database opened on a Fake, with the methods of client.RemoteDatabase
+type FakeDatabase struct {
+	f    *Fake
+	name string
+	txs  map[uint64]*fakeTransaction
+	// err fails all requests once the database is closed or its connection dropped
+	err error
+}
+
+type fakeTransaction struct {
+	db    *FakeDatabase
+	id    uint64
+	table string
+	// writes holds the uncommitted values by key, which are nil for a removed key
+	writes       map[string][]byte
+	asyncfailure error
+	itrs         []*fakeIterator
+}
+
+type fakeIterator struct {
+	tx      *fakeTransaction
+	keys    []string
+	values  [][]byte
+	index   int
+	invalid bool
+}
+
+var _ keydbr.Database = (*FakeDatabase)(nil)
+
+// NewFake returns a Fake with no databases
+func NewFake() *Fake {
+	return &Fake{databases: map[string]map[string]map[string][]byte{}, conns: map[*FakeDatabase]bool{}}
+}
+
+// Open opens a database as client.Open does
+func (f *Fake) Open(dbname string, createIfNeeded bool) (*FakeDatabase, error) {
+	if err := keydbr.ValidateDatabaseName(dbname); err != nil {
+		return nil, err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.databases[dbname]; !ok {
+		if !createIfNeeded {
+			return nil, keydbr.NoDatabaseFound
+		}
+		f.databases[dbname] = map[string]map[string][]byte{}
+	}
+	db := &FakeDatabase{f: f, name: dbname, txs: map[uint64]*fakeTransaction{}}
+	f.conns[db] = true
+	return db, nil
+}
+
+// Remove removes a database as client.Remove does, which fails if the database is open
+func (f *Fake) Remove(dbname string) error {
+	if err := keydbr.ValidateDatabaseName(dbname); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.databases[dbname]; !ok {
+		return keydbr.NoDatabaseFound
+	}
+	for db := range f.conns {
+		if db.name == dbname {
+			return databaseInUse
+		}
+	}
+	delete(f.databases, dbname)
+	return nil
+}
+
+// List returns the databases in name order, as client.List does
+func (f *Fake) List() ([]string, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	var dbnames []string
+	for dbname := range f.databases {
+		dbnames = append(dbnames, dbname)
+	}
+	sort.Strings(dbnames)
+	return dbnames, nil
+}
+
+// ListTables returns the tables of a database in name order, as client.ListTables does. A table is listed once a
+// transaction writing to it has been committed.
+func (f *Fake) ListTables(dbname string) ([]string, error) {
+	if err := keydbr.ValidateDatabaseName(dbname); err != nil {
+		return nil, err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	tables, ok := f.databases[dbname]
+	if !ok {
+		return nil, keydbr.NoDatabaseFound
+	}
+	var names []string
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FailNextCommit makes the next commit of any transaction fail with err. As when a commit fails on the server, the
+// transaction remains open and must be rolled back. Calling it again fails the commits after it in turn.
+func (f *Fake) FailNextCommit(err error) {
+	f.Lock()
+	defer f.Unlock()
+	f.failures = append(f.failures, err)
+}
+
+// Disconnect drops the connections of all open databases, see FakeDatabase.Disconnect
+func (f *Fake) Disconnect() {
+	f.Lock()
+	defer f.Unlock()
+	for db := range f.conns {
+		db.disconnect(ConnectionDropped)
+	}
+}
+
+// Disconnect drops the connection of the database, as when the network or server fails. Its transactions are
+// rolled back and all later requests, including Close, fail with ConnectionDropped.
+func (db *FakeDatabase) Disconnect() {
+	db.f.Lock()
+	defer db.f.Unlock()
+	db.disconnect(ConnectionDropped)
+}
+
+func (db *FakeDatabase) disconnect(err error) {
+	if db.err != nil {
+		return
+	}
+	for _, tx := range db.txs {
+		tx.end()
+	}
+	db.err = err
+	delete(db.f.conns, db)
+}
+
+func (db *FakeDatabase) BeginTX(table string) (keydbr.Transaction, error) {
+	db.f.Lock()
+	defer db.f.Unlock()
+
+	if db.err != nil {
+		return nil, db.err
+	}
+	if err := keydbr.ValidateTableName(table); err != nil {
+		return nil, err
+	}
+	db.f.nextid++
+	tx := &fakeTransaction{db: db, id: db.f.nextid, table: table, writes: map[string][]byte{}}
+	db.txs[tx.id] = tx
+	return tx, nil
+}
+
+// Close closes the database, which fails if it has open transactions
+func (db *FakeDatabase) Close() error {
+	db.f.Lock()
+	defer db.f.Unlock()
+
+	if db.err != nil {
+		return db.err
+	}
+	if len(db.txs) > 0 {
+		return databaseOpenTxs
+	}
+	db.disconnect(databaseClosed)
+	return nil
+}
+
+// check returns the error for a request on the transaction, which fails once the transaction is complete
+func (tx *fakeTransaction) check() error {
+	if tx.db.err != nil {
+		return tx.db.err
+	}
+	if _, ok := tx.db.txs[tx.id]; !ok {
+		return invalidTxID
+	}
+	return nil
+}
+
+func checkKey(key []byte) error {
+	if len(key) == 0 {
+		return emptyKey
+	}
+	if len(key) > maxKeyLength {
+		return keyTooLong
+	}
+	return nil
+}
+
+// committed returns the committed data of the transaction's table, which is nil if it has never been written
+func (tx *fakeTransaction) committed() map[string][]byte {
+	return tx.db.f.databases[tx.db.name][tx.table]
+}
+
+func (tx *fakeTransaction) get(key []byte) ([]byte, error) {
+	value, ok := tx.writes[string(key)]
+	if !ok {
+		value, ok = tx.committed()[string(key)]
+	}
+	if !ok || value == nil {
+		return nil, keydbr.KeyNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (tx *fakeTransaction) Get(key []byte) ([]byte, error) {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return tx.get(key)
+}
+
+func (tx *fakeTransaction) put(key []byte, value []byte) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		// keydb does not distinguish an empty value from a removed key
+		tx.writes[string(key)] = nil
+	} else {
+		tx.writes[string(key)] = append([]byte{}, value...)
+	}
+	return nil
+}
+
+// Put stores a value. As with the client, an invalid key is reported by the next Commit rather than by Put.
+func (tx *fakeTransaction) Put(key []byte, value []byte) error {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+
+	if tx.db.err != nil {
+		return tx.db.err
+	}
+	if err := tx.put(key, value); err != nil && tx.asyncfailure == nil {
+		tx.asyncfailure = err
+	}
+	return nil
+}
+
+func (tx *fakeTransaction) PutSync(key []byte, value []byte) error {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+	return tx.put(key, value)
+}
+
+func (tx *fakeTransaction) Remove(key []byte) ([]byte, error) {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	value, err := tx.get(key)
+	if err != nil {
+		return nil, err
+	}
+	tx.writes[string(key)] = nil
+	return value, nil
+}
+
+// Lookup returns an iterator over the entries from lower to upper inclusive as of the lookup
+func (tx *fakeTransaction) Lookup(lower []byte, upper []byte) (keydbr.Iterator, error) {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	merged := map[string][]byte{}
+	for key, value := range tx.committed() {
+		merged[key] = value
+	}
+	for key, value := range tx.writes {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	itr := &fakeIterator{tx: tx}
+	for key := range merged {
+		if lower != nil && bytes.Compare([]byte(key), lower) < 0 {
+			continue
+		}
+		if upper != nil && bytes.Compare([]byte(key), upper) > 0 {
+			continue
+		}
+		itr.keys = append(itr.keys, key)
+	}
+	sort.Strings(itr.keys)
+	for _, key := range itr.keys {
+		itr.values = append(itr.values, append([]byte(nil), merged[key]...))
+	}
+	tx.itrs = append(tx.itrs, itr)
+	return itr, nil
+}
+
+func (tx *fakeTransaction) commit() error {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if tx.asyncfailure != nil {
+		return tx.asyncfailure
+	}
+	if f := tx.db.f; len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return err
+	}
+
+	if len(tx.writes) > 0 {
+		tables := tx.db.f.databases[tx.db.name]
+		if tables[tx.table] == nil {
+			tables[tx.table] = map[string][]byte{}
+		}
+		for key, value := range tx.writes {
+			if value == nil {
+				delete(tables[tx.table], key)
+			} else {
+				tables[tx.table][key] = value
+			}
+		}
+	}
+	tx.end()
+	return nil
+}
+
+func (tx *fakeTransaction) Commit() error {
+	return tx.commit()
+}
+
+func (tx *fakeTransaction) CommitSync() error {
+	return tx.commit()
+}
+
+func (tx *fakeTransaction) Rollback() error {
+	tx.db.f.Lock()
+	defer tx.db.f.Unlock()
+
+	if err := tx.check(); err != nil {
+		return err
+	}
+	tx.end()
+	return nil
+}
+
+// end removes a completed transaction, invalidating its iterators
+func (tx *fakeTransaction) end() {
+	delete(tx.db.txs, tx.id)
+	for _, itr := range tx.itrs {
+		itr.invalid = true
+	}
+}
+
+// Next returns the next entry, or EndOfIterator once, after which the iterator is invalid as on the server
+func (itr *fakeIterator) Next() (key []byte, value []byte, err error) {
+	itr.tx.db.f.Lock()
+	defer itr.tx.db.f.Unlock()
+
+	if itr.tx.db.err != nil {
+		return nil, nil, itr.tx.db.err
+	}
+	if itr.invalid {
+		return nil, nil, invalidIteratorID
+	}
+	if itr.index == len(itr.keys) {
+		itr.invalid = true
+		return nil, nil, keydbr.EndOfIterator
+	}
+	key, value = []byte(itr.keys[itr.index]), itr.values[itr.index]
+	itr.index++
+	return key, value, nil
+}
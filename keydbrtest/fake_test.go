@@ -0,0 +1,218 @@
+package keydbrtest_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/keydbrtest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// scan returns the keys from lower to upper
+func scan(t *testing.T, tx keydbr.Transaction, lower, upper []byte) []string {
+	t.Helper()
+	itr, err := tx.Lookup(lower, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{}
+	for {
+		key, _, err := itr.Next()
+		if errors.Is(err, keydbr.EndOfIterator) {
+			return keys
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, string(key))
+	}
+}
+
+// isolation checks the behavior shared by the fake and a server
+func isolation(t *testing.T, db1, db2 keydbr.Database) {
+	tx1, err := db1.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx2, err := db2.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"c", "a", "b"} {
+		if err := tx1.PutSync([]byte(key), []byte("value "+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if value, err := tx1.Get([]byte("a")); err != nil || string(value) != "value a" {
+		t.Fatal("transaction should see its own writes", string(value), err)
+	}
+	if _, err := tx2.Get([]byte("a")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("uncommitted write should not be visible", err)
+	}
+	if err := tx1.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx1.Commit(); err == nil {
+		t.Fatal("completed transaction should be invalid")
+	}
+	if value, err := tx2.Get([]byte("a")); err != nil || string(value) != "value a" {
+		t.Fatal("committed write should be visible", string(value), err)
+	}
+
+	if value, err := tx2.Remove([]byte("b")); err != nil || string(value) != "value b" {
+		t.Fatal("wrong removed value", string(value), err)
+	}
+	if _, err := tx2.Remove([]byte("b")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("removed key should not be found", err)
+	}
+	if err := tx2.PutSync([]byte("d"), []byte("value d")); err != nil {
+		t.Fatal(err)
+	}
+	if keys := scan(t, tx2, nil, nil); !reflect.DeepEqual(keys, []string{"a", "c", "d"}) {
+		t.Fatal("wrong keys", keys)
+	}
+	if keys := scan(t, tx2, []byte("b"), []byte("c")); !reflect.DeepEqual(keys, []string{"c"}) {
+		t.Fatal("wrong range", keys)
+	}
+	// keydb does not distinguish an empty value from a removed key
+	if err := tx2.PutSync([]byte("c"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx2.Get([]byte("c")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("empty value should not be found", err)
+	}
+	if keys := scan(t, tx2, nil, nil); !reflect.DeepEqual(keys, []string{"a", "d"}) {
+		t.Fatal("empty value should not be returned by lookups", keys)
+	}
+	if err := tx2.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db1.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := scan(t, tx, nil, nil); !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Fatal("rolled back writes should be discarded", keys)
+	}
+	if err := tx.PutSync(nil, []byte("value")); err == nil {
+		t.Fatal("empty key should be rejected")
+	}
+	if err := tx.Put(make([]byte, 2000), []byte("value")); err != nil {
+		t.Fatal("put should not wait for the server", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("commit should report the failed put")
+	}
+	if _, err := db1.BeginTX(".invalid"); !errors.Is(err, keydbr.InvalidTableName) {
+		t.Fatal("invalid table name should be rejected", err)
+	}
+	if err := db2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db1.Close(); err == nil {
+		t.Fatal("close should fail with an open transaction")
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db1.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeIsolation(t *testing.T) {
+	f := keydbrtest.NewFake()
+	if _, err := f.Open("main", false); !errors.Is(err, keydbr.NoDatabaseFound) {
+		t.Fatal("missing database should not be found", err)
+	}
+	db1, err := f.Open("main", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db2, err := f.Open("main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Remove("main"); err == nil {
+		t.Fatal("open database should not be removed")
+	}
+	isolation(t, db1, db2)
+
+	if tables, err := f.ListTables("main"); err != nil || !reflect.DeepEqual(tables, []string{"main"}) {
+		t.Fatal("wrong tables", tables, err)
+	}
+	if err := f.Remove("main"); err != nil {
+		t.Fatal(err)
+	}
+	if dbnames, err := f.List(); err != nil || len(dbnames) != 0 {
+		t.Fatal("database should be removed", dbnames, err)
+	}
+}
+
+// TestServerIsolation checks that the server behaves as the fake
+func TestServerIsolation(t *testing.T) {
+	srv := keydbrtest.NewServer(t)
+	isolation(t, srv.Open(t, "main"), srv.Open(t, "main"))
+}
+
+func TestFakeFailures(t *testing.T) {
+	f := keydbrtest.NewFake()
+	db, err := f.Open("main", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("disk full")
+	f.FailNextCommit(failure)
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Put([]byte("a"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != failure {
+		t.Fatal("commit should fail", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal("failed transaction should be rolled back", err)
+	}
+
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.PutSync([]byte("a"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Disconnect()
+	if _, err := tx.Get([]byte("a")); status.Code(err) != codes.Unavailable {
+		t.Fatal("dropped connection should fail requests", err)
+	}
+	if _, _, err := itr.Next(); err != keydbrtest.ConnectionDropped {
+		t.Fatal("dropped connection should fail iterators", err)
+	}
+	if err := db.Close(); err != keydbrtest.ConnectionDropped {
+		t.Fatal("dropped connection should fail close", err)
+	}
+
+	db, err = f.Open("main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Get([]byte("a")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("dropped transaction should be rolled back", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package keydbrtest runs a keydbr server in process for tests, so tests using the client do not depend on a server
+// already running on the machine.
+package keydbrtest
+
+import (
+	"context"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// Addr is the address of a test server, which is only reachable using the options returned by Server.Options
+const Addr = "keydbrtest"
+
+const bufferSize = 1024 * 1024
+
+// Server is a server listening on an in-memory connection, with its databases in a temporary directory
+type Server struct {
+	*server.Server
+	// Dir is the directory containing the databases, which is removed when the test completes
+	Dir string
+	lis *bufconn.Listener
+}
+
+// NewServer starts a server configured with opts, which is shut down when the test completes
+func NewServer(t testing.TB, opts ...server.Option) *Server {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "keydbrtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewServer(dir, opts...)
+	gs, err := srv.NewGRPCServer()
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(bufferSize)
+	go gs.Serve(lis)
+	srv.SetServing(true)
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		gs.Stop()
+		os.RemoveAll(dir)
+	})
+	return &Server{Server: srv, Dir: dir, lis: lis}
+}
+
+// Options returns the client options connecting to the server, followed by opts
+func (s *Server) Options(opts ...client.Option) []client.Option {
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		return s.lis.DialContext(ctx)
+	}
+	return append([]client.Option{client.WithDialer(dialer)}, opts...)
+}
+
+// Open opens the database dbname on the server, creating it if needed
+func (s *Server) Open(t testing.TB, dbname string, opts ...client.Option) *client.RemoteDatabase {
+	t.Helper()
+	db, err := client.Open(Addr, dbname, true, 10, s.Options(opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// Open starts a server with the default options and opens the database dbname on it
+func Open(t testing.TB, dbname string) *client.RemoteDatabase {
+	t.Helper()
+	return NewServer(t).Open(t, dbname)
+}
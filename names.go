@@ -0,0 +1,54 @@
+package keydbr
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MaxDatabaseNameLength = 255
+const MaxTableNameLength = 128
+
+// ValidateDatabaseName checks that name is a safe database name. A database name is one or more
+// segments separated by '/', for example "test/mydb". Each segment must start with a letter, digit,
+// '_' or '-', and contain only letters, digits, '_', '-' and '.'. The total length is limited
+// to MaxDatabaseNameLength bytes. An invalid name returns an error wrapping InvalidDatabaseName.
+func ValidateDatabaseName(name string) error {
+	if len(name) == 0 || len(name) > MaxDatabaseNameLength {
+		return fmt.Errorf("%w: %q, length must be between 1 and %d", InvalidDatabaseName, name, MaxDatabaseNameLength)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if !validSegment(segment) {
+			return fmt.Errorf("%w: %q", InvalidDatabaseName, name)
+		}
+	}
+	return nil
+}
+
+// ValidateTableName checks that name is a safe table name. Table names use the same character set
+// as a database name segment, and are limited to MaxTableNameLength bytes. An invalid name returns
+// an error wrapping InvalidTableName.
+func ValidateTableName(name string) error {
+	if len(name) == 0 || len(name) > MaxTableNameLength {
+		return fmt.Errorf("%w: %q, length must be between 1 and %d", InvalidTableName, name, MaxTableNameLength)
+	}
+	if !validSegment(name) {
+		return fmt.Errorf("%w: %q", InvalidTableName, name)
+	}
+	return nil
+}
+
+func validSegment(s string) bool {
+	if len(s) == 0 || s[0] == '.' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_' || c == '-' || c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package keydbr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/robaho/keydbr"
+)
+
+func TestDatabaseNames(t *testing.T) {
+	valid := []string{"main", "test/mydb", "a.b-c_d", "team1/db.v2"}
+	for _, name := range valid {
+		if err := keydbr.ValidateDatabaseName(name); err != nil {
+			t.Error("should be valid", name, err)
+		}
+	}
+	invalid := []string{"", "..", "../../etc", "/etc", "a//b", "a/", "a/../b", ".hidden", "a b", "a\\b", "c:", strings.Repeat("a", keydbr.MaxDatabaseNameLength+1)}
+	for _, name := range invalid {
+		err := keydbr.ValidateDatabaseName(name)
+		if !errors.Is(err, keydbr.InvalidDatabaseName) {
+			t.Error("should be invalid", name, err)
+		}
+	}
+}
+
+func TestTableNames(t *testing.T) {
+	if err := keydbr.ValidateTableName("main"); err != nil {
+		t.Error(err)
+	}
+	invalid := []string{"", "a/b", "..", ".a", "a\x00", strings.Repeat("a", keydbr.MaxTableNameLength+1)}
+	for _, name := range invalid {
+		err := keydbr.ValidateTableName(name)
+		if !errors.Is(err, keydbr.InvalidTableName) {
+			t.Error("should be invalid", name, err)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	if keydbr.ParseError("") != nil {
+		t.Fatal("empty string should be nil")
+	}
+	err := keydbr.ParseError(`invalid database name: "../x"`)
+	if !errors.Is(err, keydbr.InvalidDatabaseName) || err.Error() != `invalid database name: "../x"` {
+		t.Fatal("wrong error", err)
+	}
+	err = keydbr.ParseError("some other error")
+	if errors.Is(err, keydbr.InvalidDatabaseName) || err.Error() != "some other error" {
+		t.Fatal("wrong error", err)
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robaho/keydbr"
+	"io/ioutil"
+	"path"
+)
+
+// Permission is the level of access granted to a database or table. Each level includes the levels below it.
+type Permission int
+
+const (
+	NoAccess Permission = iota
+	Read
+	Write
+	Admin
+)
+
+var permissionNames = []string{"none", "read", "write", "admin"}
+
+func (p Permission) String() string {
+	if p < NoAccess || p > Admin {
+		return fmt.Sprint("permission(", int(p), ")")
+	}
+	return permissionNames[p]
+}
+
+func (p Permission) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Permission) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for i, name := range permissionNames {
+		if s == name {
+			*p = Permission(i)
+			return nil
+		}
+	}
+	return errors.New("unknown permission " + s)
+}
+
+// ACLRule grants a permission to the identities matching Identity, on the databases and tables matching
+// Database and Table. The patterns use path.Match syntax, and an empty pattern matches everything. Anonymous
+// callers (when no authenticator is configured) have an empty identity name.
+type ACLRule struct {
+	Identity   string     `json:"identity"`
+	Database   string     `json:"database"`
+	Table      string     `json:"table"`
+	Permission Permission `json:"permission"`
+}
+
+// ACL is a list of rules. The permission granted is the highest of all matching rules, and no access
+// if no rules match. Rules only grant access, so a rule for a table can raise the access to it above the
+// database's rules, but cannot lower it.
+type ACL struct {
+	Rules []ACLRule `json:"rules"`
+}
+
+// LoadACL reads an ACL from a JSON file, e.g. {"rules":[{"identity":"alice","database":"team1/*","permission":"write"}]}
+func LoadACL(file string) (*ACL, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var acl ACL
+	if err := json.Unmarshal(data, &acl); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	for _, rule := range acl.Rules {
+		for _, pattern := range []string{rule.Identity, rule.Database, rule.Table} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern %q", file, pattern)
+			}
+		}
+	}
+	return &acl, nil
+}
+
+func match(pattern string, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func matchTable(pattern string, table string) bool {
+	if table == "" {
+		return pattern == "" || pattern == "*"
+	}
+	return match(pattern, table)
+}
+
+// Permission returns the permission granted to id on table in dbname. Use a table of "" to check
+// access to the database as a whole, in which case only rules matching all tables apply. A nil ACL
+// grants full access.
+func (acl *ACL) Permission(id *Identity, dbname string, table string) Permission {
+	if acl == nil {
+		return Admin
+	}
+	name := ""
+	if id != nil {
+		name = id.Name
+	}
+	p := NoAccess
+	for _, rule := range acl.Rules {
+		if rule.Permission > p && match(rule.Identity, name) && match(rule.Database, dbname) && matchTable(rule.Table, table) {
+			p = rule.Permission
+		}
+	}
+	return p
+}
+
+// AnyTable returns the highest permission granted to id on any table in dbname, which allows an identity whose
+// rules are all for tables to open the database
+func (acl *ACL) AnyTable(id *Identity, dbname string) Permission {
+	if acl == nil {
+		return Admin
+	}
+	name := ""
+	if id != nil {
+		name = id.Name
+	}
+	p := NoAccess
+	for _, rule := range acl.Rules {
+		if rule.Permission > p && match(rule.Identity, name) && match(rule.Database, dbname) {
+			p = rule.Permission
+		}
+	}
+	return p
+}
+
+// Check returns an error wrapping keydbr.PermissionDenied if id does not have at least permission required
+func (acl *ACL) Check(id *Identity, dbname string, table string, required Permission) error {
+	if acl.Permission(id, dbname, table) >= required {
+		return nil
+	}
+	return permissionDenied(id, dbname, table, required)
+}
+
+func permissionDenied(id *Identity, dbname string, table string, required Permission) error {
+	name := "anonymous"
+	if id != nil {
+		name = id.Name
+	}
+	if table != "" {
+		dbname = dbname + ", table " + table
+	}
+	return fmt.Errorf("%w: %s requires %s access to database %s", keydbr.PermissionDenied, name, required, dbname)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Identity is an authenticated caller
+type Identity struct {
+	Name string
+}
+
+// Authenticator determines the identity of the caller of an RPC. It returns NoCredentials if
+// the caller did not supply credentials of the type it handles.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (*Identity, error)
+}
+
+var NoCredentials = errors.New("no credentials")
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity established by the authentication interceptors, or nil
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// TokenAuthenticator authenticates callers using a bearer token sent in the "authorization" metadata
+type TokenAuthenticator struct {
+	tokens map[string]string // token -> identity
+}
+
+// LoadTokenFile reads a token file. Each line contains a token followed by the identity it grants,
+// separated by whitespace. Blank lines and lines starting with '#' are ignored.
+func LoadTokenFile(file string) (*TokenAuthenticator, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	auth := &TokenAuthenticator{tokens: make(map[string]string)}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, errors.New(file + ": expected 'token identity' on line " + strconv.Itoa(line))
+		}
+		auth.tokens[fields[0]] = fields[1]
+	}
+	return auth, scanner.Err()
+}
+
+func (a *TokenAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, NoCredentials
+	}
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	for t, name := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return &Identity{Name: name}, nil
+		}
+	}
+	return nil, errors.New("invalid token")
+}
+
+// TLSAuthenticator uses the common name of a verified client certificate as the identity
+type TLSAuthenticator struct{}
+
+func (TLSAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, NoCredentials
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, NoCredentials
+	}
+	return &Identity{Name: info.State.VerifiedChains[0][0].Subject.CommonName}, nil
+}
+
+// Authenticators tries each authenticator in order, using the first which finds credentials
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(ctx context.Context) (*Identity, error) {
+	for _, auth := range a {
+		id, err := auth.Authenticate(ctx)
+		if err != NoCredentials {
+			return id, err
+		}
+	}
+	return nil, NoCredentials
+}
+
+func authenticate(auth Authenticator, ctx context.Context) (context.Context, error) {
+	id, err := auth.Authenticate(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication failed: "+err.Error())
+	}
+	return context.WithValue(ctx, identityKey{}, id), nil
+}
+
+// AuthUnaryInterceptor rejects unauthenticated calls, and makes the caller's identity available
+// via IdentityFromContext
+func AuthUnaryInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(auth, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor is the streaming equivalent of AuthUnaryInterceptor
+func AuthStreamInterceptor(auth Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(auth, ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,185 @@
+package server_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestACL(t *testing.T) {
+	acl := &server.ACL{Rules: []server.ACLRule{
+		{Identity: "alice", Database: "team1/*", Permission: server.Admin},
+		{Identity: "bob", Database: "team1/*", Permission: server.Read},
+		{Identity: "bob", Database: "team1/*", Table: "scratch", Permission: server.Write},
+		{Identity: "bob", Database: "team1/*", Table: "secret", Permission: server.NoAccess},
+		{Identity: "dave", Database: "team1/*", Table: "shared", Permission: server.Read},
+	}}
+
+	alice, bob, carol := &server.Identity{Name: "alice"}, &server.Identity{Name: "bob"}, &server.Identity{Name: "carol"}
+	dave := &server.Identity{Name: "dave"}
+
+	if p := acl.Permission(alice, "team1/db", "main"); p != server.Admin {
+		t.Error("alice should have admin", p)
+	}
+	if p := acl.Permission(alice, "team2/db", "main"); p != server.NoAccess {
+		t.Error("alice should have no access", p)
+	}
+	if p := acl.Permission(bob, "team1/db", "main"); p != server.Read {
+		t.Error("bob should have read", p)
+	}
+	if p := acl.Permission(bob, "team1/db", "scratch"); p != server.Write {
+		t.Error("bob should have write", p)
+	}
+	if p := acl.Permission(bob, "team1/db", ""); p != server.Read {
+		t.Error("table rule should not apply to the database", p)
+	}
+	if err := acl.Check(carol, "team1/db", "", server.Read); !errors.Is(err, keydbr.PermissionDenied) {
+		t.Error("carol should be denied", err)
+	}
+	if p := acl.Permission(bob, "team1/db", "secret"); p != server.Read {
+		t.Error("table rule should not lower the database's access", p)
+	}
+	if p := acl.AnyTable(dave, "team1/db"); p != server.Read {
+		t.Error("dave should read a table", p)
+	}
+	if p, q := acl.Permission(dave, "team1/db", "shared"), acl.Permission(dave, "team1/db", "main"); p != server.Read || q != server.NoAccess {
+		t.Error("dave should only read the shared table", p, q)
+	}
+	if p := acl.AnyTable(carol, "team1/db"); p != server.NoAccess {
+		t.Error("carol should have no access to any table", p)
+	}
+	var none *server.ACL
+	if p := none.Permission(nil, "any", ""); p != server.Admin {
+		t.Error("nil acl should grant full access", p)
+	}
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keydbr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "tokens")
+	writeFile(t, tokenFile, []byte("# token identity\nalicetoken alice\nbobtoken bob\ndavetoken dave\n"))
+	tokens, err := server.LoadTokenFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := newCert(t, "test ca", nil, 1)
+	serverCert := newCert(t, "server", ca, 2)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	srv := server.NewServer(filepath.Join(dir, "databases"))
+	srv.ACL = &server.ACL{Rules: []server.ACLRule{
+		{Identity: "alice", Database: "team1/*", Permission: server.Admin},
+		{Identity: "bob", Database: "team1/*", Permission: server.Read},
+		{Identity: "dave", Database: "team1/*", Table: "shared", Permission: server.Read},
+	}}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert.tlsCertificate(t)}})),
+		grpc.StreamInterceptor(server.AuthStreamInterceptor(tokens)),
+		grpc.UnaryInterceptor(server.AuthUnaryInterceptor(tokens)))
+	pb.RegisterKeydbServer(s, srv)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	addr := lis.Addr().String()
+
+	_, err = client.Open(addr, "team1/db", true, 10, client.WithCA(pool), client.WithToken("badtoken"))
+	if err == nil {
+		t.Fatal("open with an invalid token should fail")
+	}
+
+	_, err = client.Open(addr, "team1/db", true, 10, client.WithCA(pool), client.WithToken("bobtoken"))
+	if !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("bob should not be able to create a database", err)
+	}
+
+	db, err := client.Open(addr, "team1/db", true, 10, client.WithCA(pool), client.WithToken("alicetoken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = client.Open(addr, "team1/db", false, 10, client.WithCA(pool), client.WithToken("bobtoken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, err := tx.Get([]byte("mykey")); err != nil || string(val) != "myvalue" {
+		t.Fatal("wrong value returned", string(val), err)
+	}
+	if err = tx.PutSync([]byte("mykey"), []byte("other")); !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("bob should not be able to write", err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// an identity with access to a table opens the database, but only reads that table
+	db, err = client.Open(addr, "team1/db", false, 10, client.WithCA(pool), client.WithToken("davetoken"))
+	if err != nil {
+		t.Fatal("dave should open the database", err)
+	}
+	if tx, err = db.BeginTX("shared"); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+	if _, err = db.BeginTX("main"); !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("dave should not read other tables", err)
+	}
+	if tables, err := client.ListTables(addr, "team1/db", 10, client.WithCA(pool), client.WithToken("davetoken")); err != nil || len(tables) != 0 {
+		t.Fatal("only the readable tables should be listed", tables, err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Remove(addr, "team1/db", 10, client.WithCA(pool), client.WithToken("bobtoken"))
+	if !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("bob should not be able to remove the database", err)
+	}
+	err = client.Remove(addr, "team1/db", 10, client.WithCA(pool), client.WithToken("alicetoken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,566 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultClusterTimeout is how long a commit waits for the cluster, and the timeout of the connections between its
+// members
+const DefaultClusterTimeout = 10 * time.Second
+
+// raftDir is the directory in the server path holding the raft log and snapshots. It is not a valid database name,
+// so it is not listed.
+const raftDir = ".raft"
+
+var errDatabaseInUse = errors.New("database in use")
+
+// ClusterConfig configures a member of a cluster. The members are identified by the addresses clients use, and
+// connect to each other using their raft addresses, which must be reachable by the other members.
+type ClusterConfig struct {
+	// Address is the address clients use to reach this member
+	Address string `yaml:"address" json:"address"`
+	// RaftAddress is the address this member listens on for the other members
+	RaftAddress string `yaml:"raftAddress" json:"raftAddress"`
+	// Members are all the members of the cluster, including this one
+	Members []ClusterMember `yaml:"members" json:"members"`
+}
+
+// ClusterMember is a member of a cluster
+type ClusterMember struct {
+	Address     string `yaml:"address" json:"address"`
+	RaftAddress string `yaml:"raftAddress" json:"raftAddress"`
+}
+
+// cluster is the raft state of a cluster member
+type cluster struct {
+	config    ClusterConfig
+	raft      *raft.Raft
+	transport *raft.NetworkTransport
+	store     *raftStore
+	fsm       *fsm
+	notify    chan bool
+	// ready is set once the leader has applied the commands committed by earlier leaders. It is set while holding
+	// mu, if no leadership change has been notified since, which increments changes.
+	ready   int32
+	mu      sync.Mutex
+	changes uint64
+}
+
+// fsm applies the commands of the replicated log to the databases of the server. It keeps the databases it has
+// written open, holding a reference to each which is guarded by the server's lock.
+type fsm struct {
+	s   *Server
+	dbs map[string]*openDatabase // by name
+}
+
+// fsmSnapshot writes the databases to a snapshot
+type fsmSnapshot struct {
+	s *Server
+}
+
+// StartCluster joins the cluster configured by WithCluster, bootstrapping it if the member has no raft state. It
+// must be called before serving, and ListenAndServe calls it. The member leaves the cluster on Shutdown.
+func (s *Server) StartCluster() error {
+	c := s.cluster
+	if c == nil || c.raft != nil {
+		return nil
+	}
+	dir := filepath.Join(s.path, raftDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	level := hclog.Info
+	if s.logger.Enabled(context.Background(), slog.LevelDebug) {
+		level = hclog.Debug
+	}
+	logger := hclog.New(&hclog.LoggerOptions{Name: "raft", Level: level, Output: raftLogWriter{s.logger}, DisableTime: true})
+
+	store, err := openRaftStore(filepath.Join(dir, "log"))
+	if err != nil {
+		return err
+	}
+	snapshots, err := raft.NewFileSnapshotStoreWithLogger(dir, 2, logger)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	transport, err := raft.NewTCPTransportWithLogger(c.config.RaftAddress, nil, 3, DefaultClusterTimeout, logger)
+	if err != nil {
+		store.Close()
+		return err
+	}
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(c.config.Address)
+	config.Logger = logger
+	config.NotifyCh = c.notify
+	// the databases are on disk, and replaying the commands after the snapshot brings them up to date
+	config.NoSnapshotRestoreOnStart = true
+
+	existing, err := raft.HasExistingState(store, store, snapshots)
+	if err == nil {
+		c.raft, err = raft.NewRaft(config, c.fsm, store, store, snapshots, transport)
+	}
+	if err != nil {
+		transport.Close()
+		store.Close()
+		return err
+	}
+	c.transport, c.store = transport, store
+
+	if !existing {
+		var configuration raft.Configuration
+		for _, member := range c.config.Members {
+			configuration.Servers = append(configuration.Servers, raft.Server{ID: raft.ServerID(member.Address), Address: raft.ServerAddress(member.RaftAddress)})
+		}
+		if err := c.raft.BootstrapCluster(configuration).Error(); err != nil && err != raft.ErrCantBootstrap {
+			s.stopCluster()
+			return err
+		}
+	}
+	go s.watchLeadership(c)
+	s.logger.Info("joined cluster", "address", c.config.Address, "raft", c.config.RaftAddress)
+	return nil
+}
+
+// watchLeadership makes the member ready to serve as the leader once it has applied the log. The log is applied
+// in the background, so the notifications of leadership changes are received while it is applied.
+func (s *Server) watchLeadership(c *cluster) {
+	for leader := range c.notify {
+		c.mu.Lock()
+		atomic.StoreInt32(&c.ready, 0)
+		c.changes++
+		changes := c.changes
+		c.mu.Unlock()
+		if !leader {
+			s.logger.Info("no longer the cluster leader")
+			continue
+		}
+		go s.becomeLeader(c, changes)
+	}
+}
+
+// becomeLeader makes the member ready once it has applied the log, unless its leadership has changed since
+func (s *Server) becomeLeader(c *cluster, changes uint64) {
+	if err := c.raft.Barrier(DefaultClusterTimeout).Error(); err != nil {
+		s.logger.Warn("unable to apply the log as leader", "error", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.changes == changes {
+		atomic.StoreInt32(&c.ready, 1)
+		s.logger.Info("elected cluster leader")
+	}
+}
+
+// stopCluster stops the member, after which the databases are no longer written by the cluster
+func (s *Server) stopCluster() {
+	c := s.cluster
+	if c == nil || c.raft == nil || c.raft.State() == raft.Shutdown {
+		return
+	}
+	if err := c.raft.Shutdown().Error(); err != nil {
+		s.logger.Error("unable to stop cluster member", "error", err)
+	}
+	close(c.notify)
+	c.transport.Close()
+	c.store.Close()
+}
+
+// checkLeader returns NotLeader, with the address of the leader if known, if the server is a cluster member which
+// is not the leader
+func (s *Server) checkLeader() error {
+	c := s.cluster
+	if c == nil {
+		return nil
+	}
+	if c.raft == nil {
+		return keydbr.NotLeader
+	}
+	if c.raft.State() == raft.Leader && atomic.LoadInt32(&c.ready) == 1 {
+		return nil
+	}
+	_, id := c.raft.LeaderWithID()
+	if id == "" || string(id) == c.config.Address {
+		return keydbr.NotLeader
+	}
+	return fmt.Errorf("%w: %s", keydbr.NotLeader, id)
+}
+
+// apply commits a command to the replicated log, returning once it has been applied by this member
+func (s *Server) apply(cmd *pb.ClusterCommand) error {
+	data, err := proto.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	future := s.cluster.raft.Apply(data, DefaultClusterTimeout)
+	if err := future.Error(); err != nil {
+		if err == raft.ErrNotLeader {
+			if err := s.checkLeader(); err != nil {
+				return err
+			}
+			return keydbr.NotLeader
+		}
+		return err
+	}
+	if err, ok := future.Response().(error); ok {
+		return err
+	}
+	return nil
+}
+
+// commitCluster commits the writes of the transaction through the cluster, which applies them to the database
+// on each member, and then discards the transaction. The writes are durable once committed.
+func (tx *transaction) commitCluster(s *Server) error {
+	if len(tx.mutations) > 0 {
+		if err := s.apply(&pb.ClusterCommand{Dbname: tx.dbname, Table: tx.table, Mutations: tx.mutations}); err != nil {
+			return err
+		}
+	}
+	tx.mutations = nil
+	return tx.Rollback()
+}
+
+// removeFromCluster removes a database from every member. The caller must hold the lock, which is released while
+// the command is applied.
+func (s *Server) removeFromCluster(dbname, fullpath string) error {
+	if err := s.checkLeader(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(fullpath); os.IsNotExist(err) || s.removing(fullpath) {
+		return keydb.NoDatabaseFound
+	}
+	if opendb, ok := s.opendb[fullpath]; ok {
+		// the database is open for the cluster, and must not be open for any session
+		refs := opendb.refcount
+		if s.cluster.fsm.dbs[dbname] == opendb {
+			refs--
+		}
+		if refs > 0 {
+			return errDatabaseInUse
+		}
+	}
+	s.Unlock()
+	defer s.Lock()
+	return s.apply(&pb.ClusterCommand{Dbname: dbname, Remove: true})
+}
+
+// ClusterStatus returns the leader and the members of the cluster, and the index of the last command applied by
+// this member
+func (s *Server) ClusterStatus(ctx context.Context, in *pb.ClusterStatusRequest) (*pb.ClusterStatusReply, error) {
+	c := s.cluster
+	if c == nil || c.raft == nil {
+		return &pb.ClusterStatusReply{Error: "server is not a cluster member"}, nil
+	}
+	_, leader := c.raft.LeaderWithID()
+	future := c.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return &pb.ClusterStatusReply{Error: err.Error()}, nil
+	}
+	reply := &pb.ClusterStatusReply{Leader: string(leader), Applied: c.raft.AppliedIndex()}
+	for _, server := range future.Configuration().Servers {
+		reply.Members = append(reply.Members, string(server.ID))
+	}
+	return reply, nil
+}
+
+func (f *fsm) Apply(log *raft.Log) interface{} {
+	var cmd pb.ClusterCommand
+	if err := proto.Unmarshal(log.Data, &cmd); err != nil {
+		return err
+	}
+	err := f.apply(&cmd)
+	if err != nil {
+		f.s.logger.Error("unable to apply cluster command", "database", cmd.Dbname, "index", log.Index, "error", err)
+	}
+	return err
+}
+
+// apply applies a command. Commands set or remove keys, and create or remove databases, so applying a command
+// again, as when the log is replayed after a restart, has no further effect.
+func (f *fsm) apply(cmd *pb.ClusterCommand) error {
+	if err := keydbr.ValidateDatabaseName(cmd.Dbname); err != nil {
+		return err
+	}
+	if cmd.Remove {
+		return f.remove(cmd.Dbname)
+	}
+	opendb, err := f.database(cmd.Dbname)
+	if err != nil || cmd.Create {
+		return err
+	}
+	return applyMutations(opendb, cmd.Table, cmd.Mutations)
+}
+
+// database returns the database dbname, creating it if needed
+func (f *fsm) database(dbname string) (*openDatabase, error) {
+	s := f.s
+	s.Lock()
+	defer s.Unlock()
+	if opendb, ok := f.dbs[dbname]; ok {
+		return opendb, nil
+	}
+	opendb, err := s.acquire(dbname, filepath.Join(s.path, dbname), true, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	f.dbs[dbname] = opendb
+	return opendb, nil
+}
+
+// remove removes a database. The database may be open on this member, for a snapshot or for sessions opened while
+// it was the leader, so to remove it the same way as the other members it is emptied, and is deleted once it is no
+// longer open. The sessions which opened it can no longer begin transactions.
+func (f *fsm) remove(dbname string) error {
+	s := f.s
+	s.Lock()
+	defer s.Unlock()
+	if opendb, ok := f.dbs[dbname]; ok {
+		delete(f.dbs, dbname)
+		s.release(opendb, s.logger)
+	}
+	fullpath := filepath.Join(s.path, dbname)
+	if opendb, ok := s.opendb[fullpath]; ok {
+		if opendb.removed {
+			return nil
+		}
+		opendb.removed = true
+		opendb.mu.Lock()
+		opendb.generation++
+		opendb.mu.Unlock()
+		s.logger.Info("removing database once it is closed", "database", dbname, "references", opendb.refcount)
+		return clearDatabase(opendb)
+	}
+	err := keydb.Remove(fullpath)
+	if err == keydb.NoDatabaseFound {
+		return nil
+	}
+	if err == nil {
+		s.logger.Info("removed database", "database", dbname)
+	}
+	return err
+}
+
+// Snapshot returns a snapshot which reads the databases while later commands are applied, so it may include some
+// of their writes. This is harmless, since the commands are applied again after the snapshot is restored.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	return &fsmSnapshot{s: f.s}, nil
+}
+
+// Restore replaces the databases with the contents of a snapshot, which is a sequence of commands
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	s := f.s
+
+	existing, err := listDatabases(s.path)
+	if err != nil {
+		return err
+	}
+	for _, dbname := range existing {
+		if keydbr.ValidateDatabaseName(dbname) != nil {
+			continue
+		}
+		opendb, err := f.database(dbname)
+		if err == nil {
+			err = clearDatabase(opendb)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	restored := make(map[string]bool)
+	r := bufio.NewReader(rc)
+	for {
+		cmd, err := readCommand(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := f.apply(cmd); err != nil {
+			return err
+		}
+		restored[cmd.Dbname] = true
+	}
+
+	for _, dbname := range existing {
+		if keydbr.ValidateDatabaseName(dbname) == nil && !restored[dbname] {
+			if err := f.remove(dbname); err != nil {
+				return err
+			}
+		}
+	}
+	s.logger.Info("restored cluster snapshot", "databases", len(restored))
+	return nil
+}
+
+func (snap *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := snap.s.writeSnapshot(sink); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (snap *fsmSnapshot) Release() {}
+
+// writeSnapshot writes the databases as commands creating each database followed by commands writing its entries
+func (s *Server) writeSnapshot(w io.Writer) error {
+	dbnames, err := listDatabases(s.path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, dbname := range dbnames {
+		if keydbr.ValidateDatabaseName(dbname) != nil {
+			continue
+		}
+		fullpath := filepath.Join(s.path, dbname)
+		s.Lock()
+		opendb, err := s.acquire(dbname, fullpath, false, s.logger)
+		s.Unlock()
+		if err == keydb.NoDatabaseFound {
+			continue // removed since it was listed
+		}
+		if err != nil {
+			return err
+		}
+		err = writeCommand(bw, &pb.ClusterCommand{Dbname: dbname, Create: true})
+		if err == nil {
+			err = writeDatabase(bw, opendb, dbname)
+		}
+		s.Lock()
+		s.release(opendb, s.logger)
+		s.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeDatabase writes the entries of each table of a database in batches
+func writeDatabase(w io.Writer, opendb *openDatabase, dbname string) error {
+	tables, err := opendb.tables()
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		tx, err := opendb.beginTX(table)
+		if err != nil {
+			return err
+		}
+		err = writeTable(w, tx, dbname, table)
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTable(w io.Writer, tx *keydb.Transaction, dbname, table string) error {
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		return err
+	}
+	cmd := &pb.ClusterCommand{Dbname: dbname, Table: table}
+	size := 0
+	for {
+		key, value, err := itr.Next()
+		if err == keydb.EndOfIterator {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		cmd.Mutations = append(cmd.Mutations, &pb.Mutation{Key: key, Value: value})
+		size += len(key) + len(value)
+		if len(cmd.Mutations) >= replicationBatch || size >= copyBatchBytes {
+			if err := writeCommand(w, cmd); err != nil {
+				return err
+			}
+			cmd.Mutations, size = nil, 0
+		}
+	}
+	if len(cmd.Mutations) == 0 {
+		return nil
+	}
+	return writeCommand(w, cmd)
+}
+
+// writeCommand writes a command preceded by its length
+func writeCommand(w io.Writer, cmd *pb.ClusterCommand) error {
+	data, err := proto.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	length := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(length, uint64(len(data)))
+	if _, err := w.Write(length[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readCommand reads a command written by writeCommand, returning io.EOF at the end of the snapshot
+func readCommand(r *bufio.Reader) (*pb.ClusterCommand, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	var cmd pb.ClusterCommand
+	return &cmd, proto.Unmarshal(data, &cmd)
+}
+
+// raftLogWriter writes the entries logged by raft to the server's logger
+type raftLogWriter struct {
+	logger *slog.Logger
+}
+
+func (w raftLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+	level := slog.LevelInfo
+	switch {
+	case strings.HasPrefix(line, "[ERROR]"):
+		level = slog.LevelError
+	case strings.HasPrefix(line, "[WARN]"):
+		level = slog.LevelWarn
+	case strings.HasPrefix(line, "[DEBUG]"), strings.HasPrefix(line, "[TRACE]"):
+		level = slog.LevelDebug
+	}
+	if strings.HasPrefix(line, "[") {
+		if i := strings.Index(line, "]"); i > 0 {
+			line = strings.TrimSpace(line[i+1:])
+		}
+	}
+	w.logger.Log(context.Background(), level, line)
+	return len(p), nil
+}
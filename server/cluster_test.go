@@ -0,0 +1,210 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// freeAddress returns a local address which is not in use
+func freeAddress(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+// startCluster starts a cluster of n members, returning the servers, their addresses and their directories
+func startCluster(t *testing.T, n int) ([]*server.Server, []string, []string) {
+	var listeners []net.Listener
+	var members []server.ClusterMember
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners = append(listeners, lis)
+		members = append(members, server.ClusterMember{Address: lis.Addr().String(), RaftAddress: freeAddress(t)})
+	}
+	var servers []*server.Server
+	var addrs, dirs []string
+	for i, lis := range listeners {
+		dir := tempDir(t)
+		srv := server.NewServer(dir, server.WithCluster(server.ClusterConfig{
+			Address: members[i].Address, RaftAddress: members[i].RaftAddress, Members: members}))
+		if err := srv.StartCluster(); err != nil {
+			t.Fatal(err)
+		}
+		s := grpc.NewServer()
+		pb.RegisterKeydbServer(s, srv)
+		go s.Serve(lis)
+		t.Cleanup(func() {
+			srv.Shutdown(context.Background())
+			s.Stop()
+		})
+		servers = append(servers, srv)
+		addrs = append(addrs, members[i].Address)
+		dirs = append(dirs, dir)
+	}
+	return servers, addrs, dirs
+}
+
+// leader waits for the cluster to elect a leader other than exclude, returning its index
+func leader(t *testing.T, addrs []string, exclude string) int {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		for _, addr := range addrs {
+			if addr == exclude {
+				continue
+			}
+			status, err := client.ClusterStatus(addr, 10)
+			if err == nil && status.Leader != "" && status.Leader != exclude {
+				for i := range addrs {
+					if addrs[i] == status.Leader {
+						return i
+					}
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cluster did not elect a leader")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// applied waits until the follower has applied the commands applied by the leader
+func applied(t *testing.T, leader, follower string) {
+	t.Helper()
+	status, err := client.ClusterStatus(leader, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		fstatus, err := client.ClusterStatus(follower, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fstatus.Applied >= status.Applied {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("follower did not apply the log", fstatus.Applied, status.Applied)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func get(t *testing.T, db keydbr.Database, key string) string {
+	t.Helper()
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	value, err := tx.Get([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(value)
+}
+
+func TestCluster(t *testing.T) {
+	servers, addrs, dirs := startCluster(t, 3)
+	first := leader(t, addrs, "")
+	follower := addrs[(first+1)%len(addrs)]
+
+	cluster, err := client.ClusterStatus(follower, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Leader != addrs[first] || len(cluster.Members) != 3 {
+		t.Fatalf("wrong cluster status %+v", cluster)
+	}
+
+	// a follower redirects clients to the leader
+	db, err := client.Open(follower, "main", true, 10, client.WithCluster(addrs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Put([]byte("a"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only an unavailable member is failed over, so a killed session is not reopened
+	sessions, err := client.ListSessions(addrs[first], 10)
+	if err != nil || len(sessions) != 1 {
+		t.Fatal("wrong sessions", sessions, err)
+	}
+	if err := client.KillSession(addrs[first], sessions[0].ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.BeginTX("main"); status.Code(err) != codes.Aborted {
+		t.Fatal("killed session should not be reopened", err)
+	}
+	if db, err = client.Open(follower, "main", false, 10, client.WithCluster(addrs...)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the database follows the new leader once the leader fails
+	if summary := servers[first].Shutdown(context.Background()); len(summary.Errors) > 0 {
+		t.Fatal(summary.Errors)
+	}
+	second := leader(t, addrs, addrs[first])
+	if value := get(t, db, "a"); value != "1" {
+		t.Fatal("committed value should be on the new leader", value)
+	}
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Put([]byte("b"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the remaining follower applies both transactions to its databases
+	other := 3 - first - second
+	applied(t, addrs[second], addrs[other])
+	servers[second].Shutdown(context.Background())
+	if summary := servers[other].Shutdown(context.Background()); len(summary.Errors) > 0 {
+		t.Fatal(summary.Errors)
+	}
+	srv := server.NewServer(dirs[other])
+	addr := startServer(t, srv)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	stopped, err := client.Open(addr, "main", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stopped.Close()
+	if a, b := get(t, stopped, "a"), get(t, stopped, "b"); a != "1" || b != "2" {
+		t.Fatal("follower is missing committed values", a, b)
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration read from a configuration file as a string, e.g. "10s"
+type Duration time.Duration
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+// Config is the server configuration, read from a YAML or JSON file. The files it references are
+// loaded by Options.
+type Config struct {
+	// Path is the top-level database directory
+	Path string `yaml:"path" json:"path"`
+	// Listen is the tcp address of the server
+	Listen string `yaml:"listen" json:"listen"`
+	// Metrics is the http address serving /metrics, disabled if empty
+	Metrics string `yaml:"metrics" json:"metrics"`
+	// REST is the http address of the REST gateway, disabled if empty
+	REST string `yaml:"rest" json:"rest"`
+	// RESP is the tcp address of the Redis protocol frontend, disabled if empty, which stores the keys in RESPTable
+	// of RESPDatabase
+	RESP         string `yaml:"resp" json:"resp"`
+	RESPDatabase string `yaml:"respDatabase" json:"respDatabase"`
+	RESPTable    string `yaml:"respTable" json:"respTable"`
+	// TLS enables TLS if the certificate file is set
+	TLS TLSFiles `yaml:"tls" json:"tls"`
+	// Tokens is the API token file, enables token authentication
+	Tokens string `yaml:"tokens" json:"tokens"`
+	// ACL is the JSON access control file
+	ACL string `yaml:"acl" json:"acl"`
+	// Namespaces is the JSON namespace file, enables namespaces
+	Namespaces string `yaml:"namespaces" json:"namespaces"`
+	Limits     Limits `yaml:"limits" json:"limits"`
+	// TransactionTimeout and IteratorTimeout are the idle times after which transactions are rolled back
+	// and iterators are closed
+	TransactionTimeout Duration `yaml:"transactionTimeout" json:"transactionTimeout"`
+	IteratorTimeout    Duration `yaml:"iteratorTimeout" json:"iteratorTimeout"`
+	// GracePeriod is the time open transactions are given to complete on shutdown
+	GracePeriod Duration `yaml:"gracePeriod" json:"gracePeriod"`
+	// MaxRecvMessageSize and MaxSendMessageSize are the maximum grpc message sizes, zero for the grpc default
+	MaxRecvMessageSize int `yaml:"maxRecvMessageSize" json:"maxRecvMessageSize"`
+	MaxSendMessageSize int `yaml:"maxSendMessageSize" json:"maxSendMessageSize"`
+	// LogFile is the file the log is appended to, standard error if empty
+	LogFile string `yaml:"logFile" json:"logFile"`
+	// LogFormat is "text" or "json", and LogLevel is "debug", "info", "warn" or "error", see NewLogger
+	LogFormat string `yaml:"logFormat" json:"logFormat"`
+	LogLevel  string `yaml:"logLevel" json:"logLevel"`
+	// LogKeys logs the keys of requests at debug level, which are otherwise redacted
+	LogKeys bool `yaml:"logKeys" json:"logKeys"`
+	// SlowThreshold is the duration after which a request is recorded in the slow operation log, zero to disable
+	SlowThreshold Duration `yaml:"slowThreshold" json:"slowThreshold"`
+	// SlowLogSize is the number of slow operations retained
+	SlowLogSize int `yaml:"slowLogSize" json:"slowLogSize"`
+	// Replication configures primary/replica replication
+	Replication ReplicationConfig `yaml:"replication" json:"replication"`
+	// Cluster makes the server a member of a cluster if its raft address is set
+	Cluster ClusterConfig `yaml:"cluster" json:"cluster"`
+}
+
+// ReplicationConfig configures replication. A primary retains the last LogSize transactions of each database for
+// its replicas. A replica replicates the server at Primary, connecting to it using TLS if CAFile is set, with the
+// client certificate files and API token if set.
+type ReplicationConfig struct {
+	LogSize  int    `yaml:"logSize" json:"logSize"`
+	Primary  string `yaml:"primary" json:"primary"`
+	CAFile   string `yaml:"ca" json:"ca"`
+	CertFile string `yaml:"cert" json:"cert"`
+	KeyFile  string `yaml:"key" json:"key"`
+	Token    string `yaml:"token" json:"token"`
+}
+
+// clientOptions returns the options used to connect to the primary
+func (cfg *ReplicationConfig) clientOptions() ([]client.Option, error) {
+	var opts []client.Option
+	if cfg.CAFile != "" {
+		pool, err := keydbr.LoadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithCA(pool))
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithClientCertificate(cert))
+	}
+	if cfg.Token != "" {
+		opts = append(opts, client.WithToken(cfg.Token))
+	}
+	return opts, nil
+}
+
+// DefaultConfig returns the configuration used for settings which are not in the configuration file
+func DefaultConfig() *Config {
+	return &Config{Path: "databases", Listen: DefaultAddress, GracePeriod: Duration(DefaultGracePeriod),
+		RESPDatabase: DefaultRESPDatabase, RESPTable: DefaultRESPTable}
+}
+
+// LoadConfig reads a configuration file. Files with a .json extension are read as JSON, otherwise as YAML. Unknown
+// settings are an error in either format.
+func LoadConfig(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := DefaultConfig()
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+		if err == nil && dec.More() {
+			err = errors.New("unexpected data after the configuration")
+		}
+	} else {
+		err = yaml.UnmarshalStrict(data, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewLogger returns the logger for the log settings. The caller passes it to WithLogger, or makes it the default.
+func (cfg *Config) NewLogger() (*slog.Logger, error) {
+	var w io.Writer = os.Stderr
+	if cfg.LogFile != "" {
+		f, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return NewLogger(w, cfg.LogFormat, cfg.LogLevel)
+}
+
+// Options returns the server options for the configuration, loading the files it references
+func (cfg *Config) Options() ([]Option, error) {
+	opts := []Option{
+		WithListenAddress(cfg.Listen),
+		WithMetricsAddress(cfg.Metrics),
+		WithRESTAddress(cfg.REST),
+		WithRESP(cfg.RESP, cfg.RESPDatabase, cfg.RESPTable),
+		WithLimits(cfg.Limits),
+		WithTransactionTimeout(time.Duration(cfg.TransactionTimeout)),
+		WithIteratorTimeout(time.Duration(cfg.IteratorTimeout)),
+		WithGracePeriod(time.Duration(cfg.GracePeriod)),
+		WithMaxMessageSize(cfg.MaxRecvMessageSize, cfg.MaxSendMessageSize),
+		WithLogKeys(cfg.LogKeys),
+		WithSlowThreshold(time.Duration(cfg.SlowThreshold)),
+		WithSlowLogSize(cfg.SlowLogSize),
+		WithReplicationLog(cfg.Replication.LogSize),
+	}
+	if cfg.TLS.CertFile != "" {
+		opts = append(opts, WithTLS(cfg.TLS))
+	} else if cfg.TLS.ClientCAFile != "" {
+		return nil, errors.New("the client CA file requires a certificate and key")
+	}
+	if cfg.Tokens != "" {
+		tokens, err := LoadTokenFile(cfg.Tokens)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAuthenticators(tokens))
+	}
+	if cfg.ACL != "" {
+		acl, err := LoadACL(cfg.ACL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithACL(acl))
+	}
+	if cfg.Namespaces != "" {
+		namespaces, err := LoadNamespaces(cfg.Namespaces)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithNamespaces(namespaces))
+	}
+	if cfg.Replication.Primary != "" {
+		clientOpts, err := cfg.Replication.clientOptions()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithReplicaOf(cfg.Replication.Primary, clientOpts...))
+	}
+	if cfg.Cluster.RaftAddress != "" {
+		if cfg.Replication.Primary != "" || cfg.Replication.LogSize > 0 {
+			return nil, errors.New("a cluster member cannot use replication")
+		}
+		opts = append(opts, WithCluster(cfg.Cluster))
+	}
+	return opts, nil
+}
@@ -0,0 +1,104 @@
+package server_test
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := tempDir(t)
+
+	expected := server.DefaultConfig()
+	expected.Path = "/var/lib/keydbr"
+	expected.Listen = ":9000"
+	expected.TLS = server.TLSFiles{CertFile: "server.crt", KeyFile: "server.key"}
+	expected.Limits = server.Limits{MaxTransactions: 10, RequestsPerSecond: 100.5}
+	expected.TransactionTimeout = server.Duration(time.Minute)
+	expected.MaxRecvMessageSize = 1 << 24
+	expected.Replication = server.ReplicationConfig{Primary: "primary:8501", Token: "secret"}
+
+	yamlFile := filepath.Join(dir, "keydbr.yaml")
+	writeFile(t, yamlFile, []byte(`
+path: /var/lib/keydbr
+listen: ":9000"
+tls:
+  cert: server.crt
+  key: server.key
+limits:
+  maxTransactions: 10
+  requestsPerSecond: 100.5
+transactionTimeout: 1m
+maxRecvMessageSize: 16777216
+replication:
+  primary: primary:8501
+  token: secret
+`))
+	jsonFile := filepath.Join(dir, "keydbr.json")
+	writeFile(t, jsonFile, []byte(`{"path": "/var/lib/keydbr", "listen": ":9000", "tls": {"cert": "server.crt", "key": "server.key"},
+		"limits": {"maxTransactions": 10, "requestsPerSecond": 100.5}, "transactionTimeout": "1m", "maxRecvMessageSize": 16777216,
+		"replication": {"primary": "primary:8501", "token": "secret"}}`))
+
+	for _, file := range []string{yamlFile, jsonFile} {
+		cfg, err := server.LoadConfig(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cfg, expected) {
+			t.Fatalf("wrong config from %s %+v", file, cfg)
+		}
+	}
+
+	writeFile(t, yamlFile, []byte("maxTransactions: 10\n"))
+	if _, err := server.LoadConfig(yamlFile); err == nil {
+		t.Fatal("unknown setting should fail")
+	}
+	writeFile(t, jsonFile, []byte(`{"listen": ":9000", "limits": {"maxTransaction": 10}}`))
+	if _, err := server.LoadConfig(jsonFile); err == nil {
+		t.Fatal("unknown JSON setting should fail")
+	}
+	writeFile(t, jsonFile, []byte(`{"listen": ":9000"} {"path": "/tmp"}`))
+	if _, err := server.LoadConfig(jsonFile); err == nil {
+		t.Fatal("data after the JSON configuration should fail")
+	}
+	writeFile(t, yamlFile, []byte("gracePeriod: 10\n"))
+	if _, err := server.LoadConfig(yamlFile); err == nil {
+		t.Fatal("duration without units should fail")
+	}
+}
+
+func TestListenAndServe(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewServer(tempDir(t), server.WithListener(lis), server.WithGracePeriod(100*time.Millisecond),
+		server.WithLimits(server.Limits{MaxTransactions: 1}))
+
+	served := make(chan error)
+	go func() { served <- srv.ListenAndServe() }()
+
+	db, err := client.Open(lis.Addr().String(), "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.BeginTX("main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.BeginTX("main"); err == nil {
+		t.Fatal("transactions should be limited")
+	}
+
+	summary := srv.Stop()
+	if summary.Connections != 1 || summary.RolledBack != 1 || summary.Databases != 1 {
+		t.Fatalf("wrong summary %+v", summary)
+	}
+	if err = <-served; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ServiceName is the name of the keydbr gRPC service, as used by the health service
+const ServiceName = "remote.Keydb"
+
+// DatabaseService returns the health service name reporting the readiness of a database. A database
+// is not serving if it exists but failed to open.
+func DatabaseService(dbname string) string {
+	return ServiceName + "/" + dbname
+}
+
+// SetServing updates the overall serving status reported by the health service
+func (s *Server) SetServing(serving bool) {
+	s.setHealth("", serving)
+	s.setHealth(ServiceName, serving)
+}
+
+func (s *Server) setHealth(service string, serving bool) {
+	if s.Health == nil {
+		return
+	}
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.Health.SetServingStatus(service, status)
+}
@@ -0,0 +1,74 @@
+package server_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth(t *testing.T) {
+	dir := tempDir(t)
+
+	srv := server.NewServer(dir)
+	srv.Health = health.NewServer()
+	srv.SetServing(false)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterKeydbServer(s, srv)
+	healthpb.RegisterHealthServer(s, srv.Health)
+	go s.Serve(lis)
+	defer s.Stop()
+	addr := lis.Addr().String()
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	hc := healthpb.NewHealthClient(conn)
+
+	check := func(service string, expected healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		resp, err := hc.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status != expected {
+			t.Fatal("wrong status for", service, resp.Status)
+		}
+	}
+
+	check(server.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	srv.SetServing(true)
+	check("", healthpb.HealthCheckResponse_SERVING)
+	check(server.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	check(server.DatabaseService("main"), healthpb.HealthCheckResponse_SERVING)
+
+	// a file in place of the database directory cannot be opened
+	if err = ioutil.WriteFile(filepath.Join(dir, "broken"), []byte("junk"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Open(addr, "broken", true, 10); err == nil {
+		t.Fatal("database should not open")
+	}
+	check(server.DatabaseService("broken"), healthpb.HealthCheckResponse_NOT_SERVING)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"fmt"
+	"github.com/robaho/keydbr"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limits restricts the resources used by clients. A zero value means no limit.
+type Limits struct {
+	// MaxTransactions is the maximum number of open transactions per connection
+	MaxTransactions int `yaml:"maxTransactions" json:"maxTransactions"`
+	// MaxIterators is the maximum number of open iterators per connection
+	MaxIterators int `yaml:"maxIterators" json:"maxIterators"`
+	// MaxInFlightBytes is the maximum size of the keys and values put in uncommitted transactions per connection
+	MaxInFlightBytes int64 `yaml:"maxInFlightBytes" json:"maxInFlightBytes"`
+	// RequestsPerSecond is the sustained rate of requests allowed per identity and database
+	RequestsPerSecond float64 `yaml:"requestsPerSecond" json:"requestsPerSecond"`
+	// Burst is the number of requests allowed above RequestsPerSecond, defaults to RequestsPerSecond, and is at
+	// least one request
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+func resourceExhausted(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{keydbr.ResourceExhausted}, args...)...)
+}
+
+func (l *Limits) checkTransactions(state *connstate) error {
+	if l.MaxTransactions > 0 && len(state.txs) >= l.MaxTransactions {
+		return resourceExhausted("connection is limited to %d open transactions", l.MaxTransactions)
+	}
+	return nil
+}
+
+func (l *Limits) checkIterators(state *connstate) error {
+	if l.MaxIterators > 0 && len(state.itrs) >= l.MaxIterators {
+		return resourceExhausted("connection is limited to %d open iterators", l.MaxIterators)
+	}
+	return nil
+}
+
+func (l *Limits) checkInFlight(state *connstate, size int64) error {
+	if l.MaxInFlightBytes > 0 && state.inflight+size > l.MaxInFlightBytes {
+		return resourceExhausted("connection is limited to %d uncommitted bytes", l.MaxInFlightBytes)
+	}
+	return nil
+}
+
+// tokenBucket is a rate limiter which allows burst requests, refilled at rate per second
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// rate returns the requests per second and the burst of the rate limit, or zero if requests are not limited. The
+// burst defaults to the rate, and is at least one request, so a rate below one request per second is allowed.
+func (l *Limits) rate() (float64, float64) {
+	burst := float64(l.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, l.RequestsPerSecond)
+	}
+	return l.RequestsPerSecond, burst
+}
+
+// checkRate consumes a request from the bucket for the connection's identity and database
+func (s *Server) checkRate(state *connstate) error {
+	rate, burst := s.Limits.rate()
+	if rate <= 0 {
+		return nil
+	}
+
+	name := ""
+	if state.identity != nil {
+		name = state.identity.Name
+	}
+	key := name + "\x00" + state.dbname
+
+	s.limiter.Lock()
+	defer s.limiter.Unlock()
+
+	if s.limiter.buckets == nil {
+		s.limiter.buckets = make(map[string]*tokenBucket)
+	}
+
+	now := time.Now()
+	bucket, ok := s.limiter.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		s.limiter.buckets[key] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * rate
+	if bucket.tokens > burst {
+		bucket.tokens = burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return resourceExhausted("rate limited to %g requests per second", rate)
+	}
+	bucket.tokens--
+	return nil
+}
+
+// pruneRates removes the buckets which have refilled to the burst, since a new bucket is full
+func (s *Server) pruneRates(now time.Time) {
+	rate, burst := s.Limits.rate()
+
+	s.limiter.Lock()
+	defer s.limiter.Unlock()
+
+	for key, bucket := range s.limiter.buckets {
+		if rate <= 0 || bucket.tokens+now.Sub(bucket.last).Seconds()*rate >= burst {
+			delete(s.limiter.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestConnectionLimits(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.Limits = server.Limits{MaxTransactions: 1, MaxIterators: 1, MaxInFlightBytes: 16}
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.BeginTX("main"); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("transactions should be limited", err)
+	}
+
+	if _, err = tx.Lookup(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Lookup(nil, nil); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("iterators should be limited", err)
+	}
+
+	if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.PutSync([]byte("mykey2"), []byte("myvalue2")); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("uncommitted bytes should be limited", err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the committed transaction and its iterator no longer count against the limits
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Lookup(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// an asynchronous put failure is reported by the commit
+	if err = tx.Put([]byte("mykey"), make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("commit should report the async put failure", err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.Limits = server.Limits{RequestsPerSecond: 0.1, Burst: 3}
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Get([]byte("mykey")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Get([]byte("mykey")); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("requests should be rate limited", err)
+	}
+}
+
+func TestFractionalRateLimit(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.Limits = server.Limits{RequestsPerSecond: 0.5}
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the burst defaults to one request, so a rate below one request per second allows requests
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal("a request should be allowed", err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Get([]byte("mykey")); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("requests should be rate limited", err)
+	}
+}
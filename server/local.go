@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"log/slog"
+)
+
+// localSession is a session whose requests are made by the server rather than received on a grpc stream, used by
+// the REST and RESP frontends. The requests are processed as Connection does, so they are subject to the same
+// access control, limits, metrics and logging.
+type localSession struct {
+	s      *Server
+	state  *connstate
+	conn   *meteredConn
+	stream *localStream
+	debug  bool
+	// db is the database opened by open, which remains valid after Shutdown or KillSession closes the session
+	db *openDatabase
+	// closed is set if the session was closed by Shutdown or KillSession, after which all requests fail
+	closed error
+}
+
+// localStream is the stream of a local session, which holds the reply to the last request
+type localStream struct {
+	pb.Keydb_ConnectionServer
+	ctx   context.Context
+	reply *pb.OutMessage
+}
+
+func (l *localStream) Send(msg *pb.OutMessage) error {
+	l.reply = msg
+	return nil
+}
+
+func (l *localStream) Context() context.Context {
+	return l.ctx
+}
+
+// newLocalSession registers a session for a client connected using protocol, see endSession
+func (s *Server) newLocalSession(ctx context.Context, protocol string) *localSession {
+	stream := &localStream{ctx: ctx}
+	return &localSession{
+		s:      s,
+		state:  s.newSession(ctx, protocol),
+		conn:   &meteredConn{Keydb_ConnectionServer: stream, m: s.metrics},
+		stream: stream,
+		debug:  s.logger.Enabled(ctx, slog.LevelDebug),
+	}
+}
+
+// end closes the session's database, rolling back its open transactions
+func (ls *localSession) end() {
+	ls.s.endSession(ls.state)
+}
+
+// call processes a request, returning the reply and the error it contains
+func (ls *localSession) call(msg *pb.InMessage) (*pb.OutMessage, error) {
+	if ls.closed != nil {
+		return nil, ls.closed
+	}
+	ls.stream.reply = nil
+	if err := ls.s.process(ls.conn, ls.state, msg, ls.debug); err != nil {
+		ls.closed = err
+		return nil, err
+	}
+	if ls.stream.reply == nil {
+		return nil, errors.New("no reply")
+	}
+	return ls.stream.reply, parseReplyError(replyError(ls.stream.reply))
+}
+
+// parseReplyError converts the error in a reply back into an error, recognizing the keydb errors handled by the
+// frontends
+func parseReplyError(msg string) error {
+	for _, err := range []error{keydb.KeyNotFound, keydb.NoDatabaseFound, keydb.EndOfIterator} {
+		if msg == err.Error() {
+			return err
+		}
+	}
+	return keydbr.ParseError(msg)
+}
+
+func (ls *localSession) open(dbname string, create bool) error {
+	_, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Open{Open: &pb.OpenRequest{Dbname: dbname, Create: create}}})
+	if err == nil {
+		ls.state.Lock()
+		if ls.db = ls.state.db; ls.db == nil {
+			err = ls.state.closed // closed since it was opened
+		}
+		ls.state.Unlock()
+	}
+	return err
+}
+
+// close closes the session's database, which remains registered until end
+func (ls *localSession) close() error {
+	_, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Close{Close: &pb.CloseRequest{}}})
+	return err
+}
+
+func (ls *localSession) begin(table string) (uint64, error) {
+	reply, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Begin{Begin: &pb.BeginRequest{Table: table}}})
+	if err != nil {
+		return 0, err
+	}
+	return reply.GetBegin().Txid, nil
+}
+
+// commit commits the transaction synchronously
+func (ls *localSession) commit(txid uint64) error {
+	_, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Commit{Commit: &pb.CommitRequest{Txid: txid, Sync: true}}})
+	return err
+}
+
+func (ls *localSession) rollback(txid uint64) error {
+	_, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Rollback{Rollback: &pb.RollbackRequest{Txid: txid}}})
+	return err
+}
+
+func (ls *localSession) get(txid uint64, key []byte) ([]byte, error) {
+	reply, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Get{Get: &pb.GetRequest{Txid: txid, Key: key}}})
+	if err != nil {
+		return nil, err
+	}
+	return reply.GetGet().Value, nil
+}
+
+func (ls *localSession) put(txid uint64, key, value []byte) error {
+	_, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Put{Put: &pb.PutRequest{Txid: txid, Key: key, Value: value, Sync: true}}})
+	return err
+}
+
+// delete removes a key, returning its previous value
+func (ls *localSession) delete(txid uint64, key []byte) ([]byte, error) {
+	reply, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Delete{Delete: &pb.DeleteRequest{Txid: txid, Key: key}}})
+	if err != nil {
+		return nil, err
+	}
+	return reply.GetDelete().Value, nil
+}
+
+// each calls fn with the entries from lower to upper inclusive, until fn returns false
+func (ls *localSession) each(txid uint64, lower, upper []byte, fn func(key, value []byte) bool) error {
+	reply, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Lookup{Lookup: &pb.LookupRequest{Txid: txid, Lower: lower, Upper: upper}}})
+	if err != nil {
+		return err
+	}
+	id := reply.GetLookup().Id
+	for {
+		reply, err := ls.call(&pb.InMessage{Request: &pb.InMessage_Next{Next: &pb.LookupNextRequest{Id: id}}})
+		if errors.Is(err, keydb.EndOfIterator) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, kv := range reply.GetNext().Entries {
+			if !fn(kv.Key, kv.Value) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"google.golang.org/grpc/peer"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// NewLogger returns a logger writing to w in format "text" or "json", at level "debug", "info", "warn" or
+// "error". Empty values use the text format at info level.
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, err
+		}
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// peerAddr returns the address of the client, or an empty string if unknown
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// connLogger returns the logger for a connection, identifying the connection, peer, caller and protocol
+func (s *Server) connLogger(state *connstate, protocol string) *slog.Logger {
+	attrs := []any{"conn", state.id}
+	if state.peer != "" {
+		attrs = append(attrs, "peer", state.peer)
+	}
+	if state.identity != nil {
+		attrs = append(attrs, "identity", state.identity.Name)
+	}
+	attrs = append(attrs, "protocol", protocol)
+	return s.logger.With(attrs...)
+}
+
+// log returns the connection logger, including the open database
+func (state *connstate) log() *slog.Logger {
+	if state.db == nil {
+		return state.logger
+	}
+	return state.logger.With("database", state.dbname)
+}
+
+// logKey returns the key for logging, which is redacted to its length unless the server logs keys
+func (s *Server) logKey(key []byte) slog.Value {
+	if !s.logKeys {
+		return slog.StringValue(fmt.Sprintf("[%d bytes]", len(key)))
+	}
+	quoted := strconv.Quote(string(key))
+	return slog.StringValue(quoted[1 : len(quoted)-1])
+}
+
+// requestAttrs returns the request fields for logging. Values are never logged, only their size.
+func (s *Server) requestAttrs(msg *pb.InMessage) []any {
+	switch req := msg.Request.(type) {
+	case *pb.InMessage_Open:
+		return []any{"dbname", req.Open.Dbname, "create", req.Open.Create}
+	case *pb.InMessage_Begin:
+		return []any{"table", req.Begin.Table}
+	case *pb.InMessage_Commit:
+		return []any{"txid", req.Commit.Txid, "sync", req.Commit.Sync}
+	case *pb.InMessage_Rollback:
+		return []any{"txid", req.Rollback.Txid}
+	case *pb.InMessage_Get:
+		return []any{"txid", req.Get.Txid, "key", s.logKey(req.Get.Key)}
+	case *pb.InMessage_Put:
+		return []any{"txid", req.Put.Txid, "key", s.logKey(req.Put.Key), "size", len(req.Put.Value), "sync", req.Put.Sync}
+	case *pb.InMessage_Delete:
+		return []any{"txid", req.Delete.Txid, "key", s.logKey(req.Delete.Key)}
+	case *pb.InMessage_Lookup:
+		return []any{"txid", req.Lookup.Txid, "lower", s.logKey(req.Lookup.Lower), "upper", s.logKey(req.Lookup.Upper)}
+	case *pb.InMessage_Next:
+		return []any{"id", req.Next.Id}
+	}
+	return nil
+}
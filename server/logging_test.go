@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+// logBuffer collects the log output of a server
+type logBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+// wait returns the log once it contains s, the request logs are written after the reply is sent
+func (b *logBuffer) wait(t *testing.T, s string) string {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(10 * time.Millisecond) {
+		b.Lock()
+		log := b.buf.String()
+		b.Unlock()
+		if strings.Contains(log, s) {
+			return log
+		}
+	}
+	t.Fatalf("log does not contain %s", s)
+	return ""
+}
+
+func TestLogging(t *testing.T) {
+	for _, logKeys := range []bool{false, true} {
+		var buf logBuffer
+		logger, err := server.NewLogger(&buf, "json", "debug")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := server.NewServer(tempDir(t), server.WithLogger(logger), server.WithLogKeys(logKeys))
+		addr := startServer(t, srv)
+
+		db, err := client.Open(addr, "main", true, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := db.BeginTX("main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err = db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		log := buf.wait(t, `"msg":"connection closed"`)
+		for _, s := range []string{`"msg":"opened database","conn":1,"peer":"127.0.0.1:`, `"database":"main","type":"put"`, `"size":7`} {
+			if !strings.Contains(log, s) {
+				t.Fatal("log should contain", s, log)
+			}
+		}
+		if strings.Contains(log, "myvalue") {
+			t.Fatal("values should not be logged", log)
+		}
+		if strings.Contains(log, "mykey") != logKeys {
+			t.Fatal("keys should only be logged if enabled", log)
+		}
+	}
+
+	if _, err := server.NewLogger(&bytes.Buffer{}, "xml", ""); err == nil {
+		t.Fatal("unknown format should fail")
+	}
+	if _, err := server.NewLogger(&bytes.Buffer{}, "", "verbose"); err == nil {
+		t.Fatal("unknown level should fail")
+	}
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// upper bounds in seconds of the request latency histogram buckets
+var latencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 5}
+
+var requestTypes = []string{"open", "close", "begin", "commit", "rollback", "get", "put", "delete", "lookup", "next"}
+
+type requestMetrics struct {
+	count   uint64 // must be first for atomic alignment
+	errors  uint64
+	nanos   uint64
+	buckets []uint64
+}
+
+type metrics struct {
+	bytesIn          uint64
+	bytesOut         uint64
+	asyncPutFailures uint64
+	expiredTxs       uint64
+	expiredIterators uint64
+	requests         map[string]*requestMetrics
+}
+
+func newMetrics() *metrics {
+	m := &metrics{requests: make(map[string]*requestMetrics)}
+	for _, t := range requestTypes {
+		m.requests[t] = &requestMetrics{buckets: make([]uint64, len(latencyBuckets))}
+	}
+	return m
+}
+
+func requestType(msg *pb.InMessage) string {
+	switch msg.Request.(type) {
+	case *pb.InMessage_Open:
+		return "open"
+	case *pb.InMessage_Close:
+		return "close"
+	case *pb.InMessage_Begin:
+		return "begin"
+	case *pb.InMessage_Commit:
+		return "commit"
+	case *pb.InMessage_Rollback:
+		return "rollback"
+	case *pb.InMessage_Get:
+		return "get"
+	case *pb.InMessage_Put:
+		return "put"
+	case *pb.InMessage_Delete:
+		return "delete"
+	case *pb.InMessage_Lookup:
+		return "lookup"
+	case *pb.InMessage_Next:
+		return "next"
+	}
+	return ""
+}
+
+func (m *metrics) observe(requestType string, duration time.Duration, failed bool) {
+	rm, ok := m.requests[requestType]
+	if !ok {
+		return
+	}
+	atomic.AddUint64(&rm.count, 1)
+	if failed {
+		atomic.AddUint64(&rm.errors, 1)
+	}
+	atomic.AddUint64(&rm.nanos, uint64(duration.Nanoseconds()))
+	seconds := duration.Seconds()
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			atomic.AddUint64(&rm.buckets[i], 1)
+			break
+		}
+	}
+}
+
+// asyncPutFailed records an asynchronous put failure, which has no reply
+func (m *metrics) asyncPutFailed() {
+	atomic.AddUint64(&m.asyncPutFailures, 1)
+	atomic.AddUint64(&m.requests["put"].errors, 1)
+}
+
+func replyError(msg *pb.OutMessage) string {
+	switch reply := msg.Reply.(type) {
+	case *pb.OutMessage_Open:
+		return reply.Open.GetError()
+	case *pb.OutMessage_Close:
+		return reply.Close.GetError()
+	case *pb.OutMessage_Begin:
+		return reply.Begin.GetError()
+	case *pb.OutMessage_Commit:
+		return reply.Commit.GetError()
+	case *pb.OutMessage_Rollback:
+		return reply.Rollback.GetError()
+	case *pb.OutMessage_Get:
+		return reply.Get.GetError()
+	case *pb.OutMessage_Put:
+		return reply.Put.GetError()
+	case *pb.OutMessage_Delete:
+		return reply.Delete.GetError()
+	case *pb.OutMessage_Lookup:
+		return reply.Lookup.GetError()
+	case *pb.OutMessage_Next:
+		return reply.Next.GetError()
+	}
+	return ""
+}
+
+// meteredConn counts the bytes sent and received on a connection, and records the error and the number of
+// entries in a reply
+type meteredConn struct {
+	pb.Keydb_ConnectionServer
+	m       *metrics
+	errmsg  string
+	entries int
+}
+
+func (c *meteredConn) Send(msg *pb.OutMessage) error {
+	atomic.AddUint64(&c.m.bytesOut, uint64(proto.Size(msg)))
+	if errmsg := replyError(msg); errmsg != "" {
+		c.errmsg = errmsg
+	}
+	if next := msg.GetNext(); next != nil {
+		c.entries = len(next.Entries)
+	}
+	return c.Keydb_ConnectionServer.Send(msg)
+}
+
+func (c *meteredConn) Recv() (*pb.InMessage, error) {
+	msg, err := c.Keydb_ConnectionServer.Recv()
+	if err == nil {
+		atomic.AddUint64(&c.m.bytesIn, uint64(proto.Size(msg)))
+	}
+	return msg, err
+}
+
+// updateCounts publishes the number of open transactions and iterators, so they can be read without
+// locking the connection
+func (state *connstate) updateCounts() {
+	atomic.StoreInt64(&state.ntxs, int64(len(state.txs)))
+	atomic.StoreInt64(&state.nitrs, int64(len(state.itrs)))
+}
+
+// WriteMetrics writes the server metrics in the Prometheus text format
+func (s *Server) WriteMetrics(out io.Writer) error {
+	w := bufio.NewWriter(out)
+
+	header := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	header("keydbr_requests_total", "counter", "Requests processed by message type.")
+	for _, t := range requestTypes {
+		fmt.Fprintf(w, "keydbr_requests_total{type=%q} %d\n", t, atomic.LoadUint64(&s.metrics.requests[t].count))
+	}
+	header("keydbr_request_errors_total", "counter", "Requests which returned an error by message type.")
+	for _, t := range requestTypes {
+		fmt.Fprintf(w, "keydbr_request_errors_total{type=%q} %d\n", t, atomic.LoadUint64(&s.metrics.requests[t].errors))
+	}
+	header("keydbr_request_duration_seconds", "histogram", "Request latency by message type.")
+	for _, t := range requestTypes {
+		rm := s.metrics.requests[t]
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += atomic.LoadUint64(&rm.buckets[i])
+			fmt.Fprintf(w, "keydbr_request_duration_seconds_bucket{type=%q,le=%q} %d\n", t, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		count := atomic.LoadUint64(&rm.count)
+		fmt.Fprintf(w, "keydbr_request_duration_seconds_bucket{type=%q,le=\"+Inf\"} %d\n", t, count)
+		fmt.Fprintf(w, "keydbr_request_duration_seconds_sum{type=%q} %g\n", t, time.Duration(atomic.LoadUint64(&rm.nanos)).Seconds())
+		fmt.Fprintf(w, "keydbr_request_duration_seconds_count{type=%q} %d\n", t, count)
+	}
+
+	header("keydbr_received_bytes_total", "counter", "Bytes received in connection messages.")
+	fmt.Fprintf(w, "keydbr_received_bytes_total %d\n", atomic.LoadUint64(&s.metrics.bytesIn))
+	header("keydbr_sent_bytes_total", "counter", "Bytes sent in connection messages.")
+	fmt.Fprintf(w, "keydbr_sent_bytes_total %d\n", atomic.LoadUint64(&s.metrics.bytesOut))
+	header("keydbr_async_put_failures_total", "counter", "Asynchronous puts which failed.")
+	fmt.Fprintf(w, "keydbr_async_put_failures_total %d\n", atomic.LoadUint64(&s.metrics.asyncPutFailures))
+	header("keydbr_expired_transactions_total", "counter", "Idle transactions rolled back by the server.")
+	fmt.Fprintf(w, "keydbr_expired_transactions_total %d\n", atomic.LoadUint64(&s.metrics.expiredTxs))
+	header("keydbr_expired_iterators_total", "counter", "Idle iterators closed by the server.")
+	fmt.Fprintf(w, "keydbr_expired_iterators_total %d\n", atomic.LoadUint64(&s.metrics.expiredIterators))
+
+	s.Lock()
+	refcounts := make(map[string]int)
+	for _, opendb := range s.opendb {
+		refcounts[opendb.name] = opendb.refcount
+	}
+	s.Unlock()
+
+	names := make([]string, 0, len(refcounts))
+	for name := range refcounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	header("keydbr_open_databases", "gauge", "Databases currently open.")
+	fmt.Fprintf(w, "keydbr_open_databases %d\n", len(names))
+	header("keydbr_database_refcount", "gauge", "Connections using each open database.")
+	for _, name := range names {
+		fmt.Fprintf(w, "keydbr_database_refcount{database=%q} %d\n", name, refcounts[name])
+	}
+
+	sessions := s.sessions.list()
+	var txs, itrs int64
+	for _, state := range sessions {
+		txs += atomic.LoadInt64(&state.ntxs)
+		itrs += atomic.LoadInt64(&state.nitrs)
+	}
+	header("keydbr_connections", "gauge", "Open connections.")
+	fmt.Fprintf(w, "keydbr_connections %d\n", len(sessions))
+	header("keydbr_open_transactions", "gauge", "Open transactions.")
+	fmt.Fprintf(w, "keydbr_open_transactions %d\n", txs)
+	header("keydbr_open_iterators", "gauge", "Open iterators.")
+	fmt.Fprintf(w, "keydbr_open_iterators %d\n", itrs)
+
+	return w.Flush()
+}
+
+// MetricsHandler serves the server metrics in the Prometheus text format
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.WriteMetrics(w)
+	})
+}
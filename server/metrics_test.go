@@ -0,0 +1,59 @@
+package server_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	tx.PutSync([]byte("mykey"), []byte("myvalue"))
+	tx.Put(nil, []byte("empty keys fail"))
+	tx.Get([]byte("mykey"))
+	tx.Get([]byte("missing"))
+
+	var buf bytes.Buffer
+	if err = srv.WriteMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+	metrics := buf.String()
+
+	expected := []string{
+		`keydbr_requests_total{type="get"} 2`,
+		`keydbr_request_errors_total{type="get"} 1`,
+		`keydbr_request_duration_seconds_count{type="get"} 2`,
+		`keydbr_request_duration_seconds_bucket{type="put",le="+Inf"} 2`,
+		`keydbr_request_errors_total{type="put"} 1`,
+		`keydbr_async_put_failures_total 1`,
+		`keydbr_open_databases 1`,
+		`keydbr_database_refcount{database="main"} 1`,
+		`keydbr_connections 1`,
+		`keydbr_open_transactions 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Error("missing", line)
+		}
+	}
+	if t.Failed() {
+		t.Log(metrics)
+	}
+}
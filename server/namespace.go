@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/robaho/keydbr"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Namespace is a subdirectory of the server path which holds the databases of the identities bound to it.
+// A caller bound to namespace "team1" opening database "mydb" opens team1/mydb on the server.
+type Namespace struct {
+	Name string `json:"name"`
+	// Identities are the callers bound to this namespace. "*" binds all identities not bound elsewhere.
+	Identities []string `json:"identities"`
+	// MaxDatabases is the maximum number of databases in the namespace, or 0 for no limit
+	MaxDatabases int `json:"maxDatabases"`
+	// MaxDiskUsage is the maximum total size in bytes of the databases in the namespace, or 0 for no limit
+	MaxDiskUsage int64 `json:"maxDiskUsage"`
+
+	usage namespaceUsage
+	// creating is the number of databases being created, which count towards MaxDatabases until they are on disk.
+	// It is guarded by the server's lock.
+	creating int
+}
+
+type namespaceUsage struct {
+	sync.Mutex
+	bytes   int64
+	updated time.Time
+}
+
+// how long a disk usage calculation is reused before the namespace is scanned again
+const usageInterval = time.Second
+
+// Namespaces binds identities to namespaces
+type Namespaces struct {
+	Namespaces []*Namespace `json:"namespaces"`
+}
+
+// LoadNamespaces reads the namespace configuration from a JSON file, e.g.
+// {"namespaces":[{"name":"team1","identities":["alice","bob"],"maxDatabases":10,"maxDiskUsage":1073741824}]}
+func LoadNamespaces(file string) (*Namespaces, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var namespaces Namespaces
+	if err := json.Unmarshal(data, &namespaces); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	for _, ns := range namespaces.Namespaces {
+		if err := keydbr.ValidateTableName(ns.Name); err != nil {
+			return nil, fmt.Errorf("%s: invalid namespace name %q", file, ns.Name)
+		}
+	}
+	return &namespaces, nil
+}
+
+// Lookup returns the namespace bound to id, or nil
+func (n *Namespaces) Lookup(id *Identity) *Namespace {
+	name := ""
+	if id != nil {
+		name = id.Name
+	}
+	var def *Namespace
+	for _, ns := range n.Namespaces {
+		for _, identity := range ns.Identities {
+			if identity == name {
+				return ns
+			} else if identity == "*" && def == nil {
+				def = ns
+			}
+		}
+	}
+	return def
+}
+
+// resolve maps the database name used by a caller to the name used by the server, and the directory
+// of the database
+func (s *Server) resolve(id *Identity, dbname string) (string, string, *Namespace, error) {
+	if err := keydbr.ValidateDatabaseName(dbname); err != nil {
+		return "", "", nil, err
+	}
+	ns, err := s.namespace(id)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if ns == nil {
+		return dbname, filepath.Join(s.path, dbname), nil, nil
+	}
+	qualified := ns.Name + "/" + dbname
+	return qualified, filepath.Join(s.path, qualified), ns, nil
+}
+
+// namespace returns the namespace of the caller, or nil if namespaces are not configured
+func (s *Server) namespace(id *Identity) (*Namespace, error) {
+	if s.Namespaces == nil {
+		return nil, nil
+	}
+	ns := s.Namespaces.Lookup(id)
+	if ns == nil {
+		name := "anonymous"
+		if id != nil {
+			name = id.Name
+		}
+		return nil, fmt.Errorf("%w: %s is not bound to a namespace", keydbr.PermissionDenied, name)
+	}
+	return ns, nil
+}
+
+// checkCreate verifies that a new database can be created in the namespace. The caller must hold the lock, and on
+// success must call created once the database is created or fails to be.
+func (s *Server) checkCreate(ns *Namespace) error {
+	if ns == nil {
+		return nil
+	}
+	if ns.MaxDatabases > 0 {
+		dbs, err := s.listDatabases(filepath.Join(s.path, ns.Name))
+		if err != nil {
+			return err
+		}
+		if len(dbs)+ns.creating >= ns.MaxDatabases {
+			return fmt.Errorf("%w: namespace %s is limited to %d databases", keydbr.QuotaExceeded, ns.Name, ns.MaxDatabases)
+		}
+	}
+	if err := s.checkDiskUsage(ns); err != nil {
+		return err
+	}
+	ns.creating++
+	return nil
+}
+
+// created releases the reservation made by checkCreate. The caller must hold the lock.
+func (ns *Namespace) created() {
+	if ns != nil {
+		ns.creating--
+	}
+}
+
+// checkDiskUsage verifies that the namespace is below its disk quota. The usage is recalculated at most once per usageInterval.
+func (s *Server) checkDiskUsage(ns *Namespace) error {
+	if ns == nil || ns.MaxDiskUsage == 0 {
+		return nil
+	}
+
+	ns.usage.Lock()
+	defer ns.usage.Unlock()
+
+	if time.Since(ns.usage.updated) > usageInterval {
+		bytes, err := diskUsage(filepath.Join(s.path, ns.Name))
+		if err != nil {
+			return err
+		}
+		ns.usage.bytes, ns.usage.updated = bytes, time.Now()
+	}
+	if ns.usage.bytes >= ns.MaxDiskUsage {
+		return fmt.Errorf("%w: namespace %s is limited to %d bytes", keydbr.QuotaExceeded, ns.Name, ns.MaxDiskUsage)
+	}
+	return nil
+}
+
+func diskUsage(dir string) (int64, error) {
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// listDatabases returns the names of the databases under dir, relative to dir. A database is a directory
+// containing files.
+// listDatabases returns the databases in dir, except those the cluster removed while they were open. The caller
+// must hold the lock.
+func (s *Server) listDatabases(dir string) ([]string, error) {
+	all, err := listDatabases(dir)
+	var dbs []string
+	for _, db := range all {
+		if !s.removing(filepath.Join(dir, filepath.FromSlash(db))) {
+			dbs = append(dbs, db)
+		}
+	}
+	return dbs, err
+}
+
+func listDatabases(dir string) ([]string, error) {
+	var dbs []string
+	found := make(map[string]bool)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			db, _ := filepath.Rel(dir, filepath.Dir(path))
+			if db != "." && !found[db] {
+				found[db] = true
+				dbs = append(dbs, filepath.ToSlash(db))
+			}
+		}
+		return nil
+	})
+	sort.Strings(dbs)
+	return dbs, err
+}
@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc"
+)
+
+// startServer serves srv on a local port, returning the address
+func startServer(t *testing.T, srv *server.Server, opts ...grpc.ServerOption) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(opts...)
+	pb.RegisterKeydbServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "keydbr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestNamespaces(t *testing.T) {
+	dir := tempDir(t)
+
+	srv := server.NewServer(dir)
+	srv.Namespaces = &server.Namespaces{Namespaces: []*server.Namespace{
+		{Name: "team1", Identities: []string{"*"}, MaxDatabases: 1},
+	}}
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "db1", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "team1", "db1")); err != nil {
+		t.Fatal("database not created in namespace", err)
+	}
+
+	// a file beside the databases is not a database, and does not hide them
+	writeFile(t, filepath.Join(dir, "team1", "a.txt"), nil)
+	dbs, err := client.List(addr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dbs, []string{"db1"}) {
+		t.Fatal("wrong databases listed", dbs)
+	}
+
+	_, err = client.Open(addr, "db2", true, 10)
+	if !errors.Is(err, keydbr.QuotaExceeded) {
+		t.Fatal("database count quota should be exceeded", err)
+	}
+
+	if err = client.Remove(addr, "db1", 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "team1", "db1")); !os.IsNotExist(err) {
+		t.Fatal("database not removed from namespace", err)
+	}
+}
+
+func TestDiskQuota(t *testing.T) {
+	dir := tempDir(t)
+
+	srv := server.NewServer(dir)
+	srv.Namespaces = &server.Namespaces{Namespaces: []*server.Namespace{
+		{Name: "team1", Identities: []string{"*"}, MaxDiskUsage: 1024},
+	}}
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "db1", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// simulate the database growing beyond the quota, and wait for the cached usage to expire
+	if err = ioutil.WriteFile(filepath.Join(dir, "team1", "db1", "large"), make([]byte, 2048), 0600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); !errors.Is(err, keydbr.QuotaExceeded) {
+		t.Fatal("disk quota should be exceeded", err)
+	}
+}
+
+func TestNotBound(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.Namespaces = &server.Namespaces{Namespaces: []*server.Namespace{
+		{Name: "team1", Identities: []string{"alice"}},
+	}}
+	addr := startServer(t, srv)
+
+	_, err := client.Open(addr, "db1", true, 10)
+	if !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("anonymous caller should not be bound to a namespace", err)
+	}
+	_, err = client.List(addr, 10)
+	if !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("anonymous caller should not be bound to a namespace", err)
+	}
+}
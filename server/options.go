@@ -0,0 +1,189 @@
+package server
+
+import (
+	"github.com/robaho/keydbr/client"
+	"google.golang.org/grpc/health"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// DefaultAddress is the address ListenAndServe listens on if not configured
+const DefaultAddress = ":8501"
+
+// DefaultGracePeriod is the time Stop gives open transactions to complete if not configured
+const DefaultGracePeriod = 10 * time.Second
+
+// Option configures a Server, see NewServer
+type Option func(*Server)
+
+// WithListenAddress sets the tcp address ListenAndServe listens on
+func WithListenAddress(addr string) Option {
+	return func(s *Server) {
+		s.addr = addr
+	}
+}
+
+// WithListener sets the listener ListenAndServe accepts connections on, instead of the listen address
+func WithListener(lis net.Listener) Option {
+	return func(s *Server) {
+		s.listener = lis
+	}
+}
+
+// WithTLS enables TLS using the certificate files. If files.ClientCAFile is set, clients must present a
+// certificate, and are identified by its common name.
+func WithTLS(files TLSFiles) Option {
+	return func(s *Server) {
+		s.tlsFiles = &files
+	}
+}
+
+// WithAuthenticators authenticates callers using the first Authenticator which recognizes their credentials
+func WithAuthenticators(auth ...Authenticator) Option {
+	return func(s *Server) {
+		s.auth = append(s.auth, auth...)
+	}
+}
+
+// WithACL restricts access to databases and tables
+func WithACL(acl *ACL) Option {
+	return func(s *Server) {
+		s.ACL = acl
+	}
+}
+
+// WithNamespaces places the databases of each caller in the subdirectory of their namespace
+func WithNamespaces(namespaces *Namespaces) Option {
+	return func(s *Server) {
+		s.Namespaces = namespaces
+	}
+}
+
+// WithLimits restricts the resources used by each connection and identity
+func WithLimits(limits Limits) Option {
+	return func(s *Server) {
+		s.Limits = limits
+	}
+}
+
+// WithTransactionTimeout rolls back transactions which are idle for longer than timeout
+func WithTransactionTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.TransactionTimeout = timeout
+	}
+}
+
+// WithIteratorTimeout closes iterators which are idle for longer than timeout
+func WithIteratorTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.IteratorTimeout = timeout
+	}
+}
+
+// WithGracePeriod sets the time Stop gives open transactions to complete
+func WithGracePeriod(grace time.Duration) Option {
+	return func(s *Server) {
+		s.grace = grace
+	}
+}
+
+// WithMaxMessageSize sets the maximum size in bytes of the grpc messages received and sent. Zero uses the
+// grpc default.
+func WithMaxMessageSize(recv, send int) Option {
+	return func(s *Server) {
+		s.maxRecvMsgSize = recv
+		s.maxSendMsgSize = send
+	}
+}
+
+// WithMetricsAddress serves the metrics at /metrics on the http address
+func WithMetricsAddress(addr string) Option {
+	return func(s *Server) {
+		s.metricsAddr = addr
+	}
+}
+
+// WithRESTAddress serves the REST gateway on the http address, see RESTHandler. It uses TLS if the server does.
+func WithRESTAddress(addr string) Option {
+	return func(s *Server) {
+		s.restAddr = addr
+	}
+}
+
+// WithRESP serves the Redis protocol on the tcp address, see ServeRESP. The keys are stored in table of database
+// dbname, or DefaultRESPTable of DefaultRESPDatabase if empty. It uses TLS if the server does.
+func WithRESP(addr, dbname, table string) Option {
+	return func(s *Server) {
+		s.respAddr = addr
+		if dbname != "" {
+			s.respDatabase = dbname
+		}
+		if table != "" {
+			s.respTable = table
+		}
+	}
+}
+
+// WithReplicationLog retains the last size transactions committed to each database, so replicas can follow this
+// server, see Replicate. Zero, the default, disables replication.
+func WithReplicationLog(size int) Option {
+	return func(s *Server) {
+		s.replicationLogSize = size
+	}
+}
+
+// WithReplicaOf makes the server a read only replica of the server at primary, connecting to it with opts, until it
+// is promoted. Replication starts with ListenAndServe or StartReplication.
+func WithReplicaOf(primary string, opts ...client.Option) Option {
+	return func(s *Server) {
+		s.replica = &replica{primary: primary, opts: opts, dbs: make(map[string]*replicaDatabase)}
+	}
+}
+
+// WithCluster makes the server a member of the cluster configured by config. Only the leader serves the databases,
+// committing each transaction through the replicated log, and the other members reply with keydbr.NotLeader. The
+// member joins the cluster with ListenAndServe or StartCluster.
+func WithCluster(config ClusterConfig) Option {
+	return func(s *Server) {
+		s.cluster = &cluster{config: config, notify: make(chan bool, 1)}
+		s.cluster.fsm = &fsm{s: s, dbs: make(map[string]*openDatabase)}
+	}
+}
+
+// WithHealth sets the health service which receives the serving status of the server and of each database
+func WithHealth(hs *health.Server) Option {
+	return func(s *Server) {
+		s.Health = hs
+	}
+}
+
+// WithLogger sets the logger used by the server, the default is slog.Default, see NewLogger
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithLogKeys logs the keys of requests at debug level, which are otherwise redacted to their length. Values are
+// never logged.
+func WithLogKeys(logKeys bool) Option {
+	return func(s *Server) {
+		s.logKeys = logKeys
+	}
+}
+
+// WithSlowThreshold records the get, put, commit, lookup and next requests taking at least threshold in the slow
+// operation log, see SlowOps. Zero disables the slow operation log.
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(s *Server) {
+		s.slowThreshold = threshold
+	}
+}
+
+// WithSlowLogSize sets the number of slow operations retained, the default is DefaultSlowLogSize
+func WithSlowLogSize(size int) Option {
+	return func(s *Server) {
+		s.slowLogSize = size
+	}
+}
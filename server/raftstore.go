@@ -0,0 +1,248 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/hashicorp/go-msgpack/v2/codec"
+	"github.com/hashicorp/raft"
+	"github.com/robaho/keydb"
+	"sync"
+)
+
+// the tables of the raft store
+const raftLogTable = "logs"
+const raftStableTable = "stable"
+
+var errKeyNotFound = errors.New("not found")
+
+// raftStore is the raft log and stable store of a cluster member, kept in a keydb database. The indexes of the
+// first and last log entries are read when the store is opened, since keydb cannot iterate in reverse.
+type raftStore struct {
+	sync.Mutex
+	db          *keydb.Database
+	first, last uint64
+}
+
+var _ raft.LogStore = (*raftStore)(nil)
+var _ raft.StableStore = (*raftStore)(nil)
+
+func openRaftStore(path string) (*raftStore, error) {
+	db, err := keydb.Open(path, true)
+	if err != nil {
+		return nil, err
+	}
+	store := &raftStore{db: db}
+	if err := store.readIndexes(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// readIndexes reads the indexes of the first and last log entries
+func (rs *raftStore) readIndexes() error {
+	tx, err := rs.db.BeginTX(raftLogTable)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		return err
+	}
+	for {
+		key, _, err := itr.Next()
+		if err == keydb.EndOfIterator {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rs.first == 0 {
+			rs.first = binary.BigEndian.Uint64(key)
+		}
+		rs.last = binary.BigEndian.Uint64(key)
+	}
+}
+
+// msgpack encodes the log entries as raft encodes its messages, and raft-boltdb its entries
+var msgpack = &codec.MsgpackHandle{}
+
+func encodeLog(log *raft.Log) ([]byte, error) {
+	var value []byte
+	err := codec.NewEncoderBytes(&value, msgpack).Encode(log)
+	return value, err
+}
+
+func decodeLog(value []byte, log *raft.Log) error {
+	return codec.NewDecoderBytes(value, msgpack).Decode(log)
+}
+
+func (rs *raftStore) Close() error {
+	return rs.db.Close()
+}
+
+func indexKey(index uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, index)
+	return key
+}
+
+func (rs *raftStore) FirstIndex() (uint64, error) {
+	rs.Lock()
+	defer rs.Unlock()
+	return rs.first, nil
+}
+
+func (rs *raftStore) LastIndex() (uint64, error) {
+	rs.Lock()
+	defer rs.Unlock()
+	return rs.last, nil
+}
+
+func (rs *raftStore) GetLog(index uint64, log *raft.Log) error {
+	value, err := rs.get(raftLogTable, indexKey(index))
+	if err == errKeyNotFound {
+		return raft.ErrLogNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return decodeLog(value, log)
+}
+
+func (rs *raftStore) StoreLog(log *raft.Log) error {
+	return rs.StoreLogs([]*raft.Log{log})
+}
+
+func (rs *raftStore) StoreLogs(logs []*raft.Log) error {
+	rs.Lock()
+	defer rs.Unlock()
+
+	tx, err := rs.db.BeginTX(raftLogTable)
+	if err != nil {
+		return err
+	}
+	for _, log := range logs {
+		value, err := encodeLog(log)
+		if err == nil {
+			err = tx.Put(indexKey(log.Index), value)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.CommitSync(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, log := range logs {
+		if rs.first == 0 || log.Index < rs.first {
+			rs.first = log.Index
+		}
+		if log.Index > rs.last {
+			rs.last = log.Index
+		}
+	}
+	return nil
+}
+
+// DeleteRange removes the entries from min to max inclusive, which are either the oldest entries, after a
+// snapshot, or the newest, when they conflict with the leader
+func (rs *raftStore) DeleteRange(min, max uint64) error {
+	rs.Lock()
+	defer rs.Unlock()
+
+	tx, err := rs.db.BeginTX(raftLogTable)
+	if err != nil {
+		return err
+	}
+	itr, err := tx.Lookup(indexKey(min), indexKey(max))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	var keys [][]byte
+	for {
+		key, _, err := itr.Next()
+		if err == keydb.EndOfIterator {
+			break
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if _, err := tx.Remove(key); err != nil && err != keydb.KeyNotFound {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.CommitSync(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	switch {
+	case min <= rs.first && max >= rs.last:
+		rs.first, rs.last = 0, 0
+	case min <= rs.first:
+		rs.first = max + 1
+	case max >= rs.last:
+		rs.last = min - 1
+	}
+	return nil
+}
+
+func (rs *raftStore) get(table string, key []byte) ([]byte, error) {
+	tx, err := rs.db.BeginTX(table)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	value, err := tx.Get(key)
+	if err == keydb.KeyNotFound {
+		return nil, errKeyNotFound
+	}
+	return value, err
+}
+
+func (rs *raftStore) Set(key []byte, value []byte) error {
+	tx, err := rs.db.BeginTX(raftStableTable)
+	if err != nil {
+		return err
+	}
+	if err := tx.Put(key, value); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.CommitSync(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// Get returns the value of key, or an error with the text "not found" as raft requires
+func (rs *raftStore) Get(key []byte) ([]byte, error) {
+	return rs.get(raftStableTable, key)
+}
+
+func (rs *raftStore) SetUint64(key []byte, value uint64) error {
+	return rs.Set(key, indexKey(value))
+}
+
+// GetUint64 returns the value of key, or zero if it is not set
+func (rs *raftStore) GetUint64(key []byte) (uint64, error) {
+	value, err := rs.Get(key)
+	if err == errKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
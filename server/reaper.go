@@ -0,0 +1,161 @@
+package server
+
+import (
+	"errors"
+	"github.com/robaho/keydbr"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sessions tracks the open connections
+type sessions struct {
+	sync.Mutex
+	next uint64
+	m    map[uint64]*connstate
+}
+
+func (ss *sessions) add(state *connstate) {
+	ss.Lock()
+	defer ss.Unlock()
+
+	if ss.m == nil {
+		ss.m = make(map[uint64]*connstate)
+	}
+	ss.next++
+	state.id = ss.next
+	ss.m[state.id] = state
+}
+
+func (ss *sessions) remove(state *connstate) {
+	ss.Lock()
+	defer ss.Unlock()
+
+	delete(ss.m, state.id)
+}
+
+func (ss *sessions) list() []*connstate {
+	ss.Lock()
+	defer ss.Unlock()
+
+	list := make([]*connstate, 0, len(ss.m))
+	for _, state := range ss.m {
+		list = append(list, state)
+	}
+	return list
+}
+
+// tx returns the open transaction with id txid, and marks it as used
+func (state *connstate) tx(txid uint64) (*transaction, error) {
+	tx, ok := state.txs[txid]
+	if !ok {
+		if state.expired[txid] {
+			delete(state.expired, txid)
+			return nil, keydbr.TransactionExpired
+		}
+		return nil, errors.New("invalid tx id")
+	}
+	tx.used = time.Now()
+	return tx, nil
+}
+
+// itr returns the open iterator with id, and marks it as used
+func (state *connstate) itr(id uint64) (*iterator, error) {
+	itr, ok := state.itrs[id]
+	if !ok {
+		if state.expiredItrs[id] {
+			delete(state.expiredItrs, id)
+			return nil, keydbr.IteratorExpired
+		}
+		return nil, errors.New("invalid iterator id")
+	}
+	itr.used = time.Now()
+	if tx, ok := state.txs[itr.txid]; ok {
+		tx.used = itr.used
+	}
+	return itr, nil
+}
+
+// maxExpired is the number of expired transactions, and of expired iterators, remembered by a connection, so the
+// ids which a client never uses again are not kept forever
+const maxExpired = 1000
+
+// markExpired records an expired id, which is reported and forgotten when the client next uses it. Once there are
+// maxExpired, the oldest, which has the smallest id, is forgotten.
+func markExpired(expired map[uint64]bool, id uint64) {
+	if len(expired) >= maxExpired {
+		oldest := id
+		for other := range expired {
+			if other < oldest {
+				oldest = other
+			}
+		}
+		delete(expired, oldest)
+	}
+	expired[id] = true
+}
+
+// expire rolls back an idle transaction. Subsequent use of the transaction or its iterators reports
+// that it has expired.
+func (state *connstate) expire(txid uint64) {
+	if tx, ok := state.txs[txid]; ok {
+		tx.Rollback()
+	}
+	for id, itr := range state.itrs {
+		if itr.txid == txid {
+			markExpired(state.expiredItrs, id)
+		}
+	}
+	markExpired(state.expired, txid)
+	state.endtx(txid)
+}
+
+// reap expires the transactions and iterators which have been idle longer than the configured timeouts, and removes
+// the idle rate limit buckets. Connections which are busy processing a request are skipped.
+func (s *Server) reap(now time.Time) {
+	s.pruneRates(now)
+	for _, state := range s.sessions.list() {
+		if !state.TryLock() {
+			continue
+		}
+		if s.IteratorTimeout > 0 {
+			for id, itr := range state.itrs {
+				if now.Sub(itr.used) > s.IteratorTimeout {
+					state.log().Warn("expired idle iterator", "id", id, "idle", now.Sub(itr.used))
+					delete(state.itrs, id)
+					markExpired(state.expiredItrs, id)
+					atomic.AddUint64(&s.metrics.expiredIterators, 1)
+				}
+			}
+		}
+		if s.TransactionTimeout > 0 {
+			for txid, tx := range state.txs {
+				if now.Sub(tx.used) > s.TransactionTimeout {
+					state.log().Warn("expired idle transaction", "txid", txid, "idle", now.Sub(tx.used))
+					state.expire(txid)
+					atomic.AddUint64(&s.metrics.expiredTxs, 1)
+				}
+			}
+		}
+		state.updateCounts()
+		state.Unlock()
+	}
+}
+
+// reaper periodically expires idle transactions and iterators, until Shutdown
+func (s *Server) reaper() {
+	for {
+		interval := time.Second
+		for _, timeout := range []time.Duration{s.TransactionTimeout, s.IteratorTimeout} {
+			if timeout > 0 && timeout/4 < interval {
+				interval = timeout / 4
+			}
+		}
+		select {
+		case <-s.stopReaper:
+			return
+		case <-time.After(interval):
+		}
+		s.reap(time.Now())
+	}
+}
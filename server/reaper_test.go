@@ -0,0 +1,114 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestTransactionTimeout(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.TransactionTimeout = 100 * time.Millisecond
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if _, err = tx.Get([]byte("mykey")); !errors.Is(err, keydbr.TransactionExpired) {
+		t.Fatal("transaction should have expired", err)
+	}
+	if _, _, err = itr.Next(); !errors.Is(err, keydbr.IteratorExpired) {
+		t.Fatal("iterator should have expired with the transaction", err)
+	}
+
+	// the expired transaction no longer prevents closing the database
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIteratorTimeout(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.IteratorTimeout = 100 * time.Millisecond
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if _, _, err = itr.Next(); !errors.Is(err, keydbr.IteratorExpired) {
+		t.Fatal("iterator should have expired", err)
+	}
+	if _, err = tx.Get([]byte("mykey")); errors.Is(err, keydbr.TransactionExpired) {
+		t.Fatal("transaction should not have expired", err)
+	}
+}
+
+func TestExpiredTransactionsForgotten(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	srv.TransactionTimeout = 100 * time.Millisecond
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// a connection remembers a limited number of expired transactions which were not used again
+	var txs []keydbr.Transaction
+	for i := 0; i < 1001; i++ {
+		tx, err := db.BeginTX("main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if _, err = txs[0].Get([]byte("mykey")); err == nil || errors.Is(err, keydbr.TransactionExpired) {
+		t.Fatal("oldest expired transaction should be forgotten", err)
+	}
+	for _, tx := range txs[1:] {
+		if _, err = tx.Get([]byte("mykey")); !errors.Is(err, keydbr.TransactionExpired) {
+			t.Fatal("transaction should have expired", err)
+		}
+	}
+	// the expiration is reported once
+	if _, err = txs[1].Get([]byte("mykey")); err == nil || errors.Is(err, keydbr.TransactionExpired) {
+		t.Fatal("reported expired transaction should be forgotten", err)
+	}
+}
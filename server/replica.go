@@ -0,0 +1,388 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"google.golang.org/grpc"
+	"io/ioutil"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultReplicaInterval is how often a replica looks for new databases on its primary, and how long it waits to
+// reconnect after an error
+const DefaultReplicaInterval = time.Second
+
+// replicaPositionFile is the file in the directory of a replicated database recording its position
+const replicaPositionFile = "replica.json"
+
+// replica replicates the databases of a primary server until it is promoted
+type replica struct {
+	sync.Mutex
+	primary string
+	opts    []client.Option
+	dbs     map[string]*replicaDatabase
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	// stopped is set when replication stops, by Promote or Shutdown, and promoted once it has stopped
+	stopped  bool
+	promoted bool
+}
+
+// replicaDatabase is the replication state of a database
+type replicaDatabase struct {
+	epoch     string
+	position  uint64
+	connected bool
+	err       error
+}
+
+// replicaPosition is the position of the last transaction applied to a database
+type replicaPosition struct {
+	Epoch    string `json:"epoch"`
+	Position uint64 `json:"position"`
+}
+
+// readOnly returns true if the server is a replica which has not been promoted, which rejects writes
+func (s *Server) readOnly() bool {
+	if s.replica == nil {
+		return false
+	}
+	s.replica.Lock()
+	defer s.replica.Unlock()
+	return !s.replica.promoted
+}
+
+// StartReplication starts replicating the databases of the primary set by WithReplicaOf. ListenAndServe calls it,
+// and replication stops on Promote or Shutdown.
+func (s *Server) StartReplication() {
+	r := s.replica
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.stopped || r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(1)
+	go s.followPrimary(ctx)
+}
+
+// stopReplication stops replicating and waits until the transactions being applied complete, after which the
+// replica is promoted if promote is set
+func (s *Server) stopReplication(promote bool) {
+	r := s.replica
+	if r == nil {
+		return
+	}
+	r.Lock()
+	cancel := r.cancel
+	r.cancel, r.stopped = nil, true
+	r.Unlock()
+
+	if cancel != nil {
+		cancel()
+		r.wg.Wait()
+	}
+	if promote {
+		r.Lock()
+		r.promoted = true
+		r.Unlock()
+	}
+}
+
+// followPrimary replicates each database of the primary, looking for new databases every DefaultReplicaInterval
+func (s *Server) followPrimary(ctx context.Context) {
+	r := s.replica
+	defer r.wg.Done()
+
+	conn, err := client.Dial(r.primary, r.opts...)
+	if err != nil {
+		s.logger.Error("unable to connect to primary", "primary", r.primary, "error", err)
+		return
+	}
+	defer conn.Close()
+	primary := pb.NewKeydbClient(conn)
+	s.logger.Info("replicating primary", "primary", r.primary)
+
+	// the databases replicated before a restart are followed too, so they are removed if the primary removed them
+	local, err := replicatedDatabases(s.path)
+	if err != nil {
+		s.logger.Warn("unable to list the replicated databases", "error", err)
+	}
+	r.Lock()
+	for _, dbname := range local {
+		if _, ok := r.dbs[dbname]; !ok {
+			r.dbs[dbname] = &replicaDatabase{}
+			r.wg.Add(1)
+			go s.followDatabase(ctx, primary, dbname)
+		}
+	}
+	r.Unlock()
+
+	for {
+		reply, err := primary.List(ctx, &pb.ListRequest{})
+		if err == nil {
+			err = keydbr.ParseError(reply.Error)
+		}
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn("unable to list the databases of the primary", "primary", r.primary, "error", err)
+		}
+		if err == nil {
+			r.Lock()
+			for _, dbname := range reply.Dbnames {
+				if _, ok := r.dbs[dbname]; !ok {
+					r.dbs[dbname] = &replicaDatabase{}
+					r.wg.Add(1)
+					go s.followDatabase(ctx, primary, dbname)
+				}
+			}
+			r.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(DefaultReplicaInterval):
+		}
+	}
+}
+
+// followDatabase replicates a database until replication stops, or the database is removed from the primary,
+// reconnecting after errors
+func (s *Server) followDatabase(ctx context.Context, primary pb.KeydbClient, dbname string) {
+	r := s.replica
+	defer r.wg.Done()
+	logger := s.logger.With("primary", r.primary, "database", dbname)
+
+	for {
+		err := s.replicateDatabase(ctx, primary, dbname, logger)
+		if errors.Is(err, keydbr.NoDatabaseFound) && ctx.Err() == nil {
+			if err = s.removeReplica(dbname); err == nil {
+				logger.Info("removed database removed from primary")
+				r.Lock()
+				delete(r.dbs, dbname)
+				r.Unlock()
+				return
+			}
+		}
+		r.Lock()
+		r.dbs[dbname].connected = false
+		r.dbs[dbname].err = err
+		r.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("replication interrupted", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(DefaultReplicaInterval):
+		}
+	}
+}
+
+// replicateDatabase applies the transactions of a database received from the primary, in order, until an error
+// occurs. The position of the last transaction applied is recorded in the database directory, so replication
+// resumes from it.
+func (s *Server) replicateDatabase(ctx context.Context, primary pb.KeydbClient, dbname string, logger *slog.Logger) error {
+	if err := keydbr.ValidateDatabaseName(dbname); err != nil {
+		return err
+	}
+	fullpath := filepath.Join(s.path, filepath.FromSlash(dbname))
+
+	s.Lock()
+	if s.shuttingDown() {
+		s.Unlock()
+		return keydbr.ShuttingDown
+	}
+	opendb, err := s.acquire(dbname, fullpath, true, logger)
+	s.Unlock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.Lock()
+		s.release(opendb, logger)
+		s.Unlock()
+	}()
+
+	pos, err := readReplicaPosition(fullpath)
+	if err != nil {
+		return err
+	}
+	// the events are limited by the size of the primary's transactions
+	stream, err := primary.Replicate(ctx, &pb.ReplicateRequest{Dbname: dbname, Epoch: pos.Epoch, Position: pos.Position},
+		grpc.MaxCallRecvMsgSize(math.MaxInt32))
+	if err != nil {
+		return err
+	}
+
+	r := s.replica
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if event.Error != "" {
+			return keydbr.ParseError(event.Error)
+		}
+
+		switch {
+		case event.Clear:
+			logger.Info("copying database from primary", "epoch", event.Epoch, "position", event.Position)
+			pos = replicaPosition{}
+			err = writeReplicaPosition(fullpath, pos)
+			if err == nil {
+				err = clearDatabase(opendb)
+			}
+		case event.Snapshot:
+			err = applyMutations(opendb, event.Table, event.Mutations)
+		default:
+			if len(event.Mutations) > 0 && (event.Epoch != pos.Epoch || event.Position != pos.Position+1) {
+				return fmt.Errorf("replication position %d received after %d", event.Position, pos.Position)
+			}
+			err = applyMutations(opendb, event.Table, event.Mutations)
+			if err == nil {
+				pos = replicaPosition{Epoch: event.Epoch, Position: event.Position}
+				err = writeReplicaPosition(fullpath, pos)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		r.Lock()
+		db := r.dbs[dbname]
+		db.epoch, db.position, db.connected, db.err = pos.Epoch, pos.Position, true, nil
+		r.Unlock()
+	}
+}
+
+// removeReplica removes a replicated database which was removed from the primary
+func (s *Server) removeReplica(dbname string) error {
+	s.Lock()
+	defer s.Unlock()
+	return keydb.Remove(filepath.Join(s.path, filepath.FromSlash(dbname)))
+}
+
+// replicatedDatabases returns the databases in dir which record a replication position
+func replicatedDatabases(dir string) ([]string, error) {
+	dbs, err := listDatabases(dir)
+	if err != nil {
+		return nil, err
+	}
+	var replicated []string
+	for _, dbname := range dbs {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(dbname), replicaPositionFile)); err == nil {
+			replicated = append(replicated, dbname)
+		}
+	}
+	return replicated, nil
+}
+
+// applyMutations applies the writes of a transaction to table, waiting for the disk so the position recorded
+// after them is never ahead of the data
+func applyMutations(db *openDatabase, table string, mutations []*pb.Mutation) error {
+	if len(mutations) == 0 {
+		return nil
+	}
+	tx, err := db.beginTX(table)
+	if err != nil {
+		return err
+	}
+	for _, m := range mutations {
+		if m.Delete {
+			if _, err = tx.Remove(m.Key); err == keydb.KeyNotFound {
+				err = nil
+			}
+		} else {
+			err = tx.Put(m.Key, m.Value)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.CommitSync()
+}
+
+// clearDatabase removes the entries of every table, before a full copy
+func clearDatabase(db *openDatabase) error {
+	tables, err := db.tables()
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		tx, err := db.beginTX(table)
+		if err != nil {
+			return err
+		}
+		itr, err := tx.Lookup(nil, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		var keys [][]byte
+		for {
+			key, _, err := itr.Next()
+			if err == keydb.EndOfIterator {
+				break
+			}
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			if _, err := tx.Remove(key); err != nil && err != keydb.KeyNotFound {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.CommitSync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readReplicaPosition(fullpath string) (replicaPosition, error) {
+	var pos replicaPosition
+	data, err := ioutil.ReadFile(filepath.Join(fullpath, replicaPositionFile))
+	if os.IsNotExist(err) {
+		return pos, nil
+	}
+	if err != nil {
+		return pos, err
+	}
+	return pos, json.Unmarshal(data, &pos)
+}
+
+// writeReplicaPosition records the position, replacing the file so it is never partially written
+func writeReplicaPosition(fullpath string, pos replicaPosition) error {
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(fullpath, replicaPositionFile)
+	if err := ioutil.WriteFile(file+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/robaho/keydbr"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// the maximum number of events sent to a replica at once, and the maximum size of the entries in an event of a
+// full copy
+const replicationBatch = 1000
+const copyBatchBytes = 1024 * 1024
+
+var errReplicaBehind = errors.New("replica is behind the replication log")
+
+// replicationLog retains the most recent transactions committed to a database, so replicas can apply them in
+// order. Each transaction is appended at the next position. The log is kept in memory, so the epoch identifies
+// it, and replicas make a full copy after the primary restarts.
+type replicationLog struct {
+	sync.Mutex
+	epoch  string
+	first  uint64 // the position before the first retained event
+	events []*pb.ReplicationEvent
+	size   int
+	// changed is closed when an event is appended, or the database is removed
+	changed chan struct{}
+	removed bool
+}
+
+func newReplicationLog(size int) *replicationLog {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &replicationLog{epoch: hex.EncodeToString(b), size: size, changed: make(chan struct{})}
+}
+
+// replicationLog returns the replication log of the database at fullpath, which lasts until the server stops or
+// the database is removed
+func (s *Server) replicationLog(fullpath string) *replicationLog {
+	s.Lock()
+	defer s.Unlock()
+	log, ok := s.replogs[fullpath]
+	if !ok {
+		log = newReplicationLog(s.replicationLogSize)
+		s.replogs[fullpath] = log
+	}
+	return log
+}
+
+// position returns the epoch and the position of the last transaction
+func (l *replicationLog) position() (string, uint64) {
+	l.Lock()
+	defer l.Unlock()
+	return l.epoch, l.first + uint64(len(l.events))
+}
+
+// append adds a committed transaction, retaining at least the most recent size. The caller must hold the lock.
+func (l *replicationLog) append(table string, mutations []*pb.Mutation) {
+	position := l.first + uint64(len(l.events)) + 1
+	l.events = append(l.events, &pb.ReplicationEvent{Epoch: l.epoch, Position: position, Table: table, Mutations: mutations})
+	if len(l.events) >= 2*l.size {
+		n := copy(l.events, l.events[len(l.events)-l.size:])
+		for i := n; i < len(l.events); i++ {
+			l.events[i] = nil
+		}
+		l.first += uint64(len(l.events) - n)
+		l.events = l.events[:n]
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// remove ends the replication of a removed database, so replicas remove it too
+func (l *replicationLog) remove() {
+	l.Lock()
+	defer l.Unlock()
+	l.removed = true
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// contains returns true if the transactions after position are retained. The caller must hold the lock.
+func (l *replicationLog) contains(epoch string, position uint64) bool {
+	return epoch == l.epoch && position >= l.first && position <= l.first+uint64(len(l.events))
+}
+
+// since returns the next events after position, and a channel which is closed when another is appended
+func (l *replicationLog) since(epoch string, position uint64) ([]*pb.ReplicationEvent, <-chan struct{}, error) {
+	l.Lock()
+	defer l.Unlock()
+	if l.removed {
+		return nil, nil, keydbr.NoDatabaseFound
+	}
+	if !l.contains(epoch, position) {
+		return nil, nil, errReplicaBehind
+	}
+	events := l.events[position-l.first:]
+	if len(events) > replicationBatch {
+		events = events[:replicationBatch]
+	}
+	return append([]*pb.ReplicationEvent(nil), events...), l.changed, nil
+}
+
+// record retains a write for the replication log or the cluster
+func (tx *transaction) record(m *pb.Mutation) {
+	if tx.log != nil || tx.dbname != "" {
+		tx.mutations = append(tx.mutations, m)
+	}
+}
+
+// commit commits the transaction, waiting for the disk if durable. Its writes are appended to the replication log while holding the log's lock, so
+// the log is in commit order.
+func (tx *transaction) commit(durable bool) error {
+	commit := tx.Commit
+	if durable {
+		commit = tx.CommitSync
+	}
+	if tx.log == nil || len(tx.mutations) == 0 {
+		return commit()
+	}
+	tx.log.Lock()
+	defer tx.log.Unlock()
+	if err := commit(); err != nil {
+		return err
+	}
+	tx.log.append(tx.table, tx.mutations)
+	tx.mutations = nil
+	return nil
+}
+
+// Replicate streams the transactions committed to a database to a replica, starting after the position in the
+// request. If those transactions are no longer in the replication log, the database is copied first. The caller
+// must administer the database, and errors are sent in the Error of the last event. The database is closed once
+// copied, so it can be removed, which ends the stream with keydbr.NoDatabaseFound and removes it from the replica.
+func (s *Server) Replicate(in *pb.ReplicateRequest, stream pb.Keydb_ReplicateServer) error {
+	if s.replicationLogSize == 0 {
+		return stream.Send(&pb.ReplicationEvent{Error: "replication is not enabled"})
+	}
+	if s.shuttingDown() {
+		return stream.Send(&pb.ReplicationEvent{Error: keydbr.ShuttingDown.Error()})
+	}
+
+	ctx := stream.Context()
+	ls := s.newLocalSession(ctx, "replication")
+	defer ls.end()
+
+	var dbname, fullpath string
+	err := ls.open(in.Dbname, false)
+	if err == nil {
+		ls.state.Lock()
+		dbname, fullpath = ls.state.dbname, ls.db.fullpath
+		ls.state.Unlock()
+		err = s.ACL.Check(ls.state.identity, dbname, "", Admin)
+	}
+	if err != nil {
+		return stream.Send(&pb.ReplicationEvent{Error: err.Error()})
+	}
+
+	log := s.replicationLog(fullpath)
+	epoch, position := in.Epoch, in.Position
+	log.Lock()
+	resume := log.contains(epoch, position)
+	log.Unlock()
+	if !resume {
+		ls.state.logger.Info("copying database to replica", "database", dbname)
+		if epoch, position, err = s.copyDatabase(ls, log, stream); err != nil {
+			return stream.Send(&pb.ReplicationEvent{Error: err.Error()})
+		}
+	}
+	ls.state.logger.Info("replicating database", "database", dbname, "epoch", epoch, "position", position)
+	if err := ls.close(); err != nil {
+		return stream.Send(&pb.ReplicationEvent{Error: err.Error()})
+	}
+
+	// the replica records the position it starts from
+	if err := stream.Send(&pb.ReplicationEvent{Epoch: epoch, Position: position}); err != nil {
+		return err
+	}
+	for {
+		events, changed, err := log.since(epoch, position)
+		if err != nil {
+			return stream.Send(&pb.ReplicationEvent{Error: err.Error()})
+		}
+		for _, event := range events {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+			position = event.Position
+		}
+		if len(events) > 0 {
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ls.state.done:
+			ls.state.Lock()
+			err := ls.state.closed
+			ls.state.Unlock()
+			return err
+		}
+	}
+}
+
+// copyDatabase sends the contents of the database, returning the position in the log after which the transactions
+// must be applied to make the copy consistent
+func (s *Server) copyDatabase(ls *localSession, log *replicationLog, stream pb.Keydb_ReplicateServer) (string, uint64, error) {
+	epoch, position := log.position()
+	if err := stream.Send(&pb.ReplicationEvent{Epoch: epoch, Position: position, Clear: true}); err != nil {
+		return "", 0, err
+	}
+
+	tables, err := ls.db.tables()
+	if err != nil {
+		return "", 0, err
+	}
+	for _, table := range tables {
+		txid, err := ls.begin(table)
+		if err != nil {
+			return "", 0, err
+		}
+		var mutations []*pb.Mutation
+		size := 0
+		send := func() error {
+			err := stream.Send(&pb.ReplicationEvent{Epoch: epoch, Position: position, Table: table, Mutations: mutations, Snapshot: true})
+			mutations, size = nil, 0
+			return err
+		}
+		var sendErr error
+		err = ls.each(txid, nil, nil, func(key, value []byte) bool {
+			mutations = append(mutations, &pb.Mutation{Key: key, Value: value})
+			size += len(key) + len(value)
+			if len(mutations) >= replicationBatch || size >= copyBatchBytes {
+				sendErr = send()
+			}
+			return sendErr == nil
+		})
+		if err == nil {
+			err = sendErr
+		}
+		if err == nil && len(mutations) > 0 {
+			err = send()
+		}
+		ls.rollback(txid)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	return epoch, position, nil
+}
+
+// ReplicationStatus returns the replicated position of each database of a replica, or the position of the
+// replication log of each database of a primary, limited to the databases the caller administers
+func (s *Server) ReplicationStatus(ctx context.Context, in *pb.ReplicationStatusRequest) (*pb.ReplicationStatusReply, error) {
+	id := IdentityFromContext(ctx)
+
+	reply := &pb.ReplicationStatusReply{}
+	if s.readOnly() {
+		r := s.replica
+		reply.Primary = r.primary
+		r.Lock()
+		for dbname, db := range r.dbs {
+			if s.ACL.Permission(id, dbname, "") >= Admin {
+				reply.Databases = append(reply.Databases, &pb.ReplicationPosition{Dbname: dbname, Epoch: db.epoch,
+					Position: db.position, Connected: db.connected, Error: toErrS(db.err)})
+			}
+		}
+		r.Unlock()
+	} else {
+		s.Lock()
+		logs := make(map[string]*replicationLog, len(s.replogs))
+		for fullpath, log := range s.replogs {
+			logs[fullpath] = log
+		}
+		s.Unlock()
+		for fullpath, log := range logs {
+			dbname, err := filepath.Rel(s.path, fullpath)
+			if err != nil {
+				continue
+			}
+			dbname = filepath.ToSlash(dbname)
+			if s.ACL.Permission(id, dbname, "") >= Admin {
+				epoch, position := log.position()
+				reply.Databases = append(reply.Databases, &pb.ReplicationPosition{Dbname: dbname, Epoch: epoch, Position: position})
+			}
+		}
+	}
+	sort.Slice(reply.Databases, func(i, j int) bool { return reply.Databases[i].Dbname < reply.Databases[j].Dbname })
+	return reply, nil
+}
+
+// Promote makes a replica a primary. It stops replicating, and then accepts writes. The caller must administer
+// all databases.
+func (s *Server) Promote(ctx context.Context, in *pb.PromoteRequest) (*pb.PromoteReply, error) {
+	id := IdentityFromContext(ctx)
+
+	err := s.ACL.Check(id, "", "", Admin)
+	if err == nil && s.replica == nil {
+		err = errors.New("server is not a replica")
+	}
+	if err == nil {
+		s.stopReplication(true)
+		name := ""
+		if id != nil {
+			name = id.Name
+		}
+		s.logger.Warn("promoted to primary", "primary", s.replica.primary, "by", name)
+	}
+	return &pb.PromoteReply{Error: toErrS(err)}, nil
+}
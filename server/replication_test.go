@@ -0,0 +1,190 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+// write commits a transaction on the database at addr, removing the keys with a nil value
+func write(t *testing.T, addr string, entries map[string][]byte) {
+	t.Helper()
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range entries {
+		if value == nil {
+			_, err = tx.Remove([]byte(key))
+		} else {
+			err = tx.PutSync([]byte(key), value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor waits until the replica has the value of key, or does not have the key if value is nil
+func waitFor(t *testing.T, addr string, key string, value []byte) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var found []byte
+		db, err := client.Open(addr, "main", false, 10)
+		if err == nil {
+			var tx keydbr.Transaction
+			if tx, err = db.BeginTX("main"); err == nil {
+				found, err = tx.Get([]byte(key))
+				tx.Rollback()
+			}
+			db.Close()
+		}
+		if value == nil && errors.Is(err, keydbr.KeyNotFound) || value != nil && string(found) == string(value) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("replica has %s=%q, expected %q, %v", key, found, value, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startReplica starts a replica of primary with its databases in dir, returning the server and its address
+func startReplica(t *testing.T, dir string, primary string) (*server.Server, string) {
+	srv := server.NewServer(dir, server.WithReplicaOf(primary))
+	addr := startServer(t, srv)
+	srv.StartReplication()
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	return srv, addr
+}
+
+func TestReplication(t *testing.T) {
+	primary := server.NewServer(tempDir(t), server.WithReplicationLog(100))
+	paddr := startServer(t, primary)
+	t.Cleanup(func() { primary.Shutdown(context.Background()) })
+
+	// the replica copies the existing data, and then applies the transactions committed after the copy
+	write(t, paddr, map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	dir := tempDir(t)
+	replica, raddr := startReplica(t, dir, paddr)
+	waitFor(t, raddr, "b", []byte("2"))
+	write(t, paddr, map[string][]byte{"a": nil, "c": []byte("3")})
+	waitFor(t, raddr, "c", []byte("3"))
+	waitFor(t, raddr, "a", nil)
+
+	db, err := client.Open(raddr, "main", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.PutSync([]byte("d"), []byte("4")); !errors.Is(err, keydbr.ReadOnly) {
+		t.Fatal("replica should reject writes", err)
+	}
+	if _, err := tx.Remove([]byte("b")); !errors.Is(err, keydbr.ReadOnly) {
+		t.Fatal("replica should reject removes", err)
+	}
+	tx.Rollback()
+	db.Close()
+	if _, err := client.Open(raddr, "other", true, 10); !errors.Is(err, keydbr.ReadOnly) {
+		t.Fatal("replica should not create databases", err)
+	}
+
+	pstatus, err := client.ReplicationStatus(paddr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rstatus, err := client.ReplicationStatus(raddr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pstatus.Primary != "" || len(pstatus.Databases) != 1 || pstatus.Databases[0].Position != 2 {
+		t.Fatalf("wrong primary status %+v", pstatus)
+	}
+	if rstatus.Primary != paddr || len(rstatus.Databases) != 1 || !rstatus.Databases[0].Connected ||
+		rstatus.Databases[0].Epoch != pstatus.Databases[0].Epoch || rstatus.Databases[0].Position != 2 {
+		t.Fatalf("wrong replica status %+v", rstatus)
+	}
+
+	// a restarted replica resumes from its recorded position
+	replica.Shutdown(context.Background())
+	write(t, paddr, map[string][]byte{"d": []byte("4")})
+	replica, raddr = startReplica(t, dir, paddr)
+	waitFor(t, raddr, "d", []byte("4"))
+	waitFor(t, raddr, "b", []byte("2"))
+	if rstatus, err = client.ReplicationStatus(raddr, 10); err != nil {
+		t.Fatal(err)
+	}
+	if rstatus.Databases[0].Epoch != pstatus.Databases[0].Epoch || rstatus.Databases[0].Position != 3 {
+		t.Fatalf("wrong replica status after restart %+v", rstatus)
+	}
+
+	if err := client.Promote(paddr, 10); err == nil {
+		t.Fatal("primary should not be promoted")
+	}
+	if err := client.Promote(raddr, 10); err != nil {
+		t.Fatal(err)
+	}
+	write(t, raddr, map[string][]byte{"e": []byte("5")})
+	write(t, paddr, map[string][]byte{"f": []byte("6")})
+	time.Sleep(100 * time.Millisecond)
+	waitFor(t, raddr, "f", nil)
+	if rstatus, err = client.ReplicationStatus(raddr, 10); err != nil || rstatus.Primary != "" {
+		t.Fatalf("promoted replica should be a primary %+v %v", rstatus, err)
+	}
+}
+
+func TestReplicationRemove(t *testing.T) {
+	primary := server.NewServer(tempDir(t), server.WithReplicationLog(100))
+	paddr := startServer(t, primary)
+	t.Cleanup(func() { primary.Shutdown(context.Background()) })
+
+	write(t, paddr, map[string][]byte{"a": []byte("1")})
+	_, raddr := startReplica(t, tempDir(t), paddr)
+	waitFor(t, raddr, "a", []byte("1"))
+
+	// the database is closed once copied, so it can be removed while replicated
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := client.Remove(paddr, "main", 10)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the replica removes its copy, and stops replicating it
+	for {
+		db, err := client.Open(raddr, "main", false, 10)
+		if err == nil {
+			db.Close()
+		}
+		status, serr := client.ReplicationStatus(raddr, 10)
+		if errors.Is(err, keydbr.NoDatabaseFound) && serr == nil && len(status.Databases) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("replica did not remove the database", err, status, serr)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
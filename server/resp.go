@@ -0,0 +1,702 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRESPDatabase and DefaultRESPTable are the database and table used by the RESP frontend if not configured
+const DefaultRESPDatabase = "redis"
+const DefaultRESPTable = "main"
+
+// ExpireTableSuffix is appended to the RESP table to name the table holding the expiration times of its keys
+const ExpireTableSuffix = ".expire"
+
+// the number of keys examined by SCAN if the request does not set a count
+const defaultScanCount = 10
+
+// ServeRESP serves the Redis protocol on lis until it fails or is closed, see WithRESP. Each connection is a
+// session, which opens the configured database once the client is authenticated, creating it if necessary.
+func (s *Server) ServeRESP(lis net.Listener) error {
+	config, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if config != nil {
+		lis = tls.NewListener(lis, config)
+	}
+	for {
+		nc, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveRESPConn(nc)
+	}
+}
+
+// respConn is a connection to the RESP frontend
+type respConn struct {
+	s   *Server
+	nc  net.Conn
+	r   *respReader
+	w   respWriter
+	ctx context.Context
+	// ls is the session of the connection, nil until the client is authenticated
+	ls     *localSession
+	closed chan struct{}
+	quit   bool
+	// multi holds the commands queued by MULTI, and is nil outside of MULTI
+	multi [][][]byte
+	// aborted is set if a command could not be queued, so EXEC fails
+	aborted bool
+}
+
+func (s *Server) serveRESPConn(nc net.Conn) {
+	defer nc.Close()
+
+	p := &peer.Peer{Addr: nc.RemoteAddr()}
+	if tc, ok := nc.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			s.logger.Debug("RESP TLS handshake failed", "peer", p.Addr.String(), "error", err)
+			return
+		}
+		p.AuthInfo = credentials.TLSInfo{State: tc.ConnectionState()}
+	}
+
+	c := &respConn{s: s, nc: nc, r: newRESPReader(nc, s.maxRequestSize()), w: respWriter{bufio.NewWriter(nc)}, closed: make(chan struct{})}
+	c.ctx = peer.NewContext(context.Background(), p)
+	defer c.end()
+
+	// a client certificate authenticates the connection, otherwise the client must send AUTH
+	var err error
+	if auth := s.authenticators(); len(auth) == 0 {
+		err = c.start(c.ctx)
+	} else if ctx, autherr := authenticate(auth, c.ctx); autherr == nil {
+		err = c.start(ctx)
+	}
+	if err != nil {
+		c.w.writeReply(err)
+		c.w.Flush()
+		return
+	}
+
+	for {
+		args, err := c.r.readCommand()
+		if err != nil {
+			var pe respProtocolError
+			if errors.As(err, &pe) {
+				c.w.writeReply(err)
+				c.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		c.w.writeReply(c.dispatch(args))
+		if c.quit || (c.ls != nil && c.ls.closed != nil) {
+			c.w.Flush()
+			return
+		}
+		// replies to pipelined commands are sent together
+		if c.r.Buffered() == 0 {
+			if err := c.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// start creates the session of an authenticated client, and opens the database
+func (c *respConn) start(ctx context.Context) error {
+	if c.s.shuttingDown() {
+		return keydbr.ShuttingDown
+	}
+	ls := c.s.newLocalSession(ctx, "resp")
+	if err := ls.open(c.s.respDatabase, true); err != nil {
+		ls.end()
+		return err
+	}
+	c.ls = ls
+
+	// close the connection when the session is closed by Shutdown or KillSession, even while it is idle
+	go func() {
+		select {
+		case <-ls.state.done:
+			c.nc.Close()
+		case <-c.closed:
+		}
+	}()
+	return nil
+}
+
+func (c *respConn) end() {
+	if c.ls != nil {
+		c.ls.end()
+	}
+	close(c.closed)
+}
+
+// respCommand is a command supported by the RESP frontend
+type respCommand struct {
+	// arity is the number of arguments including the command name, or -n for at least n
+	arity int
+	// write is set if the command modifies the table
+	write bool
+	// conn is set if the command applies to the connection rather than the table
+	conn bool
+	fn   func(c *respConn, tx *respTx, args [][]byte) interface{}
+}
+
+var respCommands map[string]respCommand
+
+func init() {
+	respCommands = map[string]respCommand{
+		"PING":    {arity: -1, conn: true, fn: (*respConn).ping},
+		"ECHO":    {arity: 2, conn: true, fn: (*respConn).echo},
+		"QUIT":    {arity: -1, conn: true, fn: (*respConn).quitCmd},
+		"SELECT":  {arity: 2, conn: true, fn: (*respConn).selectCmd},
+		"AUTH":    {arity: -2, conn: true, fn: (*respConn).auth},
+		"COMMAND": {arity: -1, conn: true, fn: (*respConn).command},
+		"MULTI":   {arity: 1, conn: true, fn: (*respConn).multiCmd},
+		"EXEC":    {arity: 1, conn: true, fn: (*respConn).execCmd},
+		"DISCARD": {arity: 1, conn: true, fn: (*respConn).discard},
+		"GET":     {arity: 2, fn: (*respConn).get},
+		"SET":     {arity: -3, write: true, fn: (*respConn).set},
+		"DEL":     {arity: -2, write: true, fn: (*respConn).del},
+		"EXISTS":  {arity: -2, fn: (*respConn).exists},
+		"MGET":    {arity: -2, fn: (*respConn).mget},
+		"MSET":    {arity: -3, write: true, fn: (*respConn).mset},
+		"INCR":    {arity: 2, write: true, fn: (*respConn).incr},
+		"EXPIRE":  {arity: 3, write: true, fn: (*respConn).expire},
+		"TTL":     {arity: 2, fn: (*respConn).ttl},
+		"SCAN":    {arity: -2, fn: (*respConn).scan},
+	}
+}
+
+var errNotInteger = respError("ERR value is not an integer or out of range")
+var errSyntax = respError("ERR syntax error")
+
+// dispatch runs a command, returning its reply
+func (c *respConn) dispatch(args [][]byte) interface{} {
+	name := strings.ToUpper(string(args[0]))
+	cmd, ok := respCommands[name]
+	if !ok {
+		c.aborted = c.multi != nil
+		return respError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || len(args) < -cmd.arity {
+		c.aborted = c.multi != nil
+		return respError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+	}
+	if cmd.conn {
+		return cmd.fn(c, nil, args)
+	}
+	if c.ls == nil {
+		return respError("NOAUTH Authentication required.")
+	}
+	if c.multi != nil {
+		c.multi = append(c.multi, args)
+		return respStatus("QUEUED")
+	}
+	replies, err := c.run([][][]byte{args})
+	if err != nil {
+		return err
+	}
+	return replies[0]
+}
+
+// run runs the commands in a transaction on the table and its expiration table. The transaction is committed if
+// any of the commands writes and none of them fail, and is otherwise rolled back. It returns the replies of the
+// commands, or the error which failed the transaction. Transactions which write are serialized, so commands such
+// as INCR and SET NX, which read before writing, do not lose concurrent updates.
+func (c *respConn) run(cmds [][][]byte) ([]interface{}, error) {
+	write := false
+	for _, args := range cmds {
+		write = write || respCommands[strings.ToUpper(string(args[0]))].write
+	}
+	if write {
+		lock := &c.ls.db.resp
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	txid, err := c.ls.begin(c.s.respTable)
+	if err != nil {
+		return nil, err
+	}
+	expireTxid, err := c.ls.begin(c.s.respTable + ExpireTableSuffix)
+	if err != nil {
+		c.ls.rollback(txid)
+		return nil, err
+	}
+	tx := &respTx{ls: c.ls, txid: txid, expireTxid: expireTxid, write: write, now: time.Now()}
+
+	replies := make([]interface{}, len(cmds))
+	for i, args := range cmds {
+		replies[i] = respCommands[strings.ToUpper(string(args[0]))].fn(c, tx, args)
+		if err, ok := replies[i].(error); ok {
+			tx.end(false)
+			return nil, err
+		}
+	}
+	if err := tx.end(write); err != nil {
+		return nil, err
+	}
+	return replies, nil
+}
+
+// respTx is a transaction on the RESP table and its expiration table
+type respTx struct {
+	ls         *localSession
+	txid       uint64
+	expireTxid uint64
+	write      bool
+	now        time.Time
+}
+
+// end commits or rolls back the transaction
+func (tx *respTx) end(commit bool) error {
+	var err error
+	if commit {
+		err = tx.ls.commit(tx.txid)
+		if err == nil {
+			return tx.ls.commit(tx.expireTxid)
+		}
+		tx.ls.rollback(tx.txid)
+	} else {
+		err = tx.ls.rollback(tx.txid)
+	}
+	if err0 := tx.ls.rollback(tx.expireTxid); err == nil {
+		err = err0
+	}
+	return err
+}
+
+// expiry returns the expiration time of key, or zero if it does not expire. The times are stored as big endian
+// unix milliseconds.
+func (tx *respTx) expiry(key []byte) (time.Time, error) {
+	value, err := tx.ls.get(tx.expireTxid, key)
+	if errors.Is(err, keydb.KeyNotFound) || (err == nil && len(value) != 8) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(value))), nil
+}
+
+func (tx *respTx) setExpiry(key []byte, expiry time.Time) error {
+	if expiry.IsZero() {
+		_, err := tx.ls.delete(tx.expireTxid, key)
+		if errors.Is(err, keydb.KeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(expiry.UnixMilli()))
+	return tx.ls.put(tx.expireTxid, key, value)
+}
+
+// get returns the value of key, and false if the key does not exist or has expired. Expired keys are removed by
+// transactions which write.
+func (tx *respTx) get(key []byte) ([]byte, bool, error) {
+	value, err := tx.ls.get(tx.txid, key)
+	if errors.Is(err, keydb.KeyNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	expiry, err := tx.expiry(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if !expiry.IsZero() && !tx.now.Before(expiry) {
+		if tx.write {
+			err = tx.remove(key)
+		}
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// remove deletes key and its expiration time
+func (tx *respTx) remove(key []byte) error {
+	if _, err := tx.ls.delete(tx.txid, key); err != nil && !errors.Is(err, keydb.KeyNotFound) {
+		return err
+	}
+	return tx.setExpiry(key, time.Time{})
+}
+
+func (c *respConn) ping(tx *respTx, args [][]byte) interface{} {
+	if len(args) > 2 {
+		return respError("ERR wrong number of arguments for 'ping' command")
+	}
+	if len(args) == 2 {
+		return args[1]
+	}
+	return respStatus("PONG")
+}
+
+func (c *respConn) echo(tx *respTx, args [][]byte) interface{} {
+	return args[1]
+}
+
+func (c *respConn) quitCmd(tx *respTx, args [][]byte) interface{} {
+	c.quit = true
+	return respStatus("OK")
+}
+
+// selectCmd accepts database 0, the configured table
+func (c *respConn) selectCmd(tx *respTx, args [][]byte) interface{} {
+	if n, err := strconv.Atoi(string(args[1])); err != nil {
+		return errNotInteger
+	} else if n != 0 {
+		return respError("ERR DB index is out of range")
+	}
+	return respStatus("OK")
+}
+
+// auth authenticates the client with a token, sent as the password. A user name is ignored.
+func (c *respConn) auth(tx *respTx, args [][]byte) interface{} {
+	if len(args) > 3 {
+		return errSyntax
+	}
+	auth := c.s.authenticators()
+	if len(auth) == 0 {
+		return respError("ERR AUTH called without any password configured")
+	}
+	ctx := metadata.NewIncomingContext(c.ctx, metadata.Pairs("authorization", string(args[len(args)-1])))
+	ctx, err := authenticate(auth, ctx)
+	if err != nil {
+		return respError("WRONGPASS invalid username-password pair or user is disabled.")
+	}
+	if c.ls != nil {
+		c.ls.end()
+		c.ls = nil
+	}
+	if err := c.start(ctx); err != nil {
+		return err
+	}
+	return respStatus("OK")
+}
+
+// command returns no command details, which clients such as redis-cli request on connecting
+func (c *respConn) command(tx *respTx, args [][]byte) interface{} {
+	return []interface{}{}
+}
+
+func (c *respConn) multiCmd(tx *respTx, args [][]byte) interface{} {
+	if c.multi != nil {
+		return respError("ERR MULTI calls can not be nested")
+	}
+	c.multi, c.aborted = [][][]byte{}, false
+	return respStatus("OK")
+}
+
+// execCmd runs the commands queued by MULTI in a single transaction
+func (c *respConn) execCmd(tx *respTx, args [][]byte) interface{} {
+	if c.multi == nil {
+		return respError("ERR EXEC without MULTI")
+	}
+	cmds, aborted := c.multi, c.aborted
+	c.multi, c.aborted = nil, false
+	if aborted {
+		return respError("EXECABORT Transaction discarded because of previous errors.")
+	}
+	if len(cmds) == 0 {
+		return []interface{}{}
+	}
+	replies, err := c.run(cmds)
+	if err != nil {
+		return respError("EXECABORT Transaction rolled back because of: " + respErrorString(err))
+	}
+	return replies
+}
+
+func (c *respConn) discard(tx *respTx, args [][]byte) interface{} {
+	if c.multi == nil {
+		return respError("ERR DISCARD without MULTI")
+	}
+	c.multi, c.aborted = nil, false
+	return respStatus("OK")
+}
+
+func (c *respConn) get(tx *respTx, args [][]byte) interface{} {
+	value, found, err := tx.get(args[1])
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	return value
+}
+
+// set supports the options EX seconds, PX milliseconds, NX, XX and KEEPTTL
+func (c *respConn) set(tx *respTx, args [][]byte) interface{} {
+	var expiry time.Time
+	var nx, xx, keepTTL bool
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX":
+			if i+1 == len(args) || !expiry.IsZero() {
+				return errSyntax
+			}
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				return errNotInteger
+			}
+			if n <= 0 {
+				return respError("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+			expiry = tx.now.Add(time.Duration(n) * unit)
+		default:
+			return errSyntax
+		}
+	}
+	if (nx && xx) || (keepTTL && !expiry.IsZero()) {
+		return errSyntax
+	}
+
+	if nx || xx {
+		_, found, err := tx.get(args[1])
+		if err != nil {
+			return err
+		}
+		if found == nx {
+			return nil
+		}
+	}
+	if err := tx.ls.put(tx.txid, args[1], args[2]); err != nil {
+		return err
+	}
+	if !keepTTL {
+		if err := tx.setExpiry(args[1], expiry); err != nil {
+			return err
+		}
+	}
+	return respStatus("OK")
+}
+
+func (c *respConn) del(tx *respTx, args [][]byte) interface{} {
+	var n int64
+	for _, key := range args[1:] {
+		_, found, err := tx.get(key)
+		if err == nil && found {
+			err = tx.remove(key)
+			n++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return n
+}
+
+func (c *respConn) exists(tx *respTx, args [][]byte) interface{} {
+	var n int64
+	for _, key := range args[1:] {
+		_, found, err := tx.get(key)
+		if err != nil {
+			return err
+		}
+		if found {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *respConn) mget(tx *respTx, args [][]byte) interface{} {
+	values := make([]interface{}, len(args)-1)
+	for i, key := range args[1:] {
+		value, found, err := tx.get(key)
+		if err != nil {
+			return err
+		}
+		if found {
+			values[i] = value
+		}
+	}
+	return values
+}
+
+func (c *respConn) mset(tx *respTx, args [][]byte) interface{} {
+	if len(args)%2 != 1 {
+		return respError("ERR wrong number of arguments for 'mset' command")
+	}
+	for i := 1; i < len(args); i += 2 {
+		if err := tx.ls.put(tx.txid, args[i], args[i+1]); err != nil {
+			return err
+		}
+		if err := tx.setExpiry(args[i], time.Time{}); err != nil {
+			return err
+		}
+	}
+	return respStatus("OK")
+}
+
+// incr increments the decimal integer value of a key, keeping its expiration time
+func (c *respConn) incr(tx *respTx, args [][]byte) interface{} {
+	value, found, err := tx.get(args[1])
+	if err != nil {
+		return err
+	}
+	var n int64
+	if found {
+		if n, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return errNotInteger
+		}
+	}
+	if n == math.MaxInt64 {
+		return respError("ERR increment or decrement would overflow")
+	}
+	n++
+	if err := tx.ls.put(tx.txid, args[1], []byte(strconv.FormatInt(n, 10))); err != nil {
+		return err
+	}
+	return n
+}
+
+// expire sets the time to live of a key in seconds, deleting it if the time is not positive
+func (c *respConn) expire(tx *respTx, args [][]byte) interface{} {
+	seconds, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	_, found, err := tx.get(args[1])
+	if err != nil {
+		return err
+	}
+	if !found {
+		return int64(0)
+	}
+	if seconds <= 0 {
+		err = tx.remove(args[1])
+	} else {
+		err = tx.setExpiry(args[1], tx.now.Add(time.Duration(seconds)*time.Second))
+	}
+	if err != nil {
+		return err
+	}
+	return int64(1)
+}
+
+// ttl returns the seconds until a key expires, -1 if it does not expire, or -2 if it does not exist
+func (c *respConn) ttl(tx *respTx, args [][]byte) interface{} {
+	_, found, err := tx.get(args[1])
+	if err != nil {
+		return err
+	}
+	if !found {
+		return int64(-2)
+	}
+	expiry, err := tx.expiry(args[1])
+	if err != nil {
+		return err
+	}
+	if expiry.IsZero() {
+		return int64(-1)
+	}
+	return int64((expiry.Sub(tx.now) + time.Second/2) / time.Second)
+}
+
+// scan supports the options MATCH pattern and COUNT count. The cursor is the number of keys already examined in the
+// range of keys matching the literal prefix of the pattern, so it is valid on any connection, and a key added or
+// removed during a scan may cause another key to be returned twice or missed.
+func (c *respConn) scan(tx *respTx, args [][]byte) interface{} {
+	cursor, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		return respError("ERR invalid cursor")
+	}
+	var pattern []byte
+	count := uint64(defaultScanCount)
+	for i := 2; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return errSyntax
+		}
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if count, err = strconv.ParseUint(string(args[i+1]), 10, 64); err != nil {
+				return errNotInteger
+			}
+			if count == 0 {
+				return errSyntax
+			}
+		default:
+			return errSyntax
+		}
+	}
+
+	prefix := globPrefix(pattern)
+	var examined uint64
+	var candidates [][]byte
+	done := true
+	err = tx.ls.each(tx.txid, prefix, nil, func(key, value []byte) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return false
+		}
+		examined++
+		if examined <= cursor {
+			return true
+		}
+		if pattern == nil || globMatch(pattern, key) {
+			candidates = append(candidates, key)
+		}
+		if examined-cursor == count {
+			done = false
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []interface{}{}
+	for _, key := range candidates {
+		expiry, err := tx.expiry(key)
+		if err != nil {
+			return err
+		}
+		if expiry.IsZero() || tx.now.Before(expiry) {
+			keys = append(keys, key)
+		}
+	}
+	next := uint64(0)
+	if !done {
+		next = examined
+	}
+	return []interface{}{[]byte(strconv.FormatUint(next, 10)), keys}
+}
@@ -0,0 +1,342 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr/server"
+)
+
+// respClient is a minimal Redis protocol client
+type respClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRESP(t *testing.T, srv *server.Server) *respClient {
+	return dialRESPAddr(t, listenRESP(t, srv))
+}
+
+// listenRESP serves the Redis protocol on a local address, returning the address
+func listenRESP(t *testing.T, srv *server.Server) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ServeRESP(lis)
+	t.Cleanup(func() { lis.Close() })
+	return lis.Addr().String()
+}
+
+func dialRESPAddr(t *testing.T, addr string) *respClient {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &respClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends a command and returns the reply, which is a string for a status, an error, an int64, a string or nil
+// for a bulk string, or a []interface{} for an array
+func (c *respClient) do(args ...string) interface{} {
+	c.t.Helper()
+	cmd := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		cmd += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, cmd); err != nil {
+		c.t.Fatal(err)
+	}
+	reply, err := c.read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return reply
+}
+
+func (c *respClient) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return errors.New(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		_, err := io.ReadFull(c.r, buf)
+		return string(buf[:n]), err
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		array := []interface{}{}
+		for i := 0; i < n; i++ {
+			reply, err := c.read()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, reply)
+		}
+		return array, nil
+	}
+	return nil, errors.New("invalid reply " + line)
+}
+
+// expect sends a command and checks the reply. An expected error matches any error starting with it.
+func (c *respClient) expect(expected interface{}, args ...string) {
+	c.t.Helper()
+	reply := c.do(args...)
+	if err, ok := expected.(error); ok {
+		if got, ok := reply.(error); !ok || !strings.HasPrefix(got.Error(), err.Error()) {
+			c.t.Fatalf("%v: expected error %v, got %#v", args, err, reply)
+		}
+		return
+	}
+	if !reflect.DeepEqual(reply, expected) {
+		c.t.Fatalf("%v: expected %#v, got %#v", args, expected, reply)
+	}
+}
+
+func TestRESP(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	c := dialRESP(t, srv)
+
+	c.expect("PONG", "PING")
+	c.expect("hello", "ECHO", "hello")
+	c.expect(nil, "GET", "mykey")
+	c.expect("OK", "SET", "mykey", "myvalue")
+	c.expect("myvalue", "get", "mykey")
+	c.expect(errors.New("ERR unknown command"), "HGET", "mykey", "field")
+	c.expect(errors.New("ERR wrong number of arguments for 'get' command"), "GET")
+	c.expect(errors.New("ERR syntax error"), "SET", "mykey", "myvalue", "EX")
+
+	c.expect(nil, "SET", "mykey", "other", "NX")
+	c.expect(nil, "SET", "newkey", "other", "XX")
+	c.expect("OK", "SET", "mykey", "other", "XX")
+	c.expect("other", "GET", "mykey")
+
+	c.expect("OK", "MSET", "a", "1", "b", "2")
+	c.expect([]interface{}{"1", nil, "2"}, "MGET", "a", "missing", "b")
+	c.expect(int64(3), "EXISTS", "a", "b", "a", "missing")
+	c.expect(int64(2), "INCR", "a")
+	c.expect(int64(1), "INCR", "counter")
+	c.expect(errors.New("ERR value is not an integer"), "INCR", "mykey")
+	c.expect(int64(2), "DEL", "a", "b", "missing")
+	c.expect(int64(0), "EXISTS", "a", "b")
+
+	// expiration
+	c.expect(int64(-2), "TTL", "missing")
+	c.expect(int64(-1), "TTL", "mykey")
+	c.expect(int64(0), "EXPIRE", "missing", "10")
+	c.expect(int64(1), "EXPIRE", "mykey", "100")
+	c.expect(int64(100), "TTL", "mykey")
+	c.expect(int64(2), "INCR", "counter")
+	c.expect(int64(100), "TTL", "mykey")
+	c.expect("OK", "SET", "mykey", "myvalue")
+	c.expect(int64(-1), "TTL", "mykey")
+	c.expect("OK", "SET", "short", "lived", "PX", "50")
+	c.expect("lived", "GET", "short")
+	time.Sleep(100 * time.Millisecond)
+	c.expect(nil, "GET", "short")
+	c.expect(int64(0), "DEL", "short")
+	c.expect(int64(1), "EXPIRE", "counter", "0")
+	c.expect(nil, "GET", "counter")
+
+	// transactions
+	c.expect("OK", "MULTI")
+	c.expect("QUEUED", "SET", "x", "1")
+	c.expect("QUEUED", "INCR", "x")
+	c.expect("QUEUED", "GET", "x")
+	c.expect([]interface{}{"OK", int64(2), "2"}, "EXEC")
+
+	c.expect("OK", "MULTI")
+	c.expect("QUEUED", "SET", "x", "10")
+	c.expect("QUEUED", "INCR", "mykey")
+	c.expect(errors.New("EXECABORT Transaction rolled back because of: ERR value is not an integer"), "EXEC")
+	c.expect("2", "GET", "x")
+
+	c.expect("OK", "MULTI")
+	c.expect(errors.New("ERR wrong number of arguments"), "SET", "x")
+	c.expect(errors.New("EXECABORT"), "EXEC")
+	c.expect("OK", "MULTI")
+	c.expect("QUEUED", "SET", "x", "10")
+	c.expect("OK", "DISCARD")
+	c.expect("2", "GET", "x")
+	c.expect(errors.New("ERR EXEC without MULTI"), "EXEC")
+
+	// scan
+	for i := 0; i < 25; i++ {
+		c.expect("OK", "SET", fmt.Sprintf("user:%02d", i), "v")
+	}
+	c.expect("OK", "SET", "user:expired", "v", "PX", "1")
+	time.Sleep(10 * time.Millisecond)
+	scan := func(args ...string) []string {
+		var keys []string
+		cursor := "0"
+		for {
+			reply := c.do(append([]string{"SCAN", cursor}, args...)...).([]interface{})
+			for _, key := range reply[1].([]interface{}) {
+				keys = append(keys, key.(string))
+			}
+			if cursor = reply[0].(string); cursor == "0" {
+				break
+			}
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	if keys := scan("MATCH", "user:*", "COUNT", "7"); len(keys) != 25 || keys[0] != "user:00" || keys[24] != "user:24" {
+		t.Fatal("wrong keys", keys)
+	}
+	if keys := scan("MATCH", "user:1?"); !reflect.DeepEqual(keys, []string{"user:10", "user:11", "user:12", "user:13", "user:14", "user:15", "user:16", "user:17", "user:18", "user:19"}) {
+		t.Fatal("wrong keys", keys)
+	}
+	if keys := scan("MATCH", "*[xy]"); !reflect.DeepEqual(keys, []string{"mykey", "x"}) {
+		t.Fatal("wrong keys", keys)
+	}
+
+	// inline commands
+	if _, err := io.WriteString(c.conn, "GET x\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := c.read(); err != nil || reply != "2" {
+		t.Fatal("wrong inline reply", reply, err)
+	}
+
+	c.expect("OK", "QUIT")
+	if _, err := c.read(); err == nil {
+		t.Fatal("connection should be closed")
+	}
+}
+
+func TestRESPConcurrentIncr(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	addr := listenRESP(t, srv)
+	const clients, incrs = 8, 500
+	errs := make(chan error, clients)
+	for i := 0; i < clients; i++ {
+		c := dialRESPAddr(t, addr)
+		go func() {
+			for j := 0; j < incrs; j++ {
+				if _, err := io.WriteString(c.conn, "*2\r\n$4\r\nINCR\r\n$7\r\ncounter\r\n"); err != nil {
+					errs <- err
+					return
+				}
+				reply, err := c.read()
+				if err == nil {
+					if e, ok := reply.(error); ok {
+						err = e
+					}
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < clients; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	dialRESPAddr(t, addr).expect(strconv.Itoa(clients*incrs), "GET", "counter")
+}
+
+func TestRESPRequestSize(t *testing.T) {
+	srv := server.NewServer(tempDir(t), server.WithMaxMessageSize(1024, 0))
+	c := dialRESP(t, srv)
+	c.expect("OK", "MSET", "a", strings.Repeat("a", 500), "b", strings.Repeat("b", 500))
+
+	// the arguments are each below the limit, but not together
+	c.expect(errors.New("ERR Protocol error: too big request"), "MSET", "a", strings.Repeat("a", 600), "b", strings.Repeat("b", 600))
+}
+
+func TestRESPShutdown(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	addr := listenRESP(t, srv)
+
+	// commands being handled while the server shuts down fail without taking the server down
+	const clients = 4
+	done := make(chan struct{}, clients)
+	for i := 0; i < clients; i++ {
+		c := dialRESPAddr(t, addr)
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				if _, err := io.WriteString(c.conn, "*2\r\n$4\r\nINCR\r\n$7\r\ncounter\r\n"); err != nil {
+					return
+				}
+				reply, err := c.read()
+				if _, failed := reply.(error); err != nil || failed {
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	srv.Shutdown(context.Background())
+	for i := 0; i < clients; i++ {
+		<-done
+	}
+}
+
+func TestRESPAuthentication(t *testing.T) {
+	dir := tempDir(t)
+	tokenFile := filepath.Join(dir, "tokens")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret alice\nother bob\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := server.LoadTokenFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acl := &server.ACL{Rules: []server.ACLRule{{Identity: "alice", Permission: server.Admin}, {Identity: "bob", Permission: server.Read}}}
+	srv := server.NewServer(filepath.Join(dir, "databases"), server.WithAuthenticators(tokens), server.WithACL(acl),
+		server.WithRESP("", "cache", "items"))
+	c := dialRESP(t, srv)
+
+	c.expect("PONG", "PING")
+	c.expect(errors.New("NOAUTH"), "GET", "mykey")
+	c.expect(errors.New("WRONGPASS"), "AUTH", "wrong")
+	c.expect("OK", "AUTH", "alice", "secret")
+	c.expect("OK", "SET", "mykey", "myvalue")
+
+	c.expect("OK", "AUTH", "other")
+	c.expect("myvalue", "GET", "mykey")
+	c.expect(errors.New("NOPERM"), "SET", "mykey", "other")
+
+	if _, err := os.Stat(filepath.Join(dir, "databases", "cache")); err != nil {
+		t.Fatal("database should be created", err)
+	}
+
+	// shutdown closes the connection
+	srv.Shutdown(context.Background())
+	if _, err := c.read(); err == nil {
+		t.Fatal("connection should be closed")
+	}
+}
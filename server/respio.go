@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/robaho/keydbr"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// the maximum length of an inline command or of the header lines of a command
+const respMaxLine = 64 * 1024
+
+// the maximum number of arguments of a command
+const respMaxArgs = 1024 * 1024
+
+// respReader reads commands in the Redis serialization protocol, sent as an array of bulk strings or inline
+type respReader struct {
+	*bufio.Reader
+	maxSize int // maximum size of the arguments of a command
+}
+
+func newRESPReader(r io.Reader, maxSize int) *respReader {
+	return &respReader{Reader: bufio.NewReaderSize(r, respMaxLine), maxSize: maxSize}
+}
+
+// respProtocolError is an invalid command, after which the connection is closed
+type respProtocolError string
+
+func (e respProtocolError) Error() string {
+	return "ERR Protocol error: " + string(e)
+}
+
+// readLine returns the next line without the line ending. The line is only valid until the next read.
+func (r *respReader) readLine() ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return nil, respProtocolError("too big request")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line[:len(line)-1], []byte("\r")), nil
+}
+
+// readCommand returns the arguments of the next command, which are empty for a blank line
+func (r *respReader) readCommand() ([][]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		var args [][]byte
+		for _, field := range bytes.Fields(line) {
+			args = append(args, append([]byte(nil), field...))
+		}
+		return args, nil
+	}
+
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n > respMaxArgs {
+		return nil, respProtocolError("invalid multibulk length")
+	}
+	var args [][]byte
+	total := 0
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, respProtocolError("expected '$'")
+		}
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size < 0 || size > r.maxSize {
+			return nil, respProtocolError("invalid bulk length")
+		}
+		if total += size; total > r.maxSize {
+			return nil, respProtocolError("too big request")
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(arg, []byte("\r\n")) {
+			return nil, respProtocolError("expected CRLF")
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+// respStatus is a simple string reply
+type respStatus string
+
+// respError is an error reply, starting with the error code, e.g. "ERR syntax error"
+type respError string
+
+func (e respError) Error() string {
+	return string(e)
+}
+
+// respErrorString returns the error reply for err, using the error code ERR unless the error has its own
+func respErrorString(err error) string {
+	var re respError
+	var pe respProtocolError
+	var msg string
+	switch {
+	case errors.As(err, &re):
+		msg = string(re)
+	case errors.As(err, &pe):
+		msg = pe.Error()
+	case errors.Is(err, keydbr.PermissionDenied):
+		msg = "NOPERM " + err.Error()
+	case errors.Is(err, keydbr.ReadOnly):
+		msg = "READONLY " + err.Error()
+	default:
+		msg = "ERR " + err.Error()
+	}
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(msg)
+}
+
+// respWriter writes replies in the Redis serialization protocol
+type respWriter struct {
+	*bufio.Writer
+}
+
+// writeReply writes a reply, which is nil for a null bulk string, a respStatus, an error, an integer, a []byte bulk
+// string, or an array of replies
+func (w respWriter) writeReply(reply interface{}) {
+	switch reply := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case respStatus:
+		w.WriteString("+" + string(reply) + "\r\n")
+	case error:
+		w.WriteString("-" + respErrorString(reply) + "\r\n")
+	case int64:
+		w.WriteString(":" + strconv.FormatInt(reply, 10) + "\r\n")
+	case []byte:
+		w.WriteString("$" + strconv.Itoa(len(reply)) + "\r\n")
+		w.Write(reply)
+		w.WriteString("\r\n")
+	case []interface{}:
+		w.WriteString("*" + strconv.Itoa(len(reply)) + "\r\n")
+		for _, r := range reply {
+			w.writeReply(r)
+		}
+	default:
+		panic("invalid reply type")
+	}
+}
+
+// globMatch returns true if s matches the Redis glob pattern, which supports '*', '?', character classes such as
+// [a-z] or [^0-9], and '\' to escape a special character
+func globMatch(pattern, s []byte) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			n, ok := matchClass(pattern, s[0])
+			if !ok {
+				return false
+			}
+			pattern, s = pattern[n:], s[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass matches c against the character class at the start of pattern, returning the length of the class
+func matchClass(pattern []byte, c byte) (int, bool) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+	matched := false
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	if i < len(pattern) {
+		i++ // the closing ']'
+	}
+	return i, matched != negate
+}
+
+// globPrefix returns the literal prefix of a glob pattern, which all matching strings start with
+func globPrefix(pattern []byte) []byte {
+	var prefix []byte
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return prefix
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix = append(prefix, pattern[i])
+	}
+	return prefix
+}
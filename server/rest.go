@@ -0,0 +1,405 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultScanLimit is the number of entries returned by a REST scan which does not set a limit
+const DefaultScanLimit = 1000
+
+// the maximum size of a request if the maximum message size is not configured, the grpc default
+const defaultMaxRequestSize = 4 << 20
+
+// RESTHandler returns the handler of the REST gateway, which runs each request in a session like those of
+// Connection. The resources are
+//
+//	GET    /v1/databases                                 list the databases
+//	DELETE /v1/databases/{db}                            remove a database
+//	GET    /v1/databases/{db}/tables                     list the tables
+//	GET    /v1/databases/{db}/tables/{table}/keys        scan the keys, with lower, upper, prefix and limit
+//	GET    /v1/databases/{db}/tables/{table}/keys/{key}  get a value
+//	PUT    /v1/databases/{db}/tables/{table}/keys/{key}  put a value
+//	DELETE /v1/databases/{db}/tables/{table}/keys/{key}  delete a key
+//	POST   /v1/databases/{db}/tables/{table}/batch       run a batch of operations in one transaction
+//
+// Database names containing '/' and binary keys are percent-encoded. Values are raw bytes, unless the request
+// has or accepts the application/json content type, in which case keys and values are base64 encoded in JSON.
+// Requests on a table open the database, creating it if the create query parameter is true, and run in their own
+// transaction, which is committed synchronously. The routes are ServeMux patterns with methods and wildcards, which
+// require the module to be built as Go 1.22 or later, as go.mod declares.
+func (s *Server) RESTHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/databases", s.restList)
+	mux.HandleFunc("DELETE /v1/databases/{db}", s.restRemove)
+	mux.HandleFunc("GET /v1/databases/{db}/tables", s.restTables)
+	mux.HandleFunc("GET /v1/databases/{db}/tables/{table}/keys", s.restScan)
+	mux.HandleFunc("GET /v1/databases/{db}/tables/{table}/keys/{key}", s.restGet)
+	mux.HandleFunc("PUT /v1/databases/{db}/tables/{table}/keys/{key}", s.restPut)
+	mux.HandleFunc("DELETE /v1/databases/{db}/tables/{table}/keys/{key}", s.restDelete)
+	mux.HandleFunc("POST /v1/databases/{db}/tables/{table}/batch", s.restBatch)
+
+	auth := s.authenticators()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := restContext(r, auth)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, restErrorReply{Error: status.Convert(err).Message()})
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// restContext returns the context of a request with the identity of the caller, authenticating the authorization
+// header and client certificate as if they were the grpc metadata and peer
+func restContext(r *http.Request, auth Authenticators) (context.Context, error) {
+	p := &peer.Peer{Addr: remoteAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	ctx := peer.NewContext(r.Context(), p)
+	if header := r.Header.Get("Authorization"); header != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", header))
+	}
+	if len(auth) == 0 {
+		return ctx, nil
+	}
+	return authenticate(auth, ctx)
+}
+
+// remoteAddr is the address of an http client
+type remoteAddr string
+
+func (a remoteAddr) Network() string { return "tcp" }
+func (a remoteAddr) String() string  { return string(a) }
+
+// restEntry is a key and value, base64 encoded in JSON
+type restEntry struct {
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+}
+
+// restOperation is an operation of a batch, one of get, put or delete
+type restOperation struct {
+	Op    string `json:"op"`
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type restBatchRequest struct {
+	Operations []restOperation `json:"operations"`
+}
+
+type restBatchReply struct {
+	// Results holds the value of each get, and the previous value of each delete
+	Results []restEntry `json:"results"`
+}
+
+type restScanReply struct {
+	Entries []restEntry `json:"entries"`
+	// Truncated is set if there are more entries in the range than the limit
+	Truncated bool `json:"truncated"`
+}
+
+type restErrorReply struct {
+	Error string `json:"error"`
+}
+
+// restStatusError is an error in a request, with the http status of the response
+type restStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *restStatusError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &restStatusError{status: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+// restStatus returns the http status of a failed request
+func restStatus(err error) int {
+	var statusErr *restStatusError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.status
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, keydbr.PermissionDenied), errors.Is(err, keydbr.ReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, keydbr.InvalidDatabaseName), errors.Is(err, keydbr.InvalidTableName):
+		return http.StatusBadRequest
+	case errors.Is(err, keydbr.QuotaExceeded):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, keydbr.ResourceExhausted):
+		return http.StatusTooManyRequests
+	case errors.Is(err, keydbr.ShuttingDown), errors.Is(err, keydbr.NotLeader):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, keydb.KeyNotFound), errors.Is(err, keydb.NoDatabaseFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, restStatus(err), restErrorReply{Error: err.Error()})
+}
+
+// isJSON returns true if the request body is JSON
+func isJSON(r *http.Request) bool {
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediatype == "application/json"
+}
+
+// acceptsJSON returns true if the response should be JSON rather than raw bytes
+func acceptsJSON(r *http.Request) bool {
+	return isJSON(r) || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// readBody reads the request body, limited to the maximum message size received
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	return io.ReadAll(http.MaxBytesReader(w, r.Body, int64(s.maxRequestSize())))
+}
+
+// maxRequestSize returns the maximum size of a request received by the REST and RESP frontends
+func (s *Server) maxRequestSize() int {
+	if s.maxRecvMsgSize > 0 {
+		return s.maxRecvMsgSize
+	}
+	return defaultMaxRequestSize
+}
+
+func (s *Server) restList(w http.ResponseWriter, r *http.Request) {
+	reply, _ := s.List(r.Context(), &pb.ListRequest{})
+	if reply.Error != "" {
+		writeError(w, parseReplyError(reply.Error))
+		return
+	}
+	dbnames := reply.Dbnames
+	if dbnames == nil {
+		dbnames = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"databases": dbnames})
+}
+
+func (s *Server) restRemove(w http.ResponseWriter, r *http.Request) {
+	reply, _ := s.Remove(r.Context(), &pb.RemoveRequest{Dbname: r.PathValue("db")})
+	if reply.Error != "" {
+		writeError(w, parseReplyError(reply.Error))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) restTables(w http.ResponseWriter, r *http.Request) {
+	reply, _ := s.ListTables(r.Context(), &pb.ListTablesRequest{Dbname: r.PathValue("db")})
+	if reply.Error != "" {
+		writeError(w, parseReplyError(reply.Error))
+		return
+	}
+	tables := reply.Tables
+	if tables == nil {
+		tables = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"tables": tables})
+}
+
+// restTx runs fn in a transaction on the table of the request, in a new session. The transaction is committed if
+// fn succeeds and write is set, and is otherwise rolled back. It returns false if the request failed, in which case
+// the error has been written.
+func (s *Server) restTx(w http.ResponseWriter, r *http.Request, write bool, fn func(ls *localSession, txid uint64) error) bool {
+	if s.shuttingDown() {
+		writeError(w, keydbr.ShuttingDown)
+		return false
+	}
+	create := false
+	if v := r.URL.Query().Get("create"); v != "" {
+		var err error
+		if create, err = strconv.ParseBool(v); err != nil {
+			writeError(w, badRequest("invalid create %q", v))
+			return false
+		}
+	}
+
+	ls := s.newLocalSession(r.Context(), "rest")
+	defer ls.end()
+
+	var txid uint64
+	err := ls.open(r.PathValue("db"), create)
+	if err == nil {
+		txid, err = ls.begin(r.PathValue("table"))
+	}
+	if err == nil {
+		err = fn(ls, txid)
+		if err == nil && write {
+			err = ls.commit(txid)
+		}
+		if err != nil || !write {
+			ls.rollback(txid)
+		}
+	}
+	if err != nil {
+		writeError(w, err)
+		return false
+	}
+	return true
+}
+
+func (s *Server) restGet(w http.ResponseWriter, r *http.Request) {
+	key := []byte(r.PathValue("key"))
+	var value []byte
+	ok := s.restTx(w, r, false, func(ls *localSession, txid uint64) (err error) {
+		value, err = ls.get(txid, key)
+		return err
+	})
+	if !ok {
+		return
+	}
+	if acceptsJSON(r) {
+		writeJSON(w, http.StatusOK, restEntry{Key: key, Value: value})
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+func (s *Server) restPut(w http.ResponseWriter, r *http.Request) {
+	key := []byte(r.PathValue("key"))
+	value, err := s.readBody(w, r)
+	if err == nil && isJSON(r) {
+		var entry restEntry
+		if err = json.Unmarshal(value, &entry); err != nil {
+			err = badRequest("invalid JSON value: %v", err)
+		}
+		value = entry.Value
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if s.restTx(w, r, true, func(ls *localSession, txid uint64) error { return ls.put(txid, key, value) }) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) restDelete(w http.ResponseWriter, r *http.Request) {
+	key := []byte(r.PathValue("key"))
+	ok := s.restTx(w, r, true, func(ls *localSession, txid uint64) error {
+		_, err := ls.delete(txid, key)
+		return err
+	})
+	if ok {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// restScan returns the entries from lower to upper inclusive, or having prefix, up to limit entries
+func (s *Server) restScan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var lower, upper, prefix []byte
+	if query.Has("lower") {
+		lower = []byte(query.Get("lower"))
+	}
+	if query.Has("upper") {
+		upper = []byte(query.Get("upper"))
+	}
+	if query.Has("prefix") {
+		prefix = []byte(query.Get("prefix"))
+		lower = prefix
+	}
+	limit := DefaultScanLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, badRequest("invalid limit %q", v))
+			return
+		}
+		limit = n
+	}
+
+	reply := restScanReply{Entries: []restEntry{}}
+	ok := s.restTx(w, r, false, func(ls *localSession, txid uint64) error {
+		return ls.each(txid, lower, upper, func(key, value []byte) bool {
+			if prefix != nil && !bytes.HasPrefix(key, prefix) {
+				return false
+			}
+			if len(reply.Entries) == limit {
+				reply.Truncated = true
+				return false
+			}
+			reply.Entries = append(reply.Entries, restEntry{Key: key, Value: value})
+			return true
+		})
+	})
+	if ok {
+		writeJSON(w, http.StatusOK, reply)
+	}
+}
+
+// restBatch runs the operations in a single transaction, which is rolled back if any of them fails
+func (s *Server) restBatch(w http.ResponseWriter, r *http.Request) {
+	body, err := s.readBody(w, r)
+	var batch restBatchRequest
+	if err == nil {
+		if err = json.Unmarshal(body, &batch); err != nil {
+			err = badRequest("invalid JSON batch: %v", err)
+		}
+	}
+	for i, op := range batch.Operations {
+		if err == nil && op.Op != "get" && op.Op != "put" && op.Op != "delete" {
+			err = badRequest("operation %d: invalid op %q", i, op.Op)
+		}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	reply := restBatchReply{Results: make([]restEntry, len(batch.Operations))}
+	ok := s.restTx(w, r, true, func(ls *localSession, txid uint64) error {
+		for i, op := range batch.Operations {
+			var value []byte
+			var err error
+			switch op.Op {
+			case "get":
+				value, err = ls.get(txid, op.Key)
+			case "put":
+				err = ls.put(txid, op.Key, op.Value)
+			case "delete":
+				value, err = ls.delete(txid, op.Key)
+			}
+			if err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+			reply.Results[i] = restEntry{Key: op.Key, Value: value}
+		}
+		return nil
+	})
+	if ok {
+		writeJSON(w, http.StatusOK, reply)
+	}
+}
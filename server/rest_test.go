@@ -0,0 +1,187 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robaho/keydbr/server"
+)
+
+// restCall makes a REST request, returning the status and body of the response
+func restCall(t *testing.T, method, url, contentType, body string, header ...string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestREST(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	hs := httptest.NewServer(srv.RESTHandler())
+	defer hs.Close()
+
+	keys := hs.URL + "/v1/databases/test%2Fmain/tables/users/keys/"
+
+	if code, _ := restCall(t, "PUT", keys+"alice", "", "missing database"); code != http.StatusNotFound {
+		t.Fatal("put without create should fail", code)
+	}
+	if code, body := restCall(t, "PUT", keys+"alice?create=true", "", "alice's value"); code != http.StatusNoContent {
+		t.Fatal("put failed", code, body)
+	}
+	if code, body := restCall(t, "PUT", keys+"bob", "application/json", `{"value":"Ym9iJ3MgdmFsdWU="}`); code != http.StatusNoContent {
+		t.Fatal("put json failed", code, body)
+	}
+	if code, body := restCall(t, "GET", keys+"alice", "", ""); code != http.StatusOK || body != "alice's value" {
+		t.Fatal("wrong value", code, body)
+	}
+	if code, body := restCall(t, "GET", keys+"bob", "", "", "Accept", "application/json"); code != http.StatusOK || body != `{"key":"Ym9i","value":"Ym9iJ3MgdmFsdWU="}`+"\n" {
+		t.Fatal("wrong json value", code, body)
+	}
+	if code, body := restCall(t, "GET", keys+"carol", "", ""); code != http.StatusNotFound || !strings.Contains(body, `"error":"key not found"`) {
+		t.Fatal("missing key should not be found", code, body)
+	}
+	if code, _ := restCall(t, "GET", hs.URL+"/v1/databases/test%2Fmain/tables/.bad/keys/alice", "", ""); code != http.StatusBadRequest {
+		t.Fatal("invalid table name should be rejected", code)
+	}
+
+	if code, body := restCall(t, "GET", hs.URL+"/v1/databases", "", ""); code != http.StatusOK || body != `{"databases":["test/main"]}`+"\n" {
+		t.Fatal("wrong databases", code, body)
+	}
+	if code, body := restCall(t, "GET", hs.URL+"/v1/databases/test%2Fmain/tables", "", ""); code != http.StatusOK || body != `{"tables":["users"]}`+"\n" {
+		t.Fatal("wrong tables", code, body)
+	}
+	if code, _ := restCall(t, "POST", hs.URL+"/v1/databases", "", ""); code != http.StatusMethodNotAllowed {
+		t.Fatal("routes should match the method", code)
+	}
+
+	// the batch is rolled back if any operation fails
+	batch := hs.URL + "/v1/databases/test%2Fmain/tables/users/batch"
+	code, body := restCall(t, "POST", batch, "application/json",
+		`{"operations":[{"op":"put","key":"Y2Fyb2w=","value":"Yw=="},{"op":"delete","key":"ZGF2ZQ=="}]}`)
+	if code != http.StatusNotFound || !strings.Contains(body, "operation 1: key not found") {
+		t.Fatal("batch should fail", code, body)
+	}
+	if code, _ := restCall(t, "GET", keys+"carol", "", ""); code != http.StatusNotFound {
+		t.Fatal("failed batch should be rolled back", code)
+	}
+	code, body = restCall(t, "POST", batch, "application/json",
+		`{"operations":[{"op":"put","key":"Y2Fyb2w=","value":"Yw=="},{"op":"delete","key":"Ym9i"},{"op":"get","key":"YWxpY2U="}]}`)
+	if code != http.StatusOK {
+		t.Fatal("batch failed", code, body)
+	}
+	var results struct {
+		Results []struct{ Key, Value []byte }
+	}
+	if err := json.Unmarshal([]byte(body), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Results) != 3 || string(results.Results[1].Value) != "bob's value" || string(results.Results[2].Value) != "alice's value" {
+		t.Fatal("wrong batch results", body)
+	}
+
+	for _, key := range []string{"a1", "a2", "a3", "b1"} {
+		if code, body := restCall(t, "PUT", keys+key, "", key); code != http.StatusNoContent {
+			t.Fatal("put failed", code, body)
+		}
+	}
+	scan := func(query string) ([]string, bool) {
+		code, body := restCall(t, "GET", strings.TrimSuffix(keys, "/")+query, "", "")
+		if code != http.StatusOK {
+			t.Fatal("scan failed", code, body)
+		}
+		var reply struct {
+			Entries   []struct{ Key, Value []byte }
+			Truncated bool
+		}
+		if err := json.Unmarshal([]byte(body), &reply); err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, e := range reply.Entries {
+			keys = append(keys, string(e.Key))
+		}
+		return keys, reply.Truncated
+	}
+	if keys, truncated := scan(""); !reflect.DeepEqual(keys, []string{"a1", "a2", "a3", "alice", "b1", "carol"}) || truncated {
+		t.Fatal("wrong scan", keys, truncated)
+	}
+	if keys, truncated := scan("?prefix=a&limit=2"); !reflect.DeepEqual(keys, []string{"a1", "a2"}) || !truncated {
+		t.Fatal("wrong prefix scan", keys, truncated)
+	}
+	if keys, truncated := scan("?lower=a3&upper=b1"); !reflect.DeepEqual(keys, []string{"a3", "alice", "b1"}) || truncated {
+		t.Fatal("wrong range scan", keys, truncated)
+	}
+	if code, _ := restCall(t, "GET", strings.TrimSuffix(keys, "/")+"?limit=x", "", ""); code != http.StatusBadRequest {
+		t.Fatal("invalid limit should be rejected", code)
+	}
+
+	if code, body := restCall(t, "DELETE", keys+"alice", "", ""); code != http.StatusNoContent {
+		t.Fatal("delete failed", code, body)
+	}
+	if code, _ := restCall(t, "DELETE", keys+"alice", "", ""); code != http.StatusNotFound {
+		t.Fatal("deleted key should not be found", code)
+	}
+	if code, body := restCall(t, "DELETE", hs.URL+"/v1/databases/test%2Fmain", "", ""); code != http.StatusNoContent {
+		t.Fatal("remove failed", code, body)
+	}
+}
+
+func TestRESTAuthentication(t *testing.T) {
+	dir := tempDir(t)
+	tokenFile := filepath.Join(dir, "tokens")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret alice\nother bob\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := server.LoadTokenFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acl := &server.ACL{Rules: []server.ACLRule{{Identity: "alice", Permission: server.Admin}}}
+	srv := server.NewServer(filepath.Join(dir, "databases"), server.WithAuthenticators(tokens), server.WithACL(acl))
+	hs := httptest.NewServer(srv.RESTHandler())
+	defer hs.Close()
+
+	url := hs.URL + "/v1/databases/main/tables/main/keys/mykey?create=true"
+	if code, _ := restCall(t, "PUT", url, "", "myvalue"); code != http.StatusUnauthorized {
+		t.Fatal("missing token should be rejected", code)
+	}
+	if code, _ := restCall(t, "PUT", url, "", "myvalue", "Authorization", "Bearer wrong"); code != http.StatusUnauthorized {
+		t.Fatal("invalid token should be rejected", code)
+	}
+	if code, _ := restCall(t, "PUT", url, "", "myvalue", "Authorization", "Bearer other"); code != http.StatusForbidden {
+		t.Fatal("put should be denied", code)
+	}
+	if code, body := restCall(t, "PUT", url, "", "myvalue", "Authorization", "Bearer secret"); code != http.StatusNoContent {
+		t.Fatal("put failed", code, body)
+	}
+
+	srv.Shutdown(context.Background())
+	if code, _ := restCall(t, "GET", url, "", "", "Authorization", "Bearer secret"); code != http.StatusServiceUnavailable {
+		t.Fatal("requests should fail while shutting down", code)
+	}
+}
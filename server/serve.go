@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // replies to compressed requests are compressed with the same compressor
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"net"
+	"net/http"
+)
+
+// NewGRPCServer returns a grpc server configured by the server options, with the keydbr, health and reflection
+// services registered. The opts are applied before the configured options, so interceptors in opts are called
+// first.
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) (*grpc.Server, error) {
+	config, err := s.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config)))
+	}
+	if auth := s.authenticators(); len(auth) > 0 {
+		opts = append(opts, grpc.ChainStreamInterceptor(AuthStreamInterceptor(auth)), grpc.ChainUnaryInterceptor(AuthUnaryInterceptor(auth)))
+	}
+	if s.maxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(s.maxRecvMsgSize))
+	}
+	if s.maxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(s.maxSendMsgSize))
+	}
+
+	if s.Health == nil {
+		s.Health = health.NewServer()
+		s.SetServing(false)
+	}
+
+	gs := grpc.NewServer(opts...)
+	pb.RegisterKeydbServer(gs, s)
+	healthpb.RegisterHealthServer(gs, s.Health)
+	reflection.Register(gs)
+	return gs, nil
+}
+
+// tlsConfig returns the TLS configuration of the grpc and REST servers, or nil if TLS is not configured. The
+// certificates are loaded once, and reloaded when they change.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	if s.tlsFiles == nil {
+		return nil, nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.reloader == nil {
+		reloader, err := NewCertificateReloader(*s.tlsFiles)
+		if err != nil {
+			return nil, err
+		}
+		s.reloader = reloader
+	}
+	return s.reloader.TLSConfig(), nil
+}
+
+// authenticators returns the configured authenticators, followed by the client certificate if mutual TLS is enabled
+func (s *Server) authenticators() Authenticators {
+	auth := s.auth
+	if s.tlsFiles != nil && s.tlsFiles.ClientCAFile != "" {
+		auth = append(auth[:len(auth):len(auth)], TLSAuthenticator{})
+	}
+	return auth
+}
+
+// ListenAndServe serves the databases on the configured listener or address, and the metrics, REST gateway and
+// RESP frontend if configured.
+// It returns once the server is stopped, see Stop.
+func (s *Server) ListenAndServe(opts ...grpc.ServerOption) error {
+	gs, err := s.NewGRPCServer(opts...)
+	if err != nil {
+		return err
+	}
+	if err := s.StartCluster(); err != nil {
+		return err
+	}
+
+	lis := s.listener
+	if lis == nil {
+		lis, err = net.Listen("tcp", s.addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	var hs *http.Server
+	if s.metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", s.MetricsHandler())
+		hs = &http.Server{Addr: s.metricsAddr, Handler: mux}
+		go func() {
+			if err := hs.ListenAndServe(); err != http.ErrServerClosed {
+				s.logger.Error("unable to serve metrics", "address", s.metricsAddr, "error", err)
+			}
+		}()
+		s.logger.Info("serving metrics", "address", s.metricsAddr)
+	}
+
+	var rs *http.Server
+	if s.restAddr != "" {
+		config, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		rs = &http.Server{Addr: s.restAddr, Handler: s.RESTHandler(), TLSConfig: config}
+		go func() {
+			var err error
+			if config != nil {
+				err = rs.ListenAndServeTLS("", "")
+			} else {
+				err = rs.ListenAndServe()
+			}
+			if err != http.ErrServerClosed {
+				s.logger.Error("unable to serve REST gateway", "address", s.restAddr, "error", err)
+			}
+		}()
+		s.logger.Info("serving REST gateway", "address", s.restAddr, "tls", config != nil)
+	}
+
+	var rl net.Listener
+	if s.respAddr != "" {
+		rl, err = net.Listen("tcp", s.respAddr)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := s.ServeRESP(rl); !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("unable to serve RESP", "address", s.respAddr, "error", err)
+			}
+		}()
+		s.logger.Info("serving RESP", "address", rl.Addr().String(), "database", s.respDatabase, "table", s.respTable)
+	}
+
+	s.Lock()
+	s.grpcServer = gs
+	s.httpServer = hs
+	s.restServer = rs
+	s.respListener = rl
+	s.Unlock()
+
+	s.logger.Info("listening", "address", lis.Addr().String())
+	s.SetServing(true)
+	s.StartReplication()
+	return gs.Serve(lis)
+}
+
+// Stop stops a server started by ListenAndServe. It stops accepting connections, and gives the open transactions
+// the grace period to complete before shutting down, see Shutdown.
+func (s *Server) Stop() ShutdownSummary {
+	s.Lock()
+	gs, hs, rs, rl := s.grpcServer, s.httpServer, s.restServer, s.respListener
+	s.Unlock()
+
+	if rl != nil {
+		// the RESP connections are closed with their sessions by Shutdown
+		rl.Close()
+	}
+
+	if gs != nil {
+		go gs.GracefulStop()
+	}
+	if rs != nil {
+		// stop accepting requests, the requests in progress complete or are rolled back by Shutdown
+		go rs.Shutdown(context.Background())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.grace)
+	defer cancel()
+	summary := s.Shutdown(ctx)
+	if gs != nil {
+		gs.Stop()
+	}
+	if hs != nil {
+		hs.Close()
+	}
+	if rs != nil {
+		rs.Close()
+	}
+	return summary
+}
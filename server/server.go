@@ -4,38 +4,200 @@ import (
 	"context"
 	"errors"
 	"github.com/robaho/keydb"
+	"github.com/robaho/keydbr"
 	pb "github.com/robaho/keydbr/internal/proto"
-	"log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"io/ioutil"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type openDatabase struct {
 	refcount int
 	db       *keydb.Database
 	fullpath string
+	name     string
+	// resp serializes the RESP commands which write, since they read before writing and keydb does not detect
+	// conflicting writes, see respConn.run
+	resp sync.Mutex
+	// begun are the tables which transactions have begun on since the database was opened, which keydb may not
+	// have written to disk yet, see tables
+	mu    sync.Mutex
+	begun map[string]bool
+	// removed is set, holding the server's lock, if the cluster removed the database while it was open, which
+	// deletes it once it is no longer open. generation counts these removals, so sessions which opened the
+	// database before a removal fail even if it is created again, and is guarded by mu.
+	removed    bool
+	generation int
+}
+
+// check returns NoDatabaseFound if the cluster has removed the database since it was opened at generation
+func (db *openDatabase) check(generation int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.generation != generation {
+		return keydb.NoDatabaseFound
+	}
+	return nil
+}
+
+// beginTX begins a transaction on table, recording the table
+func (db *openDatabase) beginTX(table string) (*keydb.Transaction, error) {
+	tx, err := db.db.BeginTX(table)
+	if err == nil {
+		db.mu.Lock()
+		db.begun[table] = true
+		db.mu.Unlock()
+	}
+	return tx, err
+}
+
+// tables returns the tables on disk and the tables begun since the database was opened, so a table is listed
+// before keydb writes it to disk
+func (db *openDatabase) tables() ([]string, error) {
+	tables, err := listTables(db.fullpath)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, table := range tables {
+		found[table] = true
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for table := range db.begun {
+		if !found[table] {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
 }
 
 type transaction struct {
 	*keydb.Transaction
-	asyncfailure bool
+	asyncfailure error // the first asynchronous put failure, the transaction can only be rolled back
+	table        string
+	perm         Permission
+	bytes        int64 // size of the keys and values put
+	puts         int
+	used         time.Time
+	// log is the replication log of the database, which receives the writes of the transaction when committed
+	log *replicationLog
+	// dbname is set on a cluster member, which commits the writes through the cluster, see commitCluster
+	dbname    string
+	mutations []*pb.Mutation
+}
+
+type iterator struct {
+	keydb.LookupIterator
+	txid uint64
+	used time.Time
 }
 
 type connstate struct {
-	db   *openDatabase
-	txs  map[uint64]*transaction
-	itrs map[uint64]keydb.LookupIterator
-	next uint64 // next iterator id
+	sync.Mutex
+	ntxs     int64 // number of open transactions and iterators, see updateCounts
+	nitrs    int64
+	id       uint64
+	identity *Identity
+	dbname   string
+	ns       *Namespace
+	db       *openDatabase
+	dbgen    int // the generation of db when it was opened
+	txs      map[uint64]*transaction
+	itrs     map[uint64]*iterator
+	next     uint64 // next iterator id
+	inflight int64  // size of the keys and values put in open transactions
+	// ids of the transactions and iterators expired by the reaper
+	expired     map[uint64]bool
+	expiredItrs map[uint64]bool
+	// closed is the reason the session was closed by Shutdown or KillSession, and done is closed with it
+	closed error
+	done   chan struct{}
+	peer   string
+	opened time.Time
+	logger *slog.Logger
 }
 
 type Server struct {
+	closing int32 // set by Shutdown, must be first for atomic alignment
 	sync.Mutex
 	path   string
 	opendb map[string]*openDatabase
+	// ACL restricts access to databases and tables. If nil, all callers have full access.
+	ACL *ACL
+	// Namespaces places the databases of each caller in a subdirectory. If nil, all callers share the server path.
+	Namespaces *Namespaces
+	// Limits restricts the resources used by each connection and identity
+	Limits  Limits
+	limiter rateLimiter
+	// TransactionTimeout and IteratorTimeout roll back transactions and close iterators which are idle
+	// for longer than the timeout. Zero disables the timeout.
+	TransactionTimeout time.Duration
+	IteratorTimeout    time.Duration
+	sessions           sessions
+	reaperOnce         sync.Once
+	stopReaperOnce     sync.Once
+	stopReaper         chan struct{} // closed by Shutdown to stop the reaper
+	metrics            *metrics
+	// Health if set receives the serving status of the server and of each database
+	Health  *health.Server
+	logger  *slog.Logger
+	logKeys bool
+	// requests taking longer than slowThreshold are recorded in the slow operation log
+	slowThreshold time.Duration
+	slowLogSize   int
+	slowlog       slowLog
+
+	// used by ListenAndServe, see the options
+	addr           string
+	listener       net.Listener
+	tlsFiles       *TLSFiles
+	reloader       *CertificateReloader
+	auth           Authenticators
+	maxRecvMsgSize int
+	maxSendMsgSize int
+	metricsAddr    string
+	restAddr       string
+	respAddr       string
+	respDatabase   string
+	respTable      string
+	respListener   net.Listener
+	grace          time.Duration
+	grpcServer     *grpc.Server
+	httpServer     *http.Server
+	restServer     *http.Server
+
+	// replication, see replication.go
+	replicationLogSize int
+	replogs            map[string]*replicationLog // by database path
+	replica            *replica
+
+	// cluster membership, see cluster.go
+	cluster *cluster
 }
 
-func NewServer(dbpath string) *Server {
-	s := Server{path: dbpath, opendb: make(map[string]*openDatabase)}
+// NewServer returns a server for the databases in directory dbpath, configured by opts
+func NewServer(dbpath string, opts ...Option) *Server {
+	s := Server{path: dbpath, opendb: make(map[string]*openDatabase), replogs: make(map[string]*replicationLog), metrics: newMetrics(),
+		stopReaper: make(chan struct{})}
+	s.logger = slog.Default()
+	s.addr = DefaultAddress
+	s.grace = DefaultGracePeriod
+	s.respDatabase = DefaultRESPDatabase
+	s.respTable = DefaultRESPTable
+	for _, opt := range opts {
+		opt(&s)
+	}
 	return &s
 }
 
@@ -43,61 +205,262 @@ func (s *Server) Remove(ctx context.Context, in *pb.RemoveRequest) (*pb.RemoveRe
 	s.Lock()
 	defer s.Unlock()
 
-	log.Println("remove database", in)
-
-	fullpath := filepath.Join(s.path, in.GetDbname())
+	id := IdentityFromContext(ctx)
+	dbname, fullpath, _, err := s.resolve(id, in.GetDbname())
+	if err == nil {
+		err = s.ACL.Check(id, dbname, "", Admin)
+	}
+	if err == nil && s.readOnly() {
+		err = keydbr.ReadOnly
+	}
+	if err == nil && s.cluster != nil {
+		err = s.removeFromCluster(dbname, fullpath)
+	} else if err == nil {
+		err = keydb.Remove(fullpath)
+	}
+	if log, ok := s.replogs[fullpath]; ok && err == nil {
+		log.remove()
+		delete(s.replogs, fullpath)
+	}
 
-	err := keydb.Remove(fullpath)
-	errs := ""
+	log := s.logger.With("database", in.GetDbname())
+	if id != nil {
+		log = log.With("identity", id.Name)
+	}
 	if err != nil {
-		errs = err.Error()
+		log.Warn("unable to remove database", "error", err)
+	} else {
+		log.Info("removed database")
 	}
 
-	reply := &pb.RemoveReply{Error: errs}
+	reply := &pb.RemoveReply{Error: toErrS(err)}
 
 	return reply, nil
 }
 
+// List returns the databases in the caller's namespace which the caller can read
+func (s *Server) List(ctx context.Context, in *pb.ListRequest) (*pb.ListReply, error) {
+	id := IdentityFromContext(ctx)
+
+	ns, err := s.namespace(id)
+	if err != nil {
+		return &pb.ListReply{Error: toErrS(err)}, nil
+	}
+
+	dir, prefix := s.path, ""
+	if ns != nil {
+		dir, prefix = filepath.Join(s.path, ns.Name), ns.Name+"/"
+	}
+
+	s.Lock()
+	dbs, err := s.listDatabases(dir)
+	s.Unlock()
+	if err != nil {
+		return &pb.ListReply{Error: toErrS(err)}, nil
+	}
+
+	var dbnames []string
+	for _, dbname := range dbs {
+		if keydbr.ValidateDatabaseName(dbname) == nil && s.ACL.AnyTable(id, prefix+dbname) >= Read {
+			dbnames = append(dbnames, dbname)
+		}
+	}
+	return &pb.ListReply{Dbnames: dbnames}, nil
+}
+
+// ListTables returns the tables of a database which the caller can read. Tables are listed once keydb has written
+// their data to disk.
+func (s *Server) ListTables(ctx context.Context, in *pb.ListTablesRequest) (*pb.ListTablesReply, error) {
+	id := IdentityFromContext(ctx)
+
+	dbname, fullpath, _, err := s.resolve(id, in.GetDbname())
+	if err == nil && s.ACL.AnyTable(id, dbname) < Read {
+		err = permissionDenied(id, dbname, "", Read)
+	}
+	var all, tables []string
+	if err == nil {
+		all, err = listTables(fullpath)
+	}
+	for _, table := range all {
+		if s.ACL.Permission(id, dbname, table) >= Read {
+			tables = append(tables, table)
+		}
+	}
+	return &pb.ListTablesReply{Tables: tables, Error: toErrS(err)}, nil
+}
+
+// listTables returns the tables in the database directory, using the names of the keydb segment files
+func listTables(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, keydb.NoDatabaseFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	var tables []string
+	for _, file := range files {
+		name := file.Name()
+		if i := strings.LastIndex(name, ".keys."); i > 0 && !found[name[:i]] {
+			found[name[:i]] = true
+			tables = append(tables, name[:i])
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
 func (s *Server) Connection(conn pb.Keydb_ConnectionServer) error {
 
-	state := connstate{txs: make(map[uint64]*transaction), itrs: make(map[uint64]keydb.LookupIterator)}
+	if s.shuttingDown() {
+		return unavailable(keydbr.ShuttingDown)
+	}
 
-	defer s.closedb(&state, true)
+	ctx := conn.Context()
+	state := s.newSession(ctx, "grpc")
+	defer s.endSession(state)
+
+	mconn := &meteredConn{Keydb_ConnectionServer: conn, m: s.metrics}
+	debug := s.logger.Enabled(ctx, slog.LevelDebug)
+
+	// receive in the background, so the session can be closed while waiting for a request
+	msgs := make(chan *pb.InMessage)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := mconn.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 
 	for {
-		msg, err := conn.Recv()
-
-		if err != nil {
+		var msg *pb.InMessage
+		select {
+		case msg = <-msgs:
+		case err := <-recvErr:
 			return err
+		case <-state.done:
+			state.Lock()
+			err := state.closed
+			state.Unlock()
+			return unavailable(err)
 		}
 
-		switch msg.Request.(type) {
-		case *pb.InMessage_Open:
-			err = s.open(conn, &state, msg.GetOpen())
-		case *pb.InMessage_Close:
-			err = s.closedb(&state, false)
-			reply := &pb.OutMessage_Close{Close: &pb.CloseReply{Error: toErrS(err)}}
-			err = conn.Send(&pb.OutMessage{Reply: reply})
-		case *pb.InMessage_Begin:
-			err = s.begin(conn, &state, msg.GetBegin())
-		case *pb.InMessage_Commit:
-			err = s.commit(conn, &state, msg.GetCommit())
-		case *pb.InMessage_Rollback:
-			err = s.rollback(conn, &state, msg.GetRollback())
-		case *pb.InMessage_Get:
-			err = s.get(conn, &state, msg.GetGet())
-		case *pb.InMessage_Put:
-			err = s.put(conn, &state, msg.GetPut())
-		case *pb.InMessage_Lookup:
-			err = s.lookup(conn, &state, msg.GetLookup())
-		case *pb.InMessage_Next:
-			err = s.lookupNext(conn, &state, msg.GetNext())
+		if err := s.process(mconn, state, msg, debug); err != nil {
+			return err
 		}
+	}
+}
 
-		if err != nil {
-			return err
+// newSession registers a session for a client connected using protocol
+func (s *Server) newSession(ctx context.Context, protocol string) *connstate {
+	s.reaperOnce.Do(func() { go s.reaper() })
+
+	state := &connstate{txs: make(map[uint64]*transaction), itrs: make(map[uint64]*iterator), expired: make(map[uint64]bool), expiredItrs: make(map[uint64]bool)}
+	state.identity = IdentityFromContext(ctx)
+	state.done = make(chan struct{})
+	state.peer = peerAddr(ctx)
+	state.opened = time.Now()
+
+	// the session is locked until it has a logger, since it is visible once added
+	state.Lock()
+	s.sessions.add(state)
+	state.logger = s.connLogger(state, protocol)
+	state.Unlock()
+	state.logger.Info("connection opened")
+
+	return state
+}
+
+// endSession closes the session's database, rolling back its open transactions
+func (s *Server) endSession(state *connstate) {
+	s.sessions.remove(state)
+	state.Lock()
+	s.closedb(state, true)
+	state.Unlock()
+	state.logger.Info("connection closed", "duration", time.Since(state.opened))
+}
+
+// process handles a request, sending the reply on conn. It returns an error if the reply could not be sent, or
+// if the session has been closed.
+func (s *Server) process(conn *meteredConn, state *connstate, msg *pb.InMessage, debug bool) error {
+	start := time.Now()
+	conn.errmsg, conn.entries = "", 0
+
+	state.Lock()
+	if state.closed != nil {
+		err := state.closed
+		state.Unlock()
+		return err
+	}
+	var slow *pb.SlowOp
+	if s.slowThreshold > 0 {
+		slow = s.slowOp(state, msg)
+	}
+	err := s.handle(conn, state, msg)
+	state.updateCounts()
+	log := state.logger
+	if debug || err != nil {
+		log = state.log()
+	}
+	state.Unlock()
+
+	duration := time.Since(start)
+	s.metrics.observe(requestType(msg), duration, conn.errmsg != "")
+	s.recordSlowOp(state, slow, start, duration, conn.entries)
+
+	if debug {
+		attrs := append([]any{"type", requestType(msg), "duration", duration}, s.requestAttrs(msg)...)
+		if conn.errmsg != "" {
+			attrs = append(attrs, "error", conn.errmsg)
 		}
+		log.Debug("request", attrs...)
 	}
+
+	if err != nil {
+		log.Warn("unable to send reply", "error", err)
+	}
+	return err
+}
+
+func (s *Server) handle(conn pb.Keydb_ConnectionServer, state *connstate, msg *pb.InMessage) error {
+	var err error
+
+	switch msg.Request.(type) {
+	case *pb.InMessage_Open:
+		err = s.open(conn, state, msg.GetOpen())
+	case *pb.InMessage_Close:
+		err = s.closedb(state, false)
+		reply := &pb.OutMessage_Close{Close: &pb.CloseReply{Error: toErrS(err)}}
+		err = conn.Send(&pb.OutMessage{Reply: reply})
+	case *pb.InMessage_Begin:
+		err = s.begin(conn, state, msg.GetBegin())
+	case *pb.InMessage_Commit:
+		err = s.commit(conn, state, msg.GetCommit())
+	case *pb.InMessage_Rollback:
+		err = s.rollback(conn, state, msg.GetRollback())
+	case *pb.InMessage_Get:
+		err = s.get(conn, state, msg.GetGet())
+	case *pb.InMessage_Put:
+		err = s.put(conn, state, msg.GetPut())
+	case *pb.InMessage_Lookup:
+		err = s.lookup(conn, state, msg.GetLookup())
+	case *pb.InMessage_Next:
+		err = s.lookupNext(conn, state, msg.GetNext())
+	case *pb.InMessage_Delete:
+		err = s.delete(conn, state, msg.GetDelete())
+	}
+
+	return err
 }
 
 func toErrS(err error) string {
@@ -130,42 +493,127 @@ func (s *Server) closedb(state *connstate, rollback bool) error {
 		}
 	}
 
-	log.Println("closing database", fullpath)
+	return s.release(opendb, state.logger)
+}
+
+// acquire returns the database at fullpath, opening it if it is not open, and adds a reference to it which is
+// removed by release. The caller must hold the lock.
+func (s *Server) acquire(dbname string, fullpath string, create bool, logger *slog.Logger) (*openDatabase, error) {
+	if opendb, ok := s.opendb[fullpath]; ok {
+		if opendb.removed {
+			if !create {
+				return nil, keydb.NoDatabaseFound
+			}
+			// the database was emptied when it was removed, so it is created again by keeping it
+			opendb.removed = false
+		}
+		opendb.refcount++
+		return opendb, nil
+	}
+
+	_, staterr := os.Stat(fullpath)
+	db, err := keydb.Open(fullpath, create)
+
+	if err != nil {
+		// an existing database which fails to open is unhealthy
+		if staterr == nil || create {
+			logger.Error("unable to open database", "database", dbname, "error", err)
+			s.setHealth(DatabaseService(dbname), false)
+		}
+		return nil, err
+	}
+	s.setHealth(DatabaseService(dbname), true)
+	logger.Info("opened database", "database", dbname, "create", create)
+
+	opendb := &openDatabase{refcount: 1, db: db, fullpath: fullpath, name: dbname, begun: make(map[string]bool)}
+	s.opendb[fullpath] = opendb
+	return opendb, nil
+}
+
+// release removes a reference added by acquire, closing the database when it has none, and deleting it if the
+// cluster removed it while it was open. The caller must hold the lock.
+func (s *Server) release(opendb *openDatabase, logger *slog.Logger) error {
 	opendb.refcount--
 	if opendb.refcount == 0 {
 		err := opendb.db.Close()
-		delete(s.opendb, fullpath)
+		delete(s.opendb, opendb.fullpath)
 		if err != nil {
+			logger.Error("unable to close database", "database", opendb.name, "error", err)
 			return err
 		}
+		logger.Info("closed database", "database", opendb.name)
+		if opendb.removed {
+			if err := keydb.Remove(opendb.fullpath); err != nil {
+				logger.Error("unable to remove database", "database", opendb.name, "error", err)
+				return err
+			}
+			logger.Info("removed database", "database", opendb.name)
+		}
 	}
 	return nil
 }
 
+// removing reports whether the cluster removed the database at fullpath while it was open, so it is deleted once
+// it is no longer open. The caller must hold the lock.
+func (s *Server) removing(fullpath string) bool {
+	opendb, ok := s.opendb[fullpath]
+	return ok && opendb.removed
+}
+
 func (s *Server) open(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.OpenRequest) error {
 	s.Lock()
 	defer s.Unlock()
 
-	log.Println("open database", in)
-
-	fullpath := filepath.Join(s.path, in.GetDbname())
-
-	opendb, ok := s.opendb[fullpath]
-	if !ok {
-		db, err := keydb.Open(fullpath, in.Create)
-
-		if err != nil {
-			return err
+	dbname, fullpath, ns, err := s.resolve(state.identity, in.GetDbname())
+	if err == nil && s.shuttingDown() {
+		err = keydbr.ShuttingDown
+	}
+	if err == nil {
+		err = s.checkLeader()
+	}
+	creating := false
+	if err == nil {
+		// creating a database requires admin access, and is subject to the namespace quotas
+		required := Read
+		if _, staterr := os.Stat(fullpath); (os.IsNotExist(staterr) || s.removing(fullpath)) && in.Create {
+			required = Admin
+			creating = true
+			err = s.checkCreate(ns)
+			if err == nil {
+				// the database counts towards the quota until it is created, which a cluster does without the lock
+				defer ns.created()
+			}
+			if err == nil && s.readOnly() {
+				err = keydbr.ReadOnly
+			}
+		}
+		// opening a database requires read access to any of its tables, and creating one admin access to all
+		if err == nil && (required > Read || s.ACL.AnyTable(state.identity, dbname) < Read) {
+			err = s.ACL.Check(state.identity, dbname, "", required)
 		}
+	}
+	if err == nil && creating && s.cluster != nil {
+		// the database is created on each member by the cluster, which requires the lock
+		s.Unlock()
+		err = s.apply(&pb.ClusterCommand{Dbname: dbname, Create: true})
+		s.Lock()
+	}
+	if err != nil {
+		reply := &pb.OutMessage_Open{Open: &pb.OpenReply{Error: err.Error()}}
+		return conn.Send(&pb.OutMessage{Reply: reply})
+	}
 
-		opendb = &openDatabase{refcount: 1, db: db, fullpath: fullpath}
-		s.opendb[fullpath] = opendb
-	} else {
-		opendb.refcount++
-		log.Println("database already open, returning ref", in)
+	opendb, err := s.acquire(dbname, fullpath, in.Create, state.logger)
+	if err != nil {
+		reply := &pb.OutMessage_Open{Open: &pb.OpenReply{Error: err.Error()}}
+		return conn.Send(&pb.OutMessage{Reply: reply})
 	}
 
-	state.db = opendb
+	opendb.mu.Lock()
+	state.db, state.dbgen = opendb, opendb.generation
+	opendb.mu.Unlock()
+	state.dbname = dbname
+	state.ns = ns
 
 	reply := &pb.OutMessage_Open{Open: &pb.OpenReply{Error: ""}}
 	return conn.Send(&pb.OutMessage{Reply: reply})
@@ -174,31 +622,57 @@ func (s *Server) open(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.O
 func (s *Server) begin(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.BeginRequest) error {
 
 	var id uint64 = 0
-	tx, err := state.db.db.BeginTX(in.Table)
+	var tx *keydb.Transaction
+	perm := s.ACL.Permission(state.identity, state.dbname, in.Table)
+	err := keydbr.ValidateTableName(in.Table)
+	if err == nil && perm < Read {
+		err = permissionDenied(state.identity, state.dbname, in.Table, Read)
+	}
+	if err == nil && s.shuttingDown() {
+		err = keydbr.ShuttingDown
+	}
+	if err == nil {
+		err = s.checkLeader()
+	}
+	if err == nil {
+		err = s.Limits.checkTransactions(state)
+	}
+	if err == nil {
+		err = s.checkRate(state)
+	}
+	if err == nil {
+		err = state.db.check(state.dbgen)
+	}
+	if err == nil {
+		tx, err = state.db.beginTX(in.Table)
+	}
 	if err == nil {
 		id = tx.GetID()
-		state.txs[id] = &transaction{Transaction: tx}
+		state.txs[id] = &transaction{Transaction: tx, table: in.Table, perm: perm, used: time.Now()}
+		if s.replicationLogSize > 0 {
+			state.txs[id].log = s.replicationLog(state.db.fullpath)
+		}
+		if s.cluster != nil {
+			state.txs[id].dbname = state.dbname
+		}
 	}
 	reply := &pb.OutMessage_Begin{Begin: &pb.BeginReply{Txid: id, Error: toErrS(err)}}
 	return conn.Send(&pb.OutMessage{Reply: reply})
 }
 func (s *Server) commit(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.CommitRequest) error {
 
-	var err error
-	tx, ok := state.txs[in.Txid]
-	if !ok {
-		err = errors.New("invalid tx id")
-	} else {
-		if tx.asyncfailure {
-			return errors.New("async put failure")
-		}
-		if in.Sync {
-			err = tx.CommitSync()
+	tx, err := state.tx(in.Txid)
+	if err == nil && tx.asyncfailure != nil {
+		// the transaction must be rolled back
+		err = tx.asyncfailure
+	} else if err == nil {
+		if s.cluster != nil {
+			err = tx.commitCluster(s)
 		} else {
-			err = tx.Commit()
+			err = tx.commit(in.Sync)
 		}
-		if err != nil {
-			delete(state.txs, in.Txid)
+		if err == nil {
+			state.endtx(in.Txid)
 		}
 	}
 
@@ -208,29 +682,37 @@ func (s *Server) commit(conn pb.Keydb_ConnectionServer, state *connstate, in *pb
 
 func (s *Server) rollback(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.RollbackRequest) error {
 
-	var err error
-	tx, ok := state.txs[in.Txid]
-	if !ok {
-		err = errors.New("invalid tx id")
-	} else {
+	tx, err := state.tx(in.Txid)
+	if err == nil {
 		err = tx.Rollback()
-		if err != nil {
-			delete(state.txs, in.Txid)
-		}
+		state.endtx(in.Txid)
 	}
 
 	reply := &pb.OutMessage_Rollback{Rollback: &pb.RollbackReply{Error: toErrS(err)}}
 	return conn.Send(&pb.OutMessage{Reply: reply})
 }
 
+// endtx releases the resources held by a completed transaction
+func (state *connstate) endtx(txid uint64) {
+	if tx, ok := state.txs[txid]; ok {
+		state.inflight -= tx.bytes
+		delete(state.txs, txid)
+	}
+	for id, itr := range state.itrs {
+		if itr.txid == txid {
+			delete(state.itrs, id)
+		}
+	}
+}
+
 func (s *Server) get(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.GetRequest) error {
 
-	var err error
 	var value []byte
-	tx, ok := state.txs[in.Txid]
-	if !ok {
-		err = errors.New("invalid tx id")
-	} else {
+	tx, err := state.tx(in.Txid)
+	if err == nil {
+		err = s.checkRate(state)
+	}
+	if err == nil {
 		value, err = tx.Get(in.Key)
 	}
 
@@ -240,17 +722,39 @@ func (s *Server) get(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.Ge
 
 func (s *Server) put(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.PutRequest) error {
 
-	var err error
-	tx, ok := state.txs[in.Txid]
-	if !ok {
-		err = errors.New("invalid tx id")
-	} else {
+	size := int64(len(in.Key) + len(in.Value))
+	tx, err := state.tx(in.Txid)
+	if err == nil && tx.perm < Write {
+		err = permissionDenied(state.identity, state.dbname, tx.table, Write)
+	}
+	if err == nil && s.readOnly() {
+		err = keydbr.ReadOnly
+	}
+	if err == nil {
+		err = s.checkRate(state)
+	}
+	if err == nil {
+		err = s.Limits.checkInFlight(state, size)
+	}
+	if err == nil {
+		err = s.checkDiskUsage(state.ns)
+	}
+	if err == nil {
 		err = tx.Put(in.Key, in.Value)
 	}
+	if err == nil {
+		tx.bytes += size
+		tx.puts++
+		state.inflight += size
+		tx.record(&pb.Mutation{Key: in.Key, Value: in.Value})
+	}
 
 	if !in.Sync {
 		if err != nil {
-			tx.asyncfailure = true
+			s.metrics.asyncPutFailed()
+			if tx != nil && tx.asyncfailure == nil {
+				tx.asyncfailure = err
+			}
 		}
 		return nil
 	}
@@ -259,19 +763,53 @@ func (s *Server) put(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.Pu
 	return conn.Send(&pb.OutMessage{Reply: reply})
 }
 
+func (s *Server) delete(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.DeleteRequest) error {
+
+	var value []byte
+	size := int64(len(in.Key))
+	tx, err := state.tx(in.Txid)
+	if err == nil && tx.perm < Write {
+		err = permissionDenied(state.identity, state.dbname, tx.table, Write)
+	}
+	if err == nil && s.readOnly() {
+		err = keydbr.ReadOnly
+	}
+	if err == nil {
+		err = s.checkRate(state)
+	}
+	if err == nil {
+		err = s.Limits.checkInFlight(state, size)
+	}
+	if err == nil {
+		value, err = tx.Remove(in.Key)
+	}
+	if err == nil {
+		tx.bytes += size
+		tx.puts++
+		state.inflight += size
+		tx.record(&pb.Mutation{Key: in.Key, Delete: true})
+	}
+
+	reply := &pb.OutMessage_Delete{Delete: &pb.DeleteReply{Value: value, Error: toErrS(err)}}
+	return conn.Send(&pb.OutMessage{Reply: reply})
+}
+
 func (s *Server) lookup(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.LookupRequest) error {
 
-	var err error
 	var id uint64
-	tx, ok := state.txs[in.Txid]
-	if !ok {
-		err = errors.New("invalid tx id")
-	} else {
+	tx, err := state.tx(in.Txid)
+	if err == nil {
+		err = s.Limits.checkIterators(state)
+	}
+	if err == nil {
+		err = s.checkRate(state)
+	}
+	if err == nil {
 		itr, err0 := tx.Lookup(in.Lower, in.Upper)
 		if err0 == nil {
 			state.next++
 			id = state.next
-			state.itrs[id] = itr
+			state.itrs[id] = &iterator{LookupIterator: itr, txid: in.Txid, used: time.Now()}
 		}
 		err = err0
 	}
@@ -280,25 +818,30 @@ func (s *Server) lookup(conn pb.Keydb_ConnectionServer, state *connstate, in *pb
 	return conn.Send(&pb.OutMessage{Reply: reply})
 }
 
+// maxNextBytes is the size of the entries after which a lookup reply is sent
+const maxNextBytes = keydbr.BlobChunkSize
+
 func (s *Server) lookupNext(conn pb.Keydb_ConnectionServer, state *connstate, in *pb.LookupNextRequest) error {
 
-	var err error
 	var entries []*pb.KeyValue
 
-	itr, ok := state.itrs[in.Id]
-	if !ok {
-		err = errors.New("invalid iterator id")
-	} else {
-		// read up to 64 entries
+	itr, err := state.itr(in.Id)
+	if err == nil {
+		err = s.checkRate(state)
+	}
+	if err == nil {
+		// read up to 64 entries, or maxNextBytes, so large values such as blob chunks fit in a message
 		count := 0
+		size := 0
 
 		entries = make([]*pb.KeyValue, 64)[:0]
-		for count < 64 {
+		for count < 64 && size < maxNextBytes {
 			key, value, err0 := itr.Next()
 			err = err0
 			if err == nil {
 				kv := pb.KeyValue{Key: key, Value: value}
 				entries = append(entries, &kv)
+				size += len(key) + len(value)
 			} else {
 				if count > 0 {
 					err = nil
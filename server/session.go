@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// close rolls back the session's transactions and closes its stream with err. The caller must hold the lock.
+func (state *connstate) close(err error) {
+	if state.closed != nil {
+		return
+	}
+	for txid, tx := range state.txs {
+		tx.Rollback()
+		state.endtx(txid)
+	}
+	state.closed = err
+	state.updateCounts()
+	close(state.done)
+}
+
+// sessionDatabase returns the database name of the session as seen by a caller in namespace ns, and false if the
+// session is in a different namespace
+func (s *Server) sessionDatabase(ns *Namespace, state *connstate) (string, bool) {
+	if sessionNs, _ := s.namespace(state.identity); sessionNs != ns {
+		return "", false
+	}
+	if state.db == nil {
+		return "", true
+	}
+	if ns != nil {
+		return strings.TrimPrefix(state.dbname, ns.Name+"/"), true
+	}
+	return state.dbname, true
+}
+
+// ListSessions returns the open connections which the caller can administer, ordered by id
+func (s *Server) ListSessions(ctx context.Context, in *pb.ListSessionsRequest) (*pb.ListSessionsReply, error) {
+	id := IdentityFromContext(ctx)
+
+	ns, err := s.namespace(id)
+	if err != nil {
+		return &pb.ListSessionsReply{Error: toErrS(err)}, nil
+	}
+
+	now := time.Now()
+	var sessions []*pb.Session
+	for _, state := range s.sessions.list() {
+		state.Lock()
+		dbname, ok := s.sessionDatabase(ns, state)
+		ok = ok && s.ACL.Permission(id, state.dbname, "") >= Admin
+		state.Unlock()
+		if !ok {
+			continue
+		}
+		session := &pb.Session{
+			Id:           state.id,
+			Peer:         state.peer,
+			Dbname:       dbname,
+			Transactions: int32(atomic.LoadInt64(&state.ntxs)),
+			Iterators:    int32(atomic.LoadInt64(&state.nitrs)),
+			Opened:       state.opened.UnixNano(),
+			Age:          int64(now.Sub(state.opened)),
+		}
+		if state.identity != nil {
+			session.Identity = state.identity.Name
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Id < sessions[j].Id })
+
+	return &pb.ListSessionsReply{Sessions: sessions}, nil
+}
+
+// KillSession rolls back the transactions of a connection and closes it. The caller must administer the
+// connection's database.
+func (s *Server) KillSession(ctx context.Context, in *pb.KillSessionRequest) (*pb.KillSessionReply, error) {
+	id := IdentityFromContext(ctx)
+
+	ns, err := s.namespace(id)
+	if err != nil {
+		return &pb.KillSessionReply{Error: toErrS(err)}, nil
+	}
+
+	err = fmt.Errorf("no session %d", in.Id)
+	for _, state := range s.sessions.list() {
+		if state.id != in.Id {
+			continue
+		}
+		state.Lock()
+		// sessions in other namespaces are not visible
+		if _, ok := s.sessionDatabase(ns, state); ok {
+			if s.ACL.Permission(id, state.dbname, "") < Admin {
+				err = permissionDenied(id, state.dbname, "", Admin)
+			} else {
+				name := ""
+				if id != nil {
+					name = id.Name
+				}
+				state.logger.Warn("killing session", "by", name)
+				state.close(status.Error(codes.Aborted, "session killed"))
+				err = nil
+			}
+		}
+		state.Unlock()
+	}
+	return &pb.KillSessionReply{Error: toErrS(err)}, nil
+}
@@ -0,0 +1,128 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestSessions(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	addr := startServer(t, srv)
+
+	db1, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db1.Close()
+	db2, err := client.Open(addr, "other", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db2.Close()
+
+	tx, err := db1.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Lookup(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := client.ListSessions(addr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatal("wrong number of sessions", len(sessions))
+	}
+	s1, s2 := sessions[0], sessions[1]
+	if s1.Database != "main" || s1.Transactions != 1 || s1.Iterators != 1 || s1.Peer == "" || s1.Age <= 0 {
+		t.Fatalf("wrong session %+v", s1)
+	}
+	if s2.Database != "other" || s2.Transactions != 0 || s2.ID == s1.ID {
+		t.Fatalf("wrong session %+v", s2)
+	}
+
+	// killing a session rolls back its transactions and closes the connection, even while it is idle
+	if err = client.KillSession(addr, s1.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Get([]byte("mykey")); err == nil {
+		t.Fatal("killed session should be closed")
+	}
+	if err = client.KillSession(addr, s1.ID, 10); err == nil {
+		t.Fatal("killed session should not exist")
+	}
+
+	sessions, err = client.ListSessions(addr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != s2.ID {
+		t.Fatalf("wrong sessions %+v", sessions)
+	}
+
+	// the put was rolled back
+	tx, err = db2.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Get([]byte("mykey")); err == nil {
+		t.Fatal("transaction should be rolled back")
+	}
+}
+
+func TestSessionPermissions(t *testing.T) {
+	dir := tempDir(t)
+
+	db, err := client.Open(startServer(t, server.NewServer(dir)), "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	srv := server.NewServer(dir)
+	srv.ACL = &server.ACL{Rules: []server.ACLRule{
+		{Database: "main", Permission: server.Write},
+		{Database: "other", Permission: server.Admin},
+	}}
+	addr := startServer(t, srv)
+
+	db, err = client.Open(addr, "main", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	other, err := client.Open(addr, "other", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	// only the sessions of administered databases are listed
+	sessions, err := client.ListSessions(addr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Database != "other" {
+		t.Fatalf("wrong sessions %+v", sessions)
+	}
+
+	// the session using main has the id before other
+	err = client.KillSession(addr, sessions[0].ID-1, 10)
+	if !errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("killing the session should require admin access", err)
+	}
+	err = client.KillSession(addr, sessions[0].ID+1, 10)
+	if err == nil || errors.Is(err, keydbr.PermissionDenied) {
+		t.Fatal("session should not exist", err)
+	}
+}
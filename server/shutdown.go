@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"github.com/robaho/keydbr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync/atomic"
+	"time"
+)
+
+// ShutdownSummary reports the work performed by Shutdown
+type ShutdownSummary struct {
+	// Connections is the number of connections closed
+	Connections int
+	// Drained is the number of transactions completed by clients during the grace period
+	Drained int
+	// RolledBack is the number of transactions rolled back after the grace period
+	RolledBack int
+	// Databases is the number of databases closed
+	Databases int
+	// Errors are the errors closing databases
+	Errors []error
+}
+
+func (s *Server) shuttingDown() bool {
+	return atomic.LoadInt32(&s.closing) != 0
+}
+
+// unavailable returns keydbr.ShuttingDown as an Unavailable status, so a client of a cluster opens the database on
+// another member, and returns other errors unchanged
+func unavailable(err error) error {
+	if err == keydbr.ShuttingDown {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
+
+// openTransactions returns the number of open transactions across all connections
+func (s *Server) openTransactions() int {
+	n := 0
+	for _, state := range s.sessions.list() {
+		n += int(atomic.LoadInt64(&state.ntxs))
+	}
+	return n
+}
+
+// Shutdown stops the server accepting new connections, databases and transactions, and waits until the open
+// transactions complete or ctx is done. The remaining transactions are rolled back, and all databases are closed.
+// The open connections are closed with keydbr.ShuttingDown, and the caller should also stop the grpc server. A
+// replica stops replicating, and a cluster member leaves the cluster.
+func (s *Server) Shutdown(ctx context.Context) ShutdownSummary {
+	var summary ShutdownSummary
+
+	atomic.StoreInt32(&s.closing, 1)
+	s.SetServing(false)
+	s.stopReplication(false)
+
+	open := s.openTransactions()
+	if open > 0 {
+		s.logger.Info("waiting for open transactions", "transactions", open)
+	}
+wait:
+	for s.openTransactions() > 0 {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	s.Lock()
+	summary.Databases = len(s.opendb)
+	s.Unlock()
+
+	for _, state := range s.sessions.list() {
+		state.Lock()
+		summary.Connections++
+		summary.RolledBack += len(state.txs)
+		state.close(keydbr.ShuttingDown)
+		if err := s.closedb(state, false); err != nil {
+			summary.Errors = append(summary.Errors, err)
+		}
+		state.Unlock()
+	}
+	summary.Drained = open - summary.RolledBack
+	if summary.Drained < 0 {
+		summary.Drained = 0
+	}
+	s.stopCluster()
+
+	// close any databases whose references were not released by their connection
+	s.Lock()
+	for fullpath, opendb := range s.opendb {
+		if err := opendb.db.Close(); err != nil {
+			s.logger.Error("unable to close database", "database", opendb.name, "error", err)
+			summary.Errors = append(summary.Errors, err)
+		} else {
+			s.logger.Info("closed database", "database", opendb.name)
+		}
+		delete(s.opendb, fullpath)
+	}
+	s.Unlock()
+
+	s.stopReaperOnce.Do(func() { close(s.stopReaper) })
+	return summary
+}
@@ -0,0 +1,61 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestShutdown(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	addr := startServer(t, srv)
+
+	db1, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db2, err := client.Open(addr, "main", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx1, err := db1.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx2, err := db2.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx1.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	done := make(chan server.ShutdownSummary)
+	go func() { done <- srv.Shutdown(ctx) }()
+
+	// open transactions can complete during the grace period, but new ones cannot be started
+	time.Sleep(100 * time.Millisecond)
+	if _, err = db1.BeginTX("main"); !errors.Is(err, keydbr.ShuttingDown) {
+		t.Fatal("begin should fail while shutting down", err)
+	}
+	if err = tx1.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := <-done
+	if summary.Connections != 2 || summary.Drained != 1 || summary.RolledBack != 1 || summary.Databases != 1 || len(summary.Errors) != 0 {
+		t.Fatalf("wrong summary %+v", summary)
+	}
+
+	if _, err = tx2.Get([]byte("mykey")); err == nil {
+		t.Fatal("connection should be closed")
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSlowLogSize is the number of slow operations retained if not configured
+const DefaultSlowLogSize = 100
+
+// maximum length of the key prefix recorded for a slow operation
+const slowKeyPrefix = 16
+
+// slowLog retains the most recent slow operations
+type slowLog struct {
+	sync.Mutex
+	ops  []*pb.SlowOp
+	next int
+}
+
+func (l *slowLog) add(op *pb.SlowOp, size int) {
+	l.Lock()
+	defer l.Unlock()
+
+	if size <= 0 {
+		size = DefaultSlowLogSize
+	}
+	if len(l.ops) < size {
+		l.ops = append(l.ops, op)
+		return
+	}
+	l.ops[l.next] = op
+	l.next = (l.next + 1) % len(l.ops)
+}
+
+// recent returns the slow operations, newest first
+func (l *slowLog) recent() []*pb.SlowOp {
+	l.Lock()
+	defer l.Unlock()
+
+	ops := make([]*pb.SlowOp, 0, len(l.ops))
+	for i := len(l.ops) - 1; i >= 0; i-- {
+		ops = append(ops, l.ops[(l.next+i)%len(l.ops)])
+	}
+	return ops
+}
+
+// slowOp describes a request for the slow operation log, or returns nil if the request type is not recorded.
+// It is called before the request is handled, since a commit releases the transaction.
+func (s *Server) slowOp(state *connstate, msg *pb.InMessage) *pb.SlowOp {
+	var txid uint64
+	var key []byte
+	var entries int
+
+	switch req := msg.Request.(type) {
+	case *pb.InMessage_Get:
+		txid, key = req.Get.Txid, req.Get.Key
+	case *pb.InMessage_Put:
+		txid, key = req.Put.Txid, req.Put.Key
+	case *pb.InMessage_Commit:
+		txid = req.Commit.Txid
+		if tx, ok := state.txs[txid]; ok {
+			entries = tx.puts
+		}
+	case *pb.InMessage_Lookup:
+		txid, key = req.Lookup.Txid, req.Lookup.Lower
+	case *pb.InMessage_Next:
+		if itr, ok := state.itrs[req.Next.Id]; ok {
+			txid = itr.txid
+		}
+	default:
+		return nil
+	}
+
+	op := &pb.SlowOp{Type: requestType(msg), Dbname: state.dbname, KeySize: int32(len(key)), Entries: int32(entries), Connection: state.id}
+	if tx, ok := state.txs[txid]; ok {
+		op.Table = tx.table
+	}
+	if state.identity != nil {
+		op.Identity = state.identity.Name
+	}
+	if s.logKeys {
+		if len(key) > slowKeyPrefix {
+			key = key[:slowKeyPrefix]
+		}
+		op.KeyPrefix = append([]byte(nil), key...)
+	}
+	return op
+}
+
+// recordSlowOp records op if the request took at least the slow threshold
+func (s *Server) recordSlowOp(state *connstate, op *pb.SlowOp, start time.Time, duration time.Duration, entries int) {
+	if op == nil || duration < s.slowThreshold {
+		return
+	}
+	op.Time = start.UnixNano()
+	op.Duration = int64(duration)
+	if op.Type == "next" {
+		op.Entries = int32(entries)
+	}
+	s.slowlog.add(op, s.slowLogSize)
+
+	attrs := []any{"type", op.Type, "database", op.Dbname, "table", op.Table, "keysize", op.KeySize, "entries", op.Entries, "duration", duration}
+	if s.logKeys && op.KeySize > 0 {
+		attrs = append(attrs, "key", s.logKey(op.KeyPrefix))
+	}
+	state.logger.Warn("slow request", attrs...)
+}
+
+// SlowOps returns the most recent slow operations, newest first, on the databases in the caller's namespace which
+// the caller administers
+func (s *Server) SlowOps(ctx context.Context, in *pb.SlowOpsRequest) (*pb.SlowOpsReply, error) {
+	id := IdentityFromContext(ctx)
+
+	ns, err := s.namespace(id)
+	if err != nil {
+		return &pb.SlowOpsReply{Error: toErrS(err)}, nil
+	}
+	prefix := ""
+	if ns != nil {
+		prefix = ns.Name + "/"
+	}
+
+	var ops []*pb.SlowOp
+	for _, op := range s.slowlog.recent() {
+		if in.Limit > 0 && len(ops) >= int(in.Limit) {
+			break
+		}
+		if !strings.HasPrefix(op.Dbname, prefix) || s.ACL.Permission(id, op.Dbname, "") < Admin {
+			continue
+		}
+		reply := *op
+		reply.Dbname = strings.TrimPrefix(op.Dbname, prefix)
+		ops = append(ops, &reply)
+	}
+	return &pb.SlowOpsReply{Ops: ops}, nil
+}
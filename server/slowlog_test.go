@@ -0,0 +1,116 @@
+package server_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestSlowOps(t *testing.T) {
+	// every request is slow with a threshold of 1ns
+	srv := server.NewServer(tempDir(t), server.WithSlowThreshold(1), server.WithSlowLogSize(4), server.WithLogKeys(true))
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("mytable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.PutSync([]byte("a very long key which is truncated"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = db.BeginTX("mytable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, _, err := itr.Next(); err != nil {
+			break
+		}
+	}
+
+	ops, err := client.SlowOps(addr, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the oldest operations are discarded, the last next reaches the end of the iteration
+	if len(ops) != 4 {
+		t.Fatal("wrong number of slow operations", len(ops))
+	}
+	next, lookup, commit := ops[1], ops[2], ops[3]
+	if next.Type != "next" || next.Entries != 2 || next.Table != "mytable" || next.Database != "main" || next.Duration <= 0 {
+		t.Fatalf("wrong next operation %+v", next)
+	}
+	if ops[0].Type != "next" || ops[0].Entries != 0 || lookup.Type != "lookup" || commit.Type != "commit" || commit.Entries != 2 {
+		t.Fatalf("wrong operations %+v", ops)
+	}
+
+	ops, err = client.SlowOps(addr, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 || ops[0].Type != "next" {
+		t.Fatalf("wrong limited operations %+v", ops)
+	}
+
+}
+
+func TestSlowOpKeys(t *testing.T) {
+	key := []byte("a very long key which is truncated")
+
+	// keys are truncated, and redacted unless the server logs keys
+	for _, logKeys := range []bool{true, false} {
+		srv := server.NewServer(tempDir(t), server.WithSlowThreshold(1), server.WithLogKeys(logKeys))
+		addr := startServer(t, srv)
+
+		db, err := client.Open(addr, "main", true, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := db.BeginTX("mytable")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.PutSync(key, []byte("myvalue")); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		ops, err := client.SlowOps(addr, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ops) != 1 || ops[0].Type != "put" || ops[0].KeySize != len(key) {
+			t.Fatalf("wrong operations %+v", ops)
+		}
+		expected := key[:16]
+		if !logKeys {
+			expected = nil
+		}
+		if !bytes.Equal(ops[0].KeyPrefix, expected) {
+			t.Fatal("wrong key prefix", string(ops[0].KeyPrefix))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package server_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/server"
+)
+
+func TestListTables(t *testing.T) {
+	srv := server.NewServer(tempDir(t))
+	addr := startServer(t, srv)
+
+	db, err := client.Open(addr, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, table := range []string{"users", "orders"} {
+		tx, err := db.BeginTX(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.PutSync([]byte("mykey"), []byte("myvalue")); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tables, err := client.ListTables(addr, "main", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tables, []string{"orders", "users"}) {
+		t.Fatal("wrong tables", tables)
+	}
+
+	if _, err = client.ListTables(addr, "missing", 10); err == nil {
+		t.Fatal("missing database should fail")
+	}
+}
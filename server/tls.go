@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/robaho/keydbr"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSFiles holds the certificate files used by the server. If ClientCAFile is set, clients must
+// present a certificate signed by one of the CAs it contains (mutual TLS).
+type TLSFiles struct {
+	CertFile     string `yaml:"cert" json:"cert"`
+	KeyFile      string `yaml:"key" json:"key"`
+	ClientCAFile string `yaml:"clientCA" json:"clientCA"`
+}
+
+// CertificateReloader serves a tls.Config whose certificate and client CAs are reloaded
+// whenever the underlying files change, so certificates can be rotated without a restart.
+type CertificateReloader struct {
+	sync.Mutex
+	files   TLSFiles
+	modtime time.Time
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+}
+
+func NewCertificateReloader(files TLSFiles) (*CertificateReloader, error) {
+	r := &CertificateReloader{files: files}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns a server tls.Config which always uses the most recent certificates.
+func (r *CertificateReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.Lock()
+			defer r.Unlock()
+
+			if r.changed() {
+				if err := r.reload(); err != nil {
+					slog.Warn("unable to reload certificates, using previous", "error", err)
+				} else {
+					slog.Info("reloaded certificates")
+				}
+			}
+
+			config := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{*r.cert}}
+			if r.pool != nil {
+				config.ClientCAs = r.pool
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
+
+func (r *CertificateReloader) latest() time.Time {
+	var latest time.Time
+	for _, file := range []string{r.files.CertFile, r.files.KeyFile, r.files.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		if fi, err := os.Stat(file); err == nil && fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest
+}
+
+func (r *CertificateReloader) changed() bool {
+	return r.latest().After(r.modtime)
+}
+
+// reload loads the certificates, recording the modification time of the files even if they are invalid, so they
+// are only loaded again once they change
+func (r *CertificateReloader) reload() error {
+	r.modtime = r.latest()
+
+	cert, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	var pool *x509.CertPool
+	if r.files.ClientCAFile != "" {
+		pool, err = keydbr.LoadCertPool(r.files.ClientCAFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.cert, r.pool = &cert, pool
+	return nil
+}
@@ -0,0 +1,172 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newCert(t *testing.T, cn string, parent *testCert, serial int64) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keydbr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newCert(t, "test ca", nil, 1)
+	serverCert := newCert(t, "server", ca, 2)
+	clientCert := newCert(t, "client", ca, 3)
+
+	files := server.TLSFiles{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	writeFile(t, files.CertFile, serverCert.certPEM)
+	writeFile(t, files.KeyFile, serverCert.keyPEM)
+	writeFile(t, files.ClientCAFile, ca.certPEM)
+
+	reloader, err := server.NewCertificateReloader(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
+	pb.RegisterKeydbServer(s, server.NewServer(filepath.Join(dir, "databases")))
+	go s.Serve(lis)
+	defer s.Stop()
+
+	addr := lis.Addr().String()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	db, err := client.Open(addr, "main", true, 10, client.WithCA(pool), client.WithClientCertificate(clientCert.tlsCertificate(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Open(addr, "main", true, 10, client.WithCA(pool))
+	if err == nil {
+		t.Fatal("open without a client certificate should fail")
+	}
+
+	// rotate the server certificate, and verify the new one is presented
+	rotated := newCert(t, "server", ca, 4)
+	writeFile(t, files.CertFile, rotated.certPEM)
+	writeFile(t, files.KeyFile, rotated.keyPEM)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(files.CertFile, future, future)
+
+	serial := func() int64 {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{clientCert.tlsCertificate(t)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	if serial := serial(); serial != 4 {
+		t.Fatal("certificate was not reloaded, serial", serial)
+	}
+
+	// an invalid certificate keeps the previous one, and is not loaded again until the files change
+	writeFile(t, files.CertFile, []byte("invalid"))
+	future = future.Add(time.Minute)
+	os.Chtimes(files.CertFile, future, future)
+	if serial := serial(); serial != 4 {
+		t.Fatal("invalid certificate should not be used, serial", serial)
+	}
+	fixed := newCert(t, "server", ca, 5)
+	writeFile(t, files.CertFile, fixed.certPEM)
+	writeFile(t, files.KeyFile, fixed.keyPEM)
+	os.Chtimes(files.CertFile, future, future)
+	os.Chtimes(files.KeyFile, future, future)
+	if serial := serial(); serial != 4 {
+		t.Fatal("unchanged files should not be loaded again, serial", serial)
+	}
+	future = future.Add(time.Minute)
+	os.Chtimes(files.CertFile, future, future)
+	if serial := serial(); serial != 5 {
+		t.Fatal("certificate was not reloaded after the files changed, serial", serial)
+	}
+}
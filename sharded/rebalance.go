@@ -0,0 +1,128 @@
+package sharded
+
+import (
+	"errors"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+)
+
+// the maximum number of keys moved, and scanned, per transaction
+const rebalanceBatch = 1000
+const scanBatch = 10 * rebalanceBatch
+
+// Rebalance moves the keys of database dbname which are routed differently by to than by from, such as when a
+// shard is added to a Ring, to the servers to routes them to, creating the database on new servers. It returns the
+// number of keys moved.
+//
+// Each batch of keys is committed on its new server before it is removed from the old one, so a key is always on
+// one of them. While it runs, clients read the database by opening it with NewMoving(from, to), whose gets and
+// removes fall back to the old server of keys not yet moved, and whose lookups merge the copies, and clients opened
+// with from do not see the keys already moved. Rebalance should run while the database is not being written, as a
+// write to a key being moved may be lost, and clients switch to to once it completes. If it fails, running it
+// again completes the move.
+func Rebalance(from, to Router, dbname string, timeout int, opts ...client.Option) (int, error) {
+	dst, err := Open(to, dbname, true, timeout, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	moved := 0
+	for _, addr := range from.Shards() {
+		tables, err := client.ListTables(addr, dbname, timeout, opts...)
+		if err != nil {
+			return moved, err
+		}
+		src, err := client.Open(addr, dbname, false, timeout, opts...)
+		if err != nil {
+			return moved, err
+		}
+		for _, table := range tables {
+			n, err := moveTable(src, addr, dst, to, table)
+			moved += n
+			if err != nil {
+				src.Close()
+				return moved, err
+			}
+		}
+		if err := src.Close(); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// moveTable moves the keys of table on the shard at addr which to routes elsewhere
+func moveTable(src keydbr.Database, addr string, dst *Database, to Router, table string) (int, error) {
+	moved := 0
+	var start []byte
+	for {
+		tx, err := src.BeginTX(table)
+		if err != nil {
+			return moved, err
+		}
+		keys, values, more, err := scan(tx, addr, to, &start)
+		if err == nil && len(keys) > 0 {
+			err = move(tx, dst, table, keys, values)
+		}
+		if err != nil {
+			tx.Rollback()
+			return moved, err
+		}
+		if len(keys) == 0 {
+			tx.Rollback()
+		}
+		moved += len(keys)
+		if !more {
+			return moved, nil
+		}
+	}
+}
+
+// scan reads the next batch of keys to move from start, which is advanced past the keys scanned
+func scan(tx keydbr.Transaction, addr string, to Router, start *[]byte) (keys, values [][]byte, more bool, err error) {
+	itr, err := tx.Lookup(*start, nil)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	for scanned := 0; ; scanned++ {
+		if len(keys) >= rebalanceBatch || scanned >= scanBatch {
+			return keys, values, true, nil
+		}
+		key, value, err := itr.Next()
+		if errors.Is(err, keydbr.EndOfIterator) {
+			return keys, values, false, nil
+		}
+		if err != nil {
+			return nil, nil, false, err
+		}
+		*start = append(append([]byte(nil), key...), 0)
+		if to.Route(key) != addr {
+			keys, values = append(keys, key), append(values, value)
+		}
+	}
+}
+
+// move writes the keys to their new shards, and then removes them in tx
+func move(tx keydbr.Transaction, dst *Database, table string, keys, values [][]byte) error {
+	dsttx, err := dst.BeginTX(table)
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if err := dsttx.Put(key, values[i]); err != nil {
+			dsttx.Rollback()
+			return err
+		}
+	}
+	if err := dsttx.CommitSync(); err != nil {
+		dsttx.Rollback()
+		return err
+	}
+	for _, key := range keys {
+		if _, err := tx.Remove(key); err != nil && !errors.Is(err, keydbr.KeyNotFound) {
+			return err
+		}
+	}
+	return tx.CommitSync()
+}
@@ -0,0 +1,153 @@
+package sharded
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of points each shard has on a Ring, which spreads the keys evenly
+const DefaultVirtualNodes = 128
+
+// Router assigns each key to the address of the server holding it
+type Router interface {
+	Route(key []byte) string
+	// Shards returns the addresses of all servers
+	Shards() []string
+}
+
+// Ring routes keys by consistent hashing, so adding a shard to a ring of n moves about 1/(n+1) of the keys
+type Ring struct {
+	shards []string
+	points []uint64
+	addrs  []string // the shard of each point
+}
+
+// Range is the keys from Start up to the Start of the next range, which are held by the server at Addr
+type Range struct {
+	Start []byte
+	Addr  string
+}
+
+// Ranges routes keys by the range containing them
+type Ranges struct {
+	ranges []Range
+	shards []string
+}
+
+// Moving routes keys by To while Rebalance moves them from the shards of From, see Rebalance
+type Moving struct {
+	From   Router
+	To     Router
+	shards []string
+}
+
+var _ Router = (*Ring)(nil)
+var _ Router = (*Ranges)(nil)
+var _ Router = (*Moving)(nil)
+
+func hash(b []byte) uint64 {
+	sum := sha1.Sum(b)
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+// NewRing returns a ring of the servers at addrs, with DefaultVirtualNodes points each. The keys are routed by the
+// addresses, so every client must use the same address for a server.
+func NewRing(addrs ...string) *Ring {
+	r := &Ring{shards: dedupe(addrs)}
+	type point struct {
+		hash uint64
+		addr string
+	}
+	var points []point
+	for _, addr := range r.shards {
+		for i := 0; i < DefaultVirtualNodes; i++ {
+			points = append(points, point{hash([]byte(addr + "#" + strconv.Itoa(i))), addr})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+	for _, p := range points {
+		r.points = append(r.points, p.hash)
+		r.addrs = append(r.addrs, p.addr)
+	}
+	return r
+}
+
+// Route returns the shard of the first point at or after the hash of key
+func (r *Ring) Route(key []byte) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.addrs[i]
+}
+
+func (r *Ring) Shards() []string {
+	return r.shards
+}
+
+// NewRanges returns a router for ranges, which must include a range starting with the empty key and have distinct
+// starts. A server may hold several ranges.
+func NewRanges(ranges ...Range) (*Ranges, error) {
+	r := &Ranges{ranges: append([]Range(nil), ranges...)}
+	sort.Slice(r.ranges, func(i, j int) bool { return bytes.Compare(r.ranges[i].Start, r.ranges[j].Start) < 0 })
+	if len(r.ranges) == 0 || len(r.ranges[0].Start) != 0 {
+		return nil, errors.New("no range starts with the empty key")
+	}
+	var addrs []string
+	for i, rng := range r.ranges {
+		if i > 0 && bytes.Equal(rng.Start, r.ranges[i-1].Start) {
+			return nil, errors.New("ranges have the same start " + strconv.Quote(string(rng.Start)))
+		}
+		addrs = append(addrs, rng.Addr)
+	}
+	r.shards = dedupe(addrs)
+	return r, nil
+}
+
+// Route returns the server of the last range starting at or before key
+func (r *Ranges) Route(key []byte) string {
+	i := sort.Search(len(r.ranges), func(i int) bool { return bytes.Compare(r.ranges[i].Start, key) > 0 })
+	return r.ranges[i-1].Addr
+}
+
+func (r *Ranges) Shards() []string {
+	return r.shards
+}
+
+// NewMoving returns a router for the database while Rebalance moves its keys from one router to another. Keys are
+// routed by to, and a database opened with it reads and removes keys not yet moved from the shard from routes them
+// to, so it can be read while the keys move.
+func NewMoving(from, to Router) *Moving {
+	return &Moving{From: from, To: to, shards: dedupe(append(append([]string(nil), to.Shards()...), from.Shards()...))}
+}
+
+// Route returns the shard to routes key to
+func (m *Moving) Route(key []byte) string {
+	return m.To.Route(key)
+}
+
+// Shards returns the shards of both routers
+func (m *Moving) Shards() []string {
+	return m.shards
+}
+
+// dedupe returns the distinct addresses in order
+func dedupe(addrs []string) []string {
+	seen := map[string]bool{}
+	var distinct []string
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			distinct = append(distinct, addr)
+		}
+	}
+	return distinct
+}
@@ -0,0 +1,335 @@
+// Package sharded spreads the keys of a database across several servers. A Router assigns each key to a server,
+// either by consistent hashing (Ring) or by key ranges (Ranges), and the database opened on each server is a shard.
+// The sharded database implements the keydbr interfaces, so applications use it as a single database. Rebalance
+// moves the keys when shards are added.
+package sharded
+
+import (
+	"bytes"
+	"errors"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+)
+
+var invalidTxID = errors.New("invalid tx id")
+
+var _ keydbr.Database = (*Database)(nil)
+
+// Database is a database sharded across servers. As with the databases of package client, it is not safe for
+// concurrent use.
+type Database struct {
+	router Router
+	shards map[string]*client.RemoteDatabase
+}
+
+// transaction begins a transaction on each shard when first used. A transaction spanning several shards is
+// committed on each in turn, so it is not atomic across shards.
+type transaction struct {
+	db    *Database
+	table string
+	txs   map[string]keydbr.Transaction
+	done  bool
+}
+
+// iterator merges the iterators of the shards in key order
+type iterator struct {
+	router Router
+	itrs   []keydbr.Iterator
+	// keys and values hold the next entry of each iterator, and the key is nil once the iterator is exhausted
+	keys   [][]byte
+	values [][]byte
+	err    error
+}
+
+// Open opens the database dbname on each server of the router, creating it if createIfNeeded is set
+func Open(router Router, dbname string, createIfNeeded bool, timeout int, opts ...client.Option) (*Database, error) {
+	if len(router.Shards()) == 0 {
+		return nil, errors.New("no shards")
+	}
+	db := &Database{router: router, shards: map[string]*client.RemoteDatabase{}}
+	for _, addr := range router.Shards() {
+		shard, err := client.Open(addr, dbname, createIfNeeded, timeout, opts...)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		db.shards[addr] = shard
+	}
+	return db, nil
+}
+
+// BeginTX starts a transaction on table
+func (db *Database) BeginTX(table string) (keydbr.Transaction, error) {
+	if err := keydbr.ValidateTableName(table); err != nil {
+		return nil, err
+	}
+	return &transaction{db: db, table: table, txs: map[string]keydbr.Transaction{}}, nil
+}
+
+// Close closes the database on each server, returning the first error
+func (db *Database) Close() error {
+	var first error
+	for _, addr := range db.router.Shards() {
+		shard, ok := db.shards[addr]
+		if !ok {
+			continue
+		}
+		if err := shard.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(db.shards, addr)
+	}
+	return first
+}
+
+// shard returns the transaction on the server at addr, beginning it if needed
+func (tx *transaction) shard(addr string) (keydbr.Transaction, error) {
+	if tx.done {
+		return nil, invalidTxID
+	}
+	if shardtx, ok := tx.txs[addr]; ok {
+		return shardtx, nil
+	}
+	db, ok := tx.db.shards[addr]
+	if !ok {
+		return nil, errors.New("no shard " + addr)
+	}
+	shardtx, err := db.BeginTX(tx.table)
+	if err != nil {
+		return nil, err
+	}
+	tx.txs[addr] = shardtx
+	return shardtx, nil
+}
+
+func (tx *transaction) route(key []byte) (keydbr.Transaction, error) {
+	return tx.shard(tx.db.router.Route(key))
+}
+
+// previous returns the transaction on the shard holding key before it is moved, or nil if the database is not
+// being moved or the key does not move
+func (tx *transaction) previous(key []byte) (keydbr.Transaction, error) {
+	m, ok := tx.db.router.(*Moving)
+	if !ok {
+		return nil, nil
+	}
+	addr := m.From.Route(key)
+	if addr == m.To.Route(key) {
+		return nil, nil
+	}
+	return tx.shard(addr)
+}
+
+// Get returns the value of key from its shard, or while the database is being moved, from the shard holding it
+// before the move if it has not been moved yet
+func (tx *transaction) Get(key []byte) ([]byte, error) {
+	shardtx, err := tx.route(key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := shardtx.Get(key)
+	if errors.Is(err, keydbr.KeyNotFound) {
+		if prevtx, perr := tx.previous(key); perr != nil {
+			return nil, perr
+		} else if prevtx != nil {
+			return prevtx.Get(key)
+		}
+	}
+	return value, err
+}
+
+func (tx *transaction) Put(key []byte, value []byte) error {
+	shardtx, err := tx.route(key)
+	if err != nil {
+		return err
+	}
+	return shardtx.Put(key, value)
+}
+
+func (tx *transaction) PutSync(key []byte, value []byte) error {
+	shardtx, err := tx.route(key)
+	if err != nil {
+		return err
+	}
+	return shardtx.PutSync(key, value)
+}
+
+// Remove removes key from its shard, and while the database is being moved, from the shard holding it before the
+// move, so Rebalance does not move it back
+func (tx *transaction) Remove(key []byte) ([]byte, error) {
+	shardtx, err := tx.route(key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := shardtx.Remove(key)
+	if err != nil && !errors.Is(err, keydbr.KeyNotFound) {
+		return nil, err
+	}
+	prevtx, perr := tx.previous(key)
+	if perr != nil {
+		return nil, perr
+	}
+	if prevtx != nil {
+		prevValue, perr := prevtx.Remove(key)
+		if perr != nil && !errors.Is(perr, keydbr.KeyNotFound) {
+			return nil, perr
+		}
+		if err != nil {
+			value, err = prevValue, perr
+		}
+	}
+	return value, err
+}
+
+// Lookup returns the entries from lower to upper of all shards, merged in key order. If it fails, the transactions
+// it began are rolled back, which closes their iterators, and the iterators of the transactions already open are
+// closed when the transaction ends.
+func (tx *transaction) Lookup(lower []byte, upper []byte) (keydbr.Iterator, error) {
+	var begun []string
+	itr, err := tx.lookup(lower, upper, &begun)
+	if err != nil {
+		for _, addr := range begun {
+			tx.txs[addr].Rollback()
+			delete(tx.txs, addr)
+		}
+		return nil, err
+	}
+	return itr, nil
+}
+
+// lookup opens an iterator on each shard, adding the shards whose transactions it began to begun
+func (tx *transaction) lookup(lower []byte, upper []byte, begun *[]string) (*iterator, error) {
+	itr := &iterator{router: tx.db.router}
+	for _, addr := range tx.db.router.Shards() {
+		_, open := tx.txs[addr]
+		shardtx, err := tx.shard(addr)
+		if err != nil {
+			return nil, err
+		}
+		if !open {
+			*begun = append(*begun, addr)
+		}
+		shardItr, err := shardtx.Lookup(lower, upper)
+		if err != nil {
+			return nil, err
+		}
+		itr.itrs = append(itr.itrs, shardItr)
+	}
+	itr.keys = make([][]byte, len(itr.itrs))
+	itr.values = make([][]byte, len(itr.itrs))
+	for i := range itr.itrs {
+		if err := itr.advance(i); err != nil {
+			return nil, err
+		}
+	}
+	return itr, nil
+}
+
+// commit commits the transaction on each shard in turn. If a commit fails, the shards already committed keep their
+// writes, and the rest remain open until Rollback.
+func (tx *transaction) commit(sync bool) error {
+	if tx.done {
+		return invalidTxID
+	}
+	for _, addr := range tx.db.router.Shards() {
+		shardtx, ok := tx.txs[addr]
+		if !ok {
+			continue
+		}
+		var err error
+		if sync {
+			err = shardtx.CommitSync()
+		} else {
+			err = shardtx.Commit()
+		}
+		if err != nil {
+			return err
+		}
+		delete(tx.txs, addr)
+	}
+	tx.done = true
+	return nil
+}
+
+func (tx *transaction) Commit() error {
+	return tx.commit(false)
+}
+
+func (tx *transaction) CommitSync() error {
+	return tx.commit(true)
+}
+
+// Rollback rolls back the transaction on each shard not yet committed, returning the first error
+func (tx *transaction) Rollback() error {
+	if tx.done {
+		return invalidTxID
+	}
+	var first error
+	for addr, shardtx := range tx.txs {
+		if err := shardtx.Rollback(); err != nil && first == nil {
+			first = err
+		}
+		delete(tx.txs, addr)
+	}
+	tx.done = true
+	return first
+}
+
+// advance reads the next entry of iterator i
+func (itr *iterator) advance(i int) error {
+	key, value, err := itr.itrs[i].Next()
+	if errors.Is(err, keydbr.EndOfIterator) {
+		itr.keys[i], itr.values[i] = nil, nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	itr.keys[i], itr.values[i] = key, value
+	return nil
+}
+
+// Next returns the smallest key of the shards. A key found on several shards, as while keys are being moved by
+// Rebalance, is returned once with the value from the shard it is routed to.
+func (itr *iterator) Next() (key []byte, value []byte, err error) {
+	if itr.err != nil {
+		return nil, nil, itr.err
+	}
+	var found []int
+	for i, k := range itr.keys {
+		if k == nil {
+			continue
+		}
+		if len(found) == 0 {
+			found = append(found, i)
+			continue
+		}
+		switch c := bytes.Compare(k, itr.keys[found[0]]); {
+		case c < 0:
+			found = append(found[:0], i)
+		case c == 0:
+			found = append(found, i)
+		}
+	}
+	if len(found) == 0 {
+		itr.err = keydbr.EndOfIterator
+		return nil, nil, itr.err
+	}
+
+	key, value = itr.keys[found[0]], itr.values[found[0]]
+	if len(found) > 1 {
+		addr := itr.router.Route(key)
+		for _, i := range found {
+			if itr.router.Shards()[i] == addr {
+				value = itr.values[i]
+			}
+		}
+	}
+	for _, i := range found {
+		if err := itr.advance(i); err != nil {
+			itr.err = err
+			return nil, nil, err
+		}
+	}
+	return key, value, nil
+}
@@ -0,0 +1,362 @@
+package sharded_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"github.com/robaho/keydbr/sharded"
+	"google.golang.org/grpc"
+)
+
+// startServers starts n servers on local ports with opts, returning their addresses
+func startServers(t *testing.T, n int, opts ...server.Option) []string {
+	var addrs []string
+	for i := 0; i < n; i++ {
+		dir, err := ioutil.TempDir("", "keydbr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := server.NewServer(dir, opts...)
+		s := grpc.NewServer()
+		pb.RegisterKeydbServer(s, srv)
+		go s.Serve(lis)
+		t.Cleanup(func() {
+			srv.Stop()
+			s.Stop()
+			os.RemoveAll(dir)
+		})
+		addrs = append(addrs, lis.Addr().String())
+	}
+	return addrs
+}
+
+func get(t *testing.T, tx keydbr.Transaction, key string) string {
+	t.Helper()
+	value, err := tx.Get([]byte(key))
+	if err != nil {
+		t.Fatal(key, err)
+	}
+	return string(value)
+}
+
+func key(i int) []byte {
+	return []byte(fmt.Sprintf("key%04d", i))
+}
+
+// keys returns the keys of the table on the database at addr
+func keys(t *testing.T, addr string) []string {
+	t.Helper()
+	db, err := client.Open(addr, "main", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	return scan(t, tx, nil, nil)
+}
+
+func scan(t *testing.T, tx keydbr.Transaction, lower, upper []byte) []string {
+	t.Helper()
+	itr, err := tx.Lookup(lower, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := []string{}
+	for {
+		key, value, err := itr.Next()
+		if errors.Is(err, keydbr.EndOfIterator) {
+			return found
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value) != "value "+string(key) {
+			t.Fatal("wrong value", string(key), string(value))
+		}
+		found = append(found, string(key))
+	}
+}
+
+func TestRing(t *testing.T) {
+	ring := sharded.NewRing("a", "b", "c")
+	bigger := sharded.NewRing("a", "b", "c", "d")
+	counts := map[string]int{}
+	moved := 0
+	for i := 0; i < 10000; i++ {
+		addr := ring.Route(key(i))
+		counts[addr]++
+		if to := bigger.Route(key(i)); to != addr {
+			if to != "d" {
+				t.Fatal("key moved between existing shards", addr, to)
+			}
+			moved++
+		}
+	}
+	for addr, n := range counts {
+		if n < 2000 || n > 4700 {
+			t.Fatal("keys are not spread evenly", addr, n)
+		}
+	}
+	if moved < 1500 || moved > 3500 {
+		t.Fatal("wrong number of keys moved to the new shard", moved)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	if _, err := sharded.NewRanges(sharded.Range{Start: []byte("m"), Addr: "b"}); err == nil {
+		t.Fatal("ranges should cover the empty key")
+	}
+	if _, err := sharded.NewRanges(sharded.Range{Addr: "a"}, sharded.Range{Start: []byte{}, Addr: "b"}); err == nil {
+		t.Fatal("ranges should have distinct starts")
+	}
+	ranges, err := sharded.NewRanges(sharded.Range{Start: []byte("m"), Addr: "b"}, sharded.Range{Addr: "a"},
+		sharded.Range{Start: []byte("t"), Addr: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, addr := range map[string]string{"a": "a", "l": "a", "m": "b", "sz": "b", "t": "a", "z": "a"} {
+		if ranges.Route([]byte(key)) != addr {
+			t.Fatal("wrong shard", key, ranges.Route([]byte(key)))
+		}
+	}
+	if !reflect.DeepEqual(ranges.Shards(), []string{"a", "b"}) {
+		t.Fatal("wrong shards", ranges.Shards())
+	}
+}
+
+func TestSharded(t *testing.T) {
+	addrs := startServers(t, 2)
+	ranges, err := sharded.NewRanges(sharded.Range{Addr: addrs[0]}, sharded.Range{Start: key(50), Addr: addrs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sharded.Open(ranges, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []string
+	for i := 99; i >= 0; i-- {
+		if err := tx.Put(key(i), []byte("value "+string(key(i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		expected = append(expected, string(key(i)))
+	}
+	if found := scan(t, tx, nil, nil); !reflect.DeepEqual(found, expected) {
+		t.Fatal("uncommitted keys should be merged in order", found)
+	}
+	if err := tx.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("completed transaction should be invalid")
+	}
+	if found := keys(t, addrs[1]); !reflect.DeepEqual(found, expected[50:]) {
+		t.Fatal("wrong keys on shard", found)
+	}
+
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := tx.Get(key(75)); err != nil || string(value) != "value key0075" {
+		t.Fatal("wrong value", string(value), err)
+	}
+	if _, err := tx.Remove(key(50)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Get(key(50)); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("removed key should not be found", err)
+	}
+	if found := scan(t, tx, key(45), key(54)); !reflect.DeepEqual(found, append(expected[45:50:50], expected[51:55]...)) {
+		t.Fatal("wrong range", found)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookupFailed(t *testing.T) {
+	// the second shard allows one iterator per connection, so a Lookup fails on it while another is open
+	addrs := append(startServers(t, 1), startServers(t, 1, server.WithLimits(server.Limits{MaxIterators: 1}))...)
+	db, err := sharded.Open(sharded.NewRing(addrs...), "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := tx.Put(key(i), []byte("value "+string(key(i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Lookup(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failed.Rollback()
+	if _, err := failed.Lookup(nil, nil); !errors.Is(err, keydbr.ResourceExhausted) {
+		t.Fatal("lookup should fail on the second shard", err)
+	}
+	for _, addr := range addrs {
+		sessions, err := client.ListSessions(addr, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sessions) != 1 || sessions[0].Transactions != 1 {
+			t.Fatal("the shard transactions of the failed lookup should be rolled back", addr, sessions)
+		}
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	addrs := startServers(t, 3)
+	from := sharded.NewRing(addrs[:2]...)
+	to := sharded.NewRing(addrs...)
+
+	db, err := sharded.Open(from, "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []string
+	for i := 0; i < 3000; i++ {
+		if err := tx.Put(key(i), []byte("value "+string(key(i)))); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, string(key(i)))
+	}
+	if err := tx.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the database is read with the moving router while the keys move
+	moving, err := sharded.Open(sharded.NewMoving(from, to), "main", true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer moving.Close()
+	tx, err = moving.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range expected {
+		if get(t, tx, key) != "value "+key {
+			t.Fatal("key not yet moved should be found", key)
+		}
+	}
+	if _, err := tx.Get([]byte("missing")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("missing key should not be found", err)
+	}
+	// a key removed before it is moved is not moved back
+	removed := 0
+	for to.Route(key(removed)) != addrs[2] {
+		removed++
+	}
+	if value, err := tx.Remove(key(removed)); err != nil || string(value) != "value "+string(key(removed)) {
+		t.Fatal("key not yet moved should be removed", string(value), err)
+	}
+	if err := tx.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected[:removed:removed], expected[removed+1:]...)
+
+	moved, err := sharded.Rebalance(from, to, "main", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved == 0 {
+		t.Fatal("keys should be moved to the new shard")
+	}
+	total := 0
+	for _, addr := range addrs {
+		found := keys(t, addr)
+		for _, key := range found {
+			if to.Route([]byte(key)) != addr {
+				t.Fatal("key on the wrong shard", key, addr)
+			}
+		}
+		total += len(found)
+	}
+	if found := keys(t, addrs[2]); len(found) != moved || total != len(expected) {
+		t.Fatal("wrong number of keys moved", len(found), moved, total)
+	}
+	tx, err = moving.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range expected {
+		if get(t, tx, key) != "value "+key {
+			t.Fatal("moved key should be found", key)
+		}
+	}
+	if found := scan(t, tx, nil, nil); !reflect.DeepEqual(found, expected) {
+		t.Fatal("keys should be found while moving", len(found))
+	}
+	tx.Rollback()
+
+	db, err = sharded.Open(to, "main", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found := scan(t, tx, nil, nil); !reflect.DeepEqual(found, expected) {
+		t.Fatal("keys should be found after rebalancing", len(found))
+	}
+	tx.Rollback()
+
+	if moved, err = sharded.Rebalance(to, to, "main", 10); err != nil || moved != 0 {
+		t.Fatal("balanced shards should not move keys", moved, err)
+	}
+}
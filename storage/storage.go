@@ -0,0 +1,40 @@
+// Package storage opens a local or remote database by name, so the choice can be made by configuration.
+package storage
+
+import (
+	"fmt"
+	"github.com/robaho/keydbr"
+	"github.com/robaho/keydbr/client"
+	"github.com/robaho/keydbr/embedded"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultTimeout is the timeout in seconds for opening a remote database, if the name does not set one
+const DefaultTimeout = 10
+
+// Open opens the database named by dsn, creating it if createIfNeeded is set. A name of the form
+// keydbr://host:port/dbname?timeout=seconds opens dbname on the server using client.Open with opts, and any other
+// name is the directory of a database opened in-process using embedded.Open.
+func Open(dsn string, createIfNeeded bool, opts ...client.Option) (keydbr.Database, error) {
+	if !strings.HasPrefix(dsn, "keydbr://") {
+		return embedded.Open(dsn, createIfNeeded)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	dbname := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || dbname == "" {
+		return nil, fmt.Errorf("%s: expected keydbr://host:port/dbname", dsn)
+	}
+	timeout := DefaultTimeout
+	if v := u.Query().Get("timeout"); v != "" {
+		if timeout, err = strconv.Atoi(v); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("%s: invalid timeout %q", dsn, v)
+		}
+	}
+	return client.Open(u.Host, dbname, createIfNeeded, timeout, opts...)
+}
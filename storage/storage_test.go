@@ -0,0 +1,119 @@
+package storage_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robaho/keydbr"
+	pb "github.com/robaho/keydbr/internal/proto"
+	"github.com/robaho/keydbr/server"
+	"github.com/robaho/keydbr/storage"
+	"google.golang.org/grpc"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "keydbr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// exercise uses a database the same way whether it is local or remote
+func exercise(t *testing.T, db keydbr.Database) {
+	tx, err := db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err = tx.PutSync([]byte(key), []byte("value "+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tx.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = db.BeginTX("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := tx.Get([]byte("b")); err != nil || string(value) != "value b" {
+		t.Fatal("wrong value", string(value), err)
+	}
+	if _, err := tx.Get([]byte("missing")); !errors.Is(err, keydbr.KeyNotFound) {
+		t.Fatal("missing key should not be found", err)
+	}
+	if value, err := tx.Remove([]byte("a")); err != nil || string(value) != "value a" {
+		t.Fatal("wrong removed value", string(value), err)
+	}
+	itr, err := tx.Lookup(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys string
+	for {
+		key, _, err := itr.Next()
+		if errors.Is(err, keydbr.EndOfIterator) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys += string(key)
+	}
+	if keys != "bc" {
+		t.Fatal("wrong keys", keys)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.BeginTX(".invalid"); !errors.Is(err, keydbr.InvalidTableName) {
+		t.Fatal("invalid table name should be rejected", err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	path := filepath.Join(tempDir(t), "main")
+	if _, err := storage.Open(path, false); !errors.Is(err, keydbr.NoDatabaseFound) {
+		t.Fatal("missing database should not be found", err)
+	}
+	db, err := storage.Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, db)
+}
+
+func TestRemote(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	pb.RegisterKeydbServer(gs, server.NewServer(tempDir(t)))
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	dsn := "keydbr://" + lis.Addr().String() + "/test/main"
+	if _, err := storage.Open(dsn, false); !errors.Is(err, keydbr.NoDatabaseFound) {
+		t.Fatal("missing database should not be found", err)
+	}
+	db, err := storage.Open(dsn+"?timeout=5", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, db)
+
+	if _, err := storage.Open("keydbr://"+lis.Addr().String(), true); err == nil {
+		t.Fatal("name without a database should be rejected")
+	}
+}
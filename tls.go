@@ -0,0 +1,20 @@
+package keydbr
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+)
+
+// LoadCertPool reads a PEM encoded file containing one or more CA certificates
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + file)
+	}
+	return pool, nil
+}